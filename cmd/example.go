@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/config"
+	"github.com/dotenvy-dev/dotenvy/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exampleOutput string
+	exampleCheck  bool
+)
+
+var exampleCmd = &cobra.Command{
+	Use:   "example",
+	Short: "Generate .env.example from the schema",
+	Long: `Write a .env.example file listing every secret in dotenvy.yaml.
+
+Descriptions become comments, examples become placeholder values, and
+secrets are grouped and ordered as they are in the schema. Add metadata by
+writing a secrets entry as a mapping:
+
+  secrets:
+    - API_KEY
+    - name: DATABASE_URL
+      description: Postgres connection string
+      group: Database
+      example: postgres://localhost:5432/app
+
+Examples:
+  # Write .env.example
+  dotenvy example
+
+  # Fail if .env.example is out of date (for CI)
+  dotenvy example --check
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExample(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	exampleCmd.Flags().StringVarP(&exampleOutput, "output", "o", source.DefaultExampleFile, "Output file")
+	exampleCmd.Flags().BoolVar(&exampleCheck, "check", false, "Exit non-zero if the file is out of date instead of writing it")
+	rootCmd.AddCommand(exampleCmd)
+}
+
+func runExample() error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	schema := cfg.GetSchema()
+
+	if exampleCheck {
+		drift, err := source.CheckExample(exampleOutput, schema)
+		if err != nil {
+			return err
+		}
+		if !drift.HasDrift() {
+			fmt.Printf("%s %s is up to date\n", successStyle.Render("✓"), exampleOutput)
+			return nil
+		}
+
+		fmt.Printf("%s %s is out of date with %s\n", errorStyle.Render("✗"), exampleOutput, cfgFile)
+		if len(drift.Missing) > 0 {
+			fmt.Printf("  missing: %s\n", strings.Join(drift.Missing, ", "))
+		}
+		if len(drift.Stale) > 0 {
+			fmt.Printf("  not in schema: %s\n", strings.Join(drift.Stale, ", "))
+		}
+		if drift.Content {
+			fmt.Println("  comments, grouping or example values differ")
+		}
+		fmt.Println(mutedStyle.Render("  Run 'dotenvy example' to regenerate"))
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(exampleOutput, []byte(source.RenderExample(schema)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exampleOutput, err)
+	}
+
+	fmt.Printf("Wrote %d secrets to %s\n", len(schema), exampleOutput)
+	return nil
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/config"
+	"github.com/dotenvy-dev/dotenvy/internal/guard"
+	"github.com/dotenvy-dev/dotenvy/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var (
+	guardStaged    bool
+	guardFrom      []string
+	guardMinLength int
+)
+
+var guardCmd = &cobra.Command{
+	Use:   "guard [path]",
+	Short: "Block commits that contain secret values",
+	Long: `Scan files for any value from your env files and check that env files
+are ignored by git.
+
+Values are reduced to SHA-256 fingerprints as soon as they are read, and
+files are matched against the fingerprints - no plaintext is written or
+printed. Values shorter than --min-length are not guarded.
+
+Examples:
+  # Scan files staged for commit (what the pre-commit hook runs)
+  dotenvy guard --staged
+
+  # Scan a directory
+  dotenvy guard ./src
+
+  # Install as a git pre-commit hook
+  dotenvy guard install
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ""
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if err := runGuard(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var guardInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install dotenvy guard as a git pre-commit hook",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGuardInstall(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	guardCmd.Flags().BoolVar(&guardStaged, "staged", false, "Scan files staged for commit (default when no path is given)")
+	guardCmd.Flags().StringSliceVarP(&guardFrom, "from", "f", []string{".env.test", ".env.live"}, "Env file(s) whose values to guard")
+	guardCmd.Flags().IntVar(&guardMinLength, "min-length", guard.DefaultMinLength, "Shortest value to guard")
+	guardCmd.AddCommand(guardInstallCmd)
+	rootCmd.AddCommand(guardCmd)
+}
+
+func runGuard(path string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Fingerprint schema values from each env file
+	fingerprints := guard.NewFingerprints(guardMinLength)
+	var loaded []string
+	for _, file := range guardFrom {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		values, err := source.NewFileSource(file).ListAll()
+		if err != nil {
+			return err
+		}
+		for _, name := range cfg.GetSecretNames() {
+			if v, ok := values[name]; ok {
+				fingerprints.Add(fmt.Sprintf("%s (%s)", name, file), v)
+			}
+		}
+		loaded = append(loaded, file)
+	}
+
+	problems := 0
+
+	// Env files must be ignored by git
+	envFiles, _ := filepath.Glob(".env*")
+	for _, file := range envFiles {
+		if !guard.IsEnvFile(file) {
+			continue
+		}
+		ignored, err := guard.IsIgnored(file)
+		if err != nil {
+			fmt.Printf("%s could not check %s against .gitignore: %v\n", mutedStyle.Render("?"), file, err)
+			continue
+		}
+		if !ignored {
+			fmt.Printf("%s %s is not listed in .gitignore\n", errorStyle.Render("✗"), file)
+			problems++
+		}
+	}
+
+	// Collect files to scan
+	var findings []guard.Finding
+	scanned := 0
+	if path == "" || guardStaged {
+		staged, err := guard.StagedFiles()
+		if err != nil {
+			return err
+		}
+		for _, file := range staged {
+			if guard.IsEnvFile(file) {
+				fmt.Printf("%s %s is staged for commit\n", errorStyle.Render("✗"), file)
+				problems++
+				continue
+			}
+			content, err := guard.StagedContent(file)
+			if err != nil {
+				return err
+			}
+			scanned++
+			findings = append(findings, fingerprints.Scan(file, content)...)
+		}
+	} else {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name := d.Name(); p != path && (name == ".git" || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if guard.IsEnvFile(p) {
+				return nil
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			scanned++
+			findings = append(findings, fingerprints.Scan(p, content)...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	for _, f := range findings {
+		fmt.Printf("%s %s:%d contains the value of %s\n",
+			errorStyle.Render("✗"), f.File, f.Line, strings.Join(f.Labels, ", "))
+	}
+	problems += len(findings)
+
+	if problems > 0 {
+		fmt.Println()
+		fmt.Println(mutedStyle.Render("Remove the values above before committing."))
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s No secrets found in %d files (%d values guarded from %s)\n",
+		successStyle.Render("✓"), scanned, fingerprints.Len(), strings.Join(loaded, ", "))
+	return nil
+}
+
+func runGuardInstall() error {
+	path, installed, err := guard.InstallHook()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		fmt.Printf("dotenvy guard is already installed in %s\n", path)
+		return nil
+	}
+	fmt.Printf("Installed dotenvy guard in %s\n", path)
+	return nil
+}
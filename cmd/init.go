@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/charmbracelet/huh"
 	"github.com/dotenvy-dev/dotenvy/internal/config"
@@ -69,6 +70,14 @@ func runInit() error {
 		}
 	}
 
+	// Detect project identifiers from platform config files
+	found := detect.FromProjectFiles(".")
+	if len(found) > 0 {
+		fmt.Println()
+		fmt.Print(tui.RenderFileDetections(found))
+		fmt.Println()
+	}
+
 	// Build provider options with pre-selection based on detection
 	detected := make(map[string]bool)
 	if detectionResult != nil {
@@ -76,6 +85,9 @@ func runInit() error {
 			detected[p] = true
 		}
 	}
+	for _, p := range found.Providers() {
+		detected[p] = true
+	}
 
 	var providers []string
 	options := []huh.Option[string]{
@@ -108,11 +120,11 @@ func runInit() error {
 	for _, p := range providers {
 		switch p {
 		case "vercel":
-			if err := configureVercel(cfg); err != nil {
+			if err := configureVercel(cfg, found); err != nil {
 				return err
 			}
 		case "convex":
-			if err := configureConvex(cfg); err != nil {
+			if err := configureConvex(cfg, found); err != nil {
 				return err
 			}
 		case "railway":
@@ -120,19 +132,19 @@ func runInit() error {
 				return err
 			}
 		case "render":
-			if err := configureRender(cfg); err != nil {
+			if err := configureRender(cfg, found); err != nil {
 				return err
 			}
 		case "supabase":
-			if err := configureSupabase(cfg); err != nil {
+			if err := configureSupabase(cfg, found); err != nil {
 				return err
 			}
 		case "netlify":
-			if err := configureNetlify(cfg); err != nil {
+			if err := configureNetlify(cfg, found); err != nil {
 				return err
 			}
 		case "flyio":
-			if err := configureFlyio(cfg); err != nil {
+			if err := configureFlyio(cfg, found); err != nil {
 				return err
 			}
 		case "dotenv":
@@ -196,14 +208,33 @@ func runInit() error {
 	return nil
 }
 
-func configureVercel(cfg *config.Config) error {
-	var project string
+// prefill seeds a prompt value from a detected project file and returns a
+// description telling the user where it came from.
+func prefill(found detect.FileMatches, providerName, field string, value *string) string {
+	m, ok := found.Lookup(providerName, field)
+	if !ok {
+		return ""
+	}
+	*value = m.Value
+	return fmt.Sprintf("Detected %s from %s (%s: %s)", m.Value, m.File, m.Confidence, m.Reason)
+}
+
+func configureVercel(cfg *config.Config, found detect.FileMatches) error {
+	var project, teamID string
+	projectHint := prefill(found, "vercel", "project", &project)
+	teamHint := prefill(found, "vercel", "team_id", &teamID)
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Vercel project name").
+				Description(projectHint).
 				Placeholder("my-app").
 				Value(&project),
+			huh.NewInput().
+				Title("Vercel team ID (leave empty for personal projects)").
+				Description(teamHint).
+				Placeholder("team_...").
+				Value(&teamID),
 		),
 	)
 	if err := form.Run(); err != nil {
@@ -213,6 +244,7 @@ func configureVercel(cfg *config.Config) error {
 	cfg.AddTarget("vercel", &config.TargetDef{
 		Type:    "vercel",
 		Project: project,
+		TeamID:  teamID,
 		Mapping: map[string]string{
 			"development": "test",
 			"preview":     "test",
@@ -227,17 +259,21 @@ func configureVercel(cfg *config.Config) error {
 	return nil
 }
 
-func configureConvex(cfg *config.Config) error {
+func configureConvex(cfg *config.Config, found detect.FileMatches) error {
 	var devDeployment, prodDeployment string
+	devHint := prefill(found, "convex", "deployment", &devDeployment)
+	prodHint := prefill(found, "convex", "prod_deployment", &prodDeployment)
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Convex dev deployment name").
+				Description(devHint).
 				Placeholder("my-app-dev").
 				Value(&devDeployment),
 			huh.NewInput().
 				Title("Convex prod deployment name (leave empty to skip)").
+				Description(prodHint).
 				Placeholder("my-app-prod").
 				Value(&prodDeployment),
 		),
@@ -312,12 +348,18 @@ func configureRailway(cfg *config.Config) error {
 	return nil
 }
 
-func configureRender(cfg *config.Config) error {
+func configureRender(cfg *config.Config, found detect.FileMatches) error {
 	var serviceID string
+	title := "Render service ID"
+	// render.yaml is the only file detected for Render
+	hasBlueprint := slices.Contains(found.Providers(), "render")
+	if hasBlueprint {
+		title = "Render service ID (leave empty to use every service in render.yaml)"
+	}
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
-				Title("Render service ID").
+				Title(title).
 				Placeholder("srv-abc123def456").
 				Value(&serviceID),
 		),
@@ -326,13 +368,17 @@ func configureRender(cfg *config.Config) error {
 		return err
 	}
 
-	cfg.AddTarget("render", &config.TargetDef{
+	def := &config.TargetDef{
 		Type:      "render",
 		ServiceID: serviceID,
 		Mapping: map[string]string{
 			"default": "test",
 		},
-	})
+	}
+	if serviceID == "" && hasBlueprint {
+		def.Blueprint = "render.yaml"
+	}
+	cfg.AddTarget("render", def)
 
 	fmt.Println("\nRender authentication:")
 	fmt.Println("  Set RENDER_API_KEY environment variable, or add 'token:' to config")
@@ -341,12 +387,14 @@ func configureRender(cfg *config.Config) error {
 	return nil
 }
 
-func configureSupabase(cfg *config.Config) error {
+func configureSupabase(cfg *config.Config, found detect.FileMatches) error {
 	var projectRef string
+	refHint := prefill(found, "supabase", "project_ref", &projectRef)
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Supabase project ref").
+				Description(refHint).
 				Placeholder("abcdefghijklmnop").
 				Value(&projectRef),
 		),
@@ -371,8 +419,9 @@ func configureSupabase(cfg *config.Config) error {
 	return nil
 }
 
-func configureNetlify(cfg *config.Config) error {
+func configureNetlify(cfg *config.Config, found detect.FileMatches) error {
 	var accountID, siteID string
+	siteHint := prefill(found, "netlify", "site_id", &siteID)
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
@@ -381,6 +430,7 @@ func configureNetlify(cfg *config.Config) error {
 				Value(&accountID),
 			huh.NewInput().
 				Title("Netlify site ID (leave empty for account-level)").
+				Description(siteHint).
 				Placeholder("abc123-def456").
 				Value(&siteID),
 		),
@@ -412,12 +462,14 @@ func configureNetlify(cfg *config.Config) error {
 	return nil
 }
 
-func configureFlyio(cfg *config.Config) error {
+func configureFlyio(cfg *config.Config, found detect.FileMatches) error {
 	var appName string
+	appHint := prefill(found, "flyio", "app_name", &appName)
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Fly.io app name").
+				Description(appHint).
 				Placeholder("my-app-staging").
 				Value(&appName),
 		),
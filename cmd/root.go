@@ -10,16 +10,29 @@ import (
 	"github.com/dotenvy-dev/dotenvy/internal/tui"
 
 	// Register providers
+	_ "github.com/dotenvy-dev/dotenvy/providers/awsecs"
+	_ "github.com/dotenvy-dev/dotenvy/providers/awslambda"
 	_ "github.com/dotenvy-dev/dotenvy/providers/awssm"
 	_ "github.com/dotenvy-dev/dotenvy/providers/awsssm"
+	_ "github.com/dotenvy-dev/dotenvy/providers/azurekv"
+	_ "github.com/dotenvy-dev/dotenvy/providers/cloudflare"
 	_ "github.com/dotenvy-dev/dotenvy/providers/convex"
+	_ "github.com/dotenvy-dev/dotenvy/providers/digitalocean"
+	_ "github.com/dotenvy-dev/dotenvy/providers/doppler"
 	_ "github.com/dotenvy-dev/dotenvy/providers/dotenv"
 	_ "github.com/dotenvy-dev/dotenvy/providers/flyio"
 	_ "github.com/dotenvy-dev/dotenvy/providers/gcpsm"
+	_ "github.com/dotenvy-dev/dotenvy/providers/githubactions"
+	_ "github.com/dotenvy-dev/dotenvy/providers/gitlab"
+	_ "github.com/dotenvy-dev/dotenvy/providers/heroku"
+	_ "github.com/dotenvy-dev/dotenvy/providers/infisical"
+	_ "github.com/dotenvy-dev/dotenvy/providers/koyeb"
+	_ "github.com/dotenvy-dev/dotenvy/providers/kubernetes"
 	_ "github.com/dotenvy-dev/dotenvy/providers/netlify"
 	_ "github.com/dotenvy-dev/dotenvy/providers/railway"
 	_ "github.com/dotenvy-dev/dotenvy/providers/render"
 	_ "github.com/dotenvy-dev/dotenvy/providers/supabase"
+	_ "github.com/dotenvy-dev/dotenvy/providers/vault"
 	_ "github.com/dotenvy-dev/dotenvy/providers/vercel"
 )
 
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dotenvy-dev/dotenvy/internal/config"
+	"github.com/dotenvy-dev/dotenvy/internal/scan"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scanIgnore []string
+	scanStrict bool
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [path]",
+	Short: "Find env vars read in code but missing from the schema",
+	Long: `Walk the source tree and compare env var references against dotenvy.yaml.
+
+Recognised access patterns:
+  process.env.X, process.env["X"], import.meta.env.X   (JavaScript/TypeScript)
+  os.Getenv("X"), os.LookupEnv("X")                     (Go)
+  os.environ["X"], os.environ.get("X"), os.getenv("X")  (Python)
+  ENV["X"], ENV.fetch("X")                              (Ruby)
+  env("X")                                              (PHP/Laravel and others)
+
+Exits with status 1 when code reads a key that is not in the schema, so it
+can run in CI. With --strict, schema keys that no code reads also fail.
+
+Examples:
+  # Scan the current directory
+  dotenvy scan
+
+  # Scan a subdirectory and ignore framework-provided keys
+  dotenvy scan ./web --ignore "VERCEL_*" --ignore CI
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		if err := runScan(root); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	scanCmd.Flags().StringSliceVar(&scanIgnore, "ignore", nil, "Glob pattern(s) for keys to ignore")
+	scanCmd.Flags().BoolVar(&scanStrict, "strict", false, "Also fail when schema keys are never referenced")
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(root string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	result, err := scan.Dir(root)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	ignore := append(append([]string{}, scan.DefaultIgnore...), scanIgnore...)
+	report := result.Compare(cfg.GetSecretNames(), ignore)
+
+	fmt.Println(titleStyle.Render("dotenvy scan"))
+	fmt.Printf("Scanned %d files, found %d referenced keys\n\n", result.Files, len(result.References))
+
+	if len(report.Missing) > 0 {
+		fmt.Printf("%s Used in code but not in %s (%d):\n", errorStyle.Render("✗"), cfgFile, len(report.Missing))
+		for _, key := range report.Missing {
+			fmt.Printf("  %s\n", key)
+			for _, loc := range result.References[key] {
+				fmt.Printf("    %s\n", mutedStyle.Render(fmt.Sprintf("%s:%d", loc.File, loc.Line)))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(report.Unused) > 0 {
+		icon := mutedStyle.Render("○")
+		if scanStrict {
+			icon = errorStyle.Render("✗")
+		}
+		fmt.Printf("%s In %s but never referenced (%d):\n", icon, cfgFile, len(report.Unused))
+		for _, key := range report.Unused {
+			fmt.Printf("  %s\n", key)
+		}
+		fmt.Println()
+	}
+
+	if len(report.Missing) == 0 && len(report.Unused) == 0 {
+		fmt.Printf("%s Schema and code agree\n", successStyle.Render("✓"))
+		return nil
+	}
+
+	if len(report.Missing) > 0 || scanStrict {
+		if len(report.Missing) > 0 {
+			fmt.Println(mutedStyle.Render("Run 'dotenvy add NAME...' to track missing keys"))
+		}
+		os.Exit(1)
+	}
+
+	return nil
+}
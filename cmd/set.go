@@ -8,6 +8,7 @@ import (
 	"github.com/dotenvy-dev/dotenvy/internal/api"
 	"github.com/dotenvy-dev/dotenvy/internal/config"
 	"github.com/dotenvy-dev/dotenvy/internal/source"
+	"github.com/dotenvy-dev/dotenvy/internal/sync"
 	"github.com/dotenvy-dev/dotenvy/internal/tui"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -17,6 +18,8 @@ var (
 	setEnv    string
 	setDryRun bool
 	setPlain  bool
+
+	setNoCrossCheck bool
 )
 
 var setCmd = &cobra.Command{
@@ -55,6 +58,7 @@ func init() {
 	setCmd.Flags().StringVarP(&setEnv, "env", "e", "test", "Environment (test or live)")
 	setCmd.Flags().BoolVar(&setDryRun, "dry-run", false, "Preview changes without applying")
 	setCmd.Flags().BoolVar(&setPlain, "plain", false, "Plain text output (no TUI)")
+	setCmd.Flags().BoolVar(&setNoCrossCheck, "no-cross-check", false, "Don't compare values against the other environment")
 	rootCmd.AddCommand(setCmd)
 }
 
@@ -140,6 +144,11 @@ func runSet(args []string) error {
 		setSecretNames = append(setSecretNames, name)
 	}
 
+	var crossCheck *sync.CrossCheck
+	if !setNoCrossCheck {
+		crossCheck = buildCrossCheck(cfg, setEnv)
+	}
+
 	// Use plain output if not a TTY
 	usePlain := setPlain || !term.IsTerminal(int(os.Stdout.Fd())) || os.Getenv("CI") != ""
 
@@ -147,7 +156,7 @@ func runSet(args []string) error {
 		// Reuse the sync plain logic
 		syncEnv = setEnv
 		syncDryRun = setDryRun
-		return runSyncPlain(cfg, src, targets, secretNames, apiClient)
+		return runSyncPlain(cfg, src, targets, secretNames, apiClient, crossCheck)
 	}
 
 	err = tui.RunSyncUI(tui.SyncConfig{
@@ -156,6 +165,7 @@ func runSet(args []string) error {
 		Tasks:       tasks,
 		DryRun:      setDryRun,
 		LocalEnv:    setEnv,
+		CrossCheck:  crossCheck,
 	})
 	if err != nil {
 		return err
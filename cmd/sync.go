@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dotenvy-dev/dotenvy/internal/api"
 	"github.com/dotenvy-dev/dotenvy/internal/config"
+	"github.com/dotenvy-dev/dotenvy/internal/guard"
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/internal/source"
 	"github.com/dotenvy-dev/dotenvy/internal/sync"
@@ -24,6 +25,9 @@ var (
 	syncDryRun  bool
 	syncTargets []string
 	syncPlain   bool
+
+	syncNoCrossCheck bool
+	syncBlockLeaks   bool
 )
 
 var syncCmd = &cobra.Command{
@@ -49,6 +53,14 @@ Examples:
 
   # Plain output (no animations)
   dotenvy sync test --plain
+
+  # Refuse to sync live if any value matches .env.test
+  dotenvy sync live --block-leaks
+
+When syncing test or live, values are compared against the other
+environment's env file (.env.live or .env.test). Keys with the same value in
+both, or with a value from a different key in the other file, are flagged.
+Mark keys that are meant to be shared with allow_shared: true in the schema.
 `,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -68,6 +80,8 @@ func init() {
 	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Preview changes without applying")
 	syncCmd.Flags().StringSliceVarP(&syncTargets, "to", "t", nil, "Target(s) to sync to (default: all)")
 	syncCmd.Flags().BoolVar(&syncPlain, "plain", false, "Plain text output (no TUI)")
+	syncCmd.Flags().BoolVar(&syncNoCrossCheck, "no-cross-check", false, "Don't compare values against the other environment")
+	syncCmd.Flags().BoolVar(&syncBlockLeaks, "block-leaks", false, "Don't sync a target if any value is shared with the other environment")
 	rootCmd.AddCommand(syncCmd)
 }
 
@@ -167,6 +181,39 @@ func inferEnvFromFilename(filename string) string {
 	return ""
 }
 
+// otherEnvironments pairs environments whose values should never overlap
+var otherEnvironments = map[string]string{
+	"test": "live",
+	"live": "test",
+}
+
+// buildCrossCheck loads the other environment's env file for cross-checking.
+// Returns nil if the environment has no counterpart or its file is missing.
+func buildCrossCheck(cfg *config.Config, env string) *sync.CrossCheck {
+	other, ok := otherEnvironments[env]
+	if !ok {
+		return nil
+	}
+	file := fmt.Sprintf(".env.%s", other)
+	if _, err := os.Stat(file); err != nil {
+		return nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, s := range cfg.GetSchema() {
+		if s.AllowShared {
+			allowed[s.Name] = true
+		}
+	}
+
+	return &sync.CrossCheck{
+		Environment: other,
+		Source:      source.NewFileSource(file),
+		Allowed:     allowed,
+		MinLength:   guard.DefaultMinLength,
+	}
+}
+
 func runSync(args []string) error {
 	// Resolve environment and file from arguments
 	env, file, err := resolveEnvAndFile(args)
@@ -197,6 +244,11 @@ func runSync(args []string) error {
 	// Use resolved env
 	syncEnv = env
 
+	var crossCheck *sync.CrossCheck
+	if !syncNoCrossCheck {
+		crossCheck = buildCrossCheck(cfg, env)
+	}
+
 	// Get targets
 	allTargets := cfg.GetTargets()
 	var targets []model.Target
@@ -249,7 +301,7 @@ func runSync(args []string) error {
 	apiClient := api.NewClient(cfg.APIKey, cfg.APIURL)
 
 	if usePlain {
-		return runSyncPlain(cfg, src, targets, secretNames, apiClient)
+		return runSyncPlain(cfg, src, targets, secretNames, apiClient, crossCheck)
 	}
 
 	// Run the fancy TUI
@@ -259,6 +311,8 @@ func runSync(args []string) error {
 		Tasks:       tasks,
 		DryRun:      syncDryRun,
 		LocalEnv:    syncEnv,
+		CrossCheck:  crossCheck,
+		BlockLeaks:  syncBlockLeaks,
 	})
 	if err != nil {
 		return err
@@ -285,10 +339,11 @@ func isTerminal() bool {
 }
 
 // runSyncPlain runs sync with plain text output (no TUI)
-func runSyncPlain(cfg *config.Config, src source.Source, targets []model.Target, secretNames []string, apiClient *api.Client) error {
+func runSyncPlain(cfg *config.Config, src source.Source, targets []model.Target, secretNames []string, apiClient *api.Client, crossCheck *sync.CrossCheck) error {
 	// Check auth for all targets
 	fmt.Println(headerStyle.Render("Checking authentication..."))
 	engine := sync.NewEngine()
+	engine.SetCrossCheck(crossCheck)
 	allAuth := true
 	for _, t := range targets {
 		if t.Type == "dotenv" {
@@ -313,7 +368,11 @@ func runSyncPlain(cfg *config.Config, src source.Source, targets []model.Target,
 	fmt.Println()
 	fmt.Println(headerStyle.Render("Syncing secrets..."))
 	fmt.Printf("Source: %s\n", src.Name())
-	fmt.Printf("Environment: %s\n\n", syncEnv)
+	fmt.Printf("Environment: %s\n", syncEnv)
+	if crossCheck != nil {
+		fmt.Printf("Cross-check: %s\n", crossCheck.Source.Name())
+	}
+	fmt.Println()
 
 	var totalAdded, totalChanged, totalUnknown, totalUnchanged, totalFailed int
 
@@ -336,6 +395,21 @@ func runSyncPlain(cfg *config.Config, src source.Source, targets []model.Target,
 				continue
 			}
 
+			// Warnings apply to unchanged values too
+			warnings := diff.Warnings()
+			for _, d := range warnings {
+				fmt.Printf("  %s %s (%s)\n", changeStyle.Render("!"), d.Name, d.Warning)
+			}
+			if len(warnings) > 0 && syncBlockLeaks {
+				if syncDryRun {
+					fmt.Printf("  %s would be blocked: %d value(s) shared with %s\n", changeStyle.Render("!"), len(warnings), crossCheck.Environment)
+				} else {
+					fmt.Printf("  %s blocked: %d value(s) shared with %s\n", errorStyle.Render("✗"), len(warnings), crossCheck.Environment)
+					totalFailed++
+					continue
+				}
+			}
+
 			// Show diff
 			if !diff.HasChanges() {
 				fmt.Printf("  %s\n", unchangedStyle.Render("No changes"))
@@ -364,7 +438,7 @@ func runSyncPlain(cfg *config.Config, src source.Source, targets []model.Target,
 			}
 
 			// Apply
-			result, err := engine.Sync(ctx, secretNames, src, target, remoteEnv, sync.SyncOptions{})
+			result, err := engine.Sync(ctx, secretNames, src, target, remoteEnv, sync.SyncOptions{BlockOnWarnings: syncBlockLeaks})
 			if err != nil {
 				fmt.Printf("  %s %v\n", errorStyle.Render("✗"), err)
 				totalFailed++
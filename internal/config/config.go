@@ -19,8 +19,90 @@ type Config struct {
 	Version int                   `yaml:"version"`
 	APIKey  string                `yaml:"api_key,omitempty"`
 	APIURL  string                `yaml:"api_url,omitempty"`
-	Secrets []string              `yaml:"secrets"`           // Just names, no values
+	Secrets []string              `yaml:"secrets"` // Just names, no values
 	Targets map[string]*TargetDef `yaml:"targets,omitempty"`
+
+	// Schema holds optional per-secret metadata, keyed by name. Secrets
+	// written as bare names in the secrets list have no entry.
+	Schema map[string]model.Secret `yaml:"-"`
+}
+
+// configFile is the on-disk shape of Config. Entries in the secrets list may
+// be bare names or mappings with metadata.
+type configFile struct {
+	Version int                   `yaml:"version"`
+	APIKey  string                `yaml:"api_key,omitempty"`
+	APIURL  string                `yaml:"api_url,omitempty"`
+	Secrets []secretEntry         `yaml:"secrets"`
+	Targets map[string]*TargetDef `yaml:"targets,omitempty"`
+}
+
+// secretEntry is a single item in the secrets list
+type secretEntry struct {
+	model.Secret
+}
+
+// UnmarshalYAML accepts either "NAME" or {name: NAME, description: ...}
+func (e *secretEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&e.Name)
+	}
+	if err := node.Decode(&e.Secret); err != nil {
+		return err
+	}
+	if e.Name == "" {
+		return fmt.Errorf("line %d: secret entry is missing a name", node.Line)
+	}
+	return nil
+}
+
+// MarshalYAML writes bare names for secrets without metadata
+func (e secretEntry) MarshalYAML() (any, error) {
+	if !e.HasMetadata() {
+		return e.Name, nil
+	}
+	return e.Secret, nil
+}
+
+// UnmarshalYAML decodes the file form and splits names from metadata
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	var raw configFile
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	c.Version = raw.Version
+	c.APIKey = raw.APIKey
+	c.APIURL = raw.APIURL
+	c.Targets = raw.Targets
+	c.Secrets = make([]string, 0, len(raw.Secrets))
+	c.Schema = nil
+	for _, e := range raw.Secrets {
+		c.Secrets = append(c.Secrets, e.Name)
+		if e.HasMetadata() {
+			if c.Schema == nil {
+				c.Schema = make(map[string]model.Secret)
+			}
+			c.Schema[e.Name] = e.Secret
+		}
+	}
+
+	return nil
+}
+
+// MarshalYAML merges metadata back into the secrets list
+func (c Config) MarshalYAML() (any, error) {
+	raw := configFile{
+		Version: c.Version,
+		APIKey:  c.APIKey,
+		APIURL:  c.APIURL,
+		Secrets: make([]secretEntry, 0, len(c.Secrets)),
+		Targets: c.Targets,
+	}
+	for _, name := range c.Secrets {
+		raw.Secrets = append(raw.Secrets, secretEntry{Secret: c.Secret(name)})
+	}
+	return raw, nil
 }
 
 // TargetDef represents a target definition in the config file
@@ -32,18 +114,80 @@ type TargetDef struct {
 	ServiceID  string            `yaml:"service_id,omitempty"`
 	ProjectRef string            `yaml:"project_ref,omitempty"`
 	AccountID  string            `yaml:"account_id,omitempty"`
+	TeamID     string            `yaml:"team_id,omitempty"` // Vercel team owning the project
 	AppName    string            `yaml:"app_name,omitempty"`
+	AppID      string            `yaml:"app_id,omitempty"` // DigitalOcean app ID
 	SiteID     string            `yaml:"site_id,omitempty"`
 	Path       string            `yaml:"path,omitempty"`        // For dotenv targets
 	Region     string            `yaml:"region,omitempty"`      // AWS region
-	Prefix     string            `yaml:"prefix,omitempty"`      // Key prefix (SSM path or GCP prefix)
+	Prefix     string            `yaml:"prefix,omitempty"`      // Key prefix (SSM path, Secrets Manager name prefix or GCP prefix)
 	Profile    string            `yaml:"profile,omitempty"`     // AWS profile name
-	SecretName string            `yaml:"secret_name,omitempty"` // AWS Secrets Manager secret name
+	SecretName string            `yaml:"secret_name,omitempty"` // AWS Secrets Manager or Kubernetes Secret name
+	Address    string            `yaml:"address,omitempty"`     // Server URL (Vault)
+	Namespace  string            `yaml:"namespace,omitempty"`   // Vault Enterprise or Kubernetes namespace
+	Mount      string            `yaml:"mount,omitempty"`       // Secrets engine mount (Vault)
+	RoleID     string            `yaml:"role_id,omitempty"`     // Vault AppRole role ID
+	SecretID   string            `yaml:"secret_id,omitempty"`   // Vault AppRole secret ID
+	Script     string            `yaml:"script,omitempty"`      // Cloudflare Worker name
+	Pipeline   string            `yaml:"pipeline,omitempty"`    // Heroku pipeline name or ID
+	Config     string            `yaml:"config,omitempty"`      // Doppler config name
+	Component  string            `yaml:"component,omitempty"`   // DigitalOcean app component (default: app-level)
 	Mapping    map[string]string `yaml:"mapping"`
 	Include    []string          `yaml:"include,omitempty,flow"` // Glob patterns
 	Exclude    []string          `yaml:"exclude,omitempty,flow"` // Glob patterns
 	Token      string            `yaml:"token,omitempty"`
 	DeployKey  string            `yaml:"deploy_key,omitempty"`
+
+	// Azure Key Vault
+	VaultURL      string `yaml:"vault_url,omitempty"` // e.g. https://myapp.vault.azure.net
+	TenantID      string `yaml:"tenant_id,omitempty"` // Service principal (or AZURE_* env vars)
+	ClientID      string `yaml:"client_id,omitempty"`
+	ClientSecret  string `yaml:"client_secret,omitempty"`
+	PurgeOnDelete bool   `yaml:"purge_on_delete,omitempty"` // Also purge secrets from the recycle bin (Azure) or skip the recovery window (AWS Secrets Manager)
+
+	// AWS SSM and Secrets Manager
+	KMSKeyID string            `yaml:"kms_key_id,omitempty"` // KMS key for encrypted values (default: AWS managed key)
+	Tags     map[string]string `yaml:"tags,omitempty"`       // Applied to written values (GCP labels); {env} expands to the environment
+
+	// GCP Secret Manager
+	Locations    []string `yaml:"locations,omitempty,flow"` // User-managed replication locations (default: automatic)
+	KeepVersions int      `yaml:"keep_versions,omitempty"`  // Prune versions beyond the newest N after each write
+	OldVersions  string   `yaml:"old_versions,omitempty"`   // What pruning does: disable (default) or destroy
+
+	// Netlify
+	Scopes []string `yaml:"scopes,omitempty,flow"` // builds, functions, runtime, post-processing (default: all)
+
+	// Railway
+	Services           []string `yaml:"services,omitempty,flow"`       // Service names or IDs; "shared" for shared variables
+	CreateEnvironments bool     `yaml:"create_environments,omitempty"` // Create missing environments on the first write
+
+	// Render
+	EnvGroup    string `yaml:"env_group,omitempty"`    // Environment group ID or name
+	Blueprint   string `yaml:"blueprint,omitempty"`    // Write to every service in this render.yaml
+	DeferDeploy bool   `yaml:"defer_deploy,omitempty"` // Update each service at once, then deploy it
+
+	// AWS Lambda and ECS
+	Function       string `yaml:"function,omitempty"`        // Lambda function name or ARN
+	Qualifier      string `yaml:"qualifier,omitempty"`       // $LATEST, alias or version (default: environment name)
+	TaskDefinition string `yaml:"task_definition,omitempty"` // ECS task definition family
+	Container      string `yaml:"container,omitempty"`       // ECS container (default: the only one)
+
+	// Kubernetes
+	Context      string `yaml:"context,omitempty"`       // kubeconfig context (default: current-context)
+	Kubeconfig   string `yaml:"kubeconfig,omitempty"`    // Default: $KUBECONFIG or ~/.kube/config
+	Mode         string `yaml:"mode,omitempty"`          // api (default) or file
+	SealedSecret string `yaml:"sealed_secret,omitempty"` // Annotate manifests for kubeseal with this scope
+
+	// CI platforms (GitHub Actions, GitLab)
+	Repository string `yaml:"repository,omitempty"` // owner/name
+	Org        string `yaml:"org,omitempty"`        // Organization for org-level secrets
+	Group      string `yaml:"group,omitempty"`      // GitLab group for group-level variables
+	Visibility string `yaml:"visibility,omitempty"` // Org secret visibility: all or private
+	BaseURL    string `yaml:"base_url,omitempty"`   // API root for self-hosted instances
+
+	// Environments overrides provider settings per remote environment,
+	// e.g. {production: {mount: kv-prod, path: myapp}}
+	Environments map[string]map[string]string `yaml:"environments,omitempty"`
 }
 
 // Load reads and parses the config file
@@ -113,65 +257,7 @@ func (c *Config) GetSecretNames() []string {
 func (c *Config) GetTargets() []model.Target {
 	targets := make([]model.Target, 0, len(c.Targets))
 	for name, def := range c.Targets {
-		t := model.Target{
-			Name:    name,
-			Type:    def.Type,
-			Mapping: def.Mapping,
-			Config:  make(map[string]any),
-			Secrets: model.SecretsFilter{
-				Include: def.Include,
-				Exclude: def.Exclude,
-			},
-		}
-
-		// Copy provider-specific config
-		if def.Project != "" {
-			t.Config["project"] = def.Project
-		}
-		if def.Deployment != "" {
-			t.Config["deployment"] = def.Deployment
-		}
-		if def.ProjectID != "" {
-			t.Config["project_id"] = def.ProjectID
-		}
-		if def.ServiceID != "" {
-			t.Config["service_id"] = def.ServiceID
-		}
-		if def.ProjectRef != "" {
-			t.Config["project_ref"] = def.ProjectRef
-		}
-		if def.AccountID != "" {
-			t.Config["account_id"] = def.AccountID
-		}
-		if def.AppName != "" {
-			t.Config["app_name"] = def.AppName
-		}
-		if def.SiteID != "" {
-			t.Config["site_id"] = def.SiteID
-		}
-		if def.Path != "" {
-			t.Config["path"] = def.Path
-		}
-		if def.Region != "" {
-			t.Config["region"] = def.Region
-		}
-		if def.Prefix != "" {
-			t.Config["prefix"] = def.Prefix
-		}
-		if def.Profile != "" {
-			t.Config["profile"] = def.Profile
-		}
-		if def.SecretName != "" {
-			t.Config["secret_name"] = def.SecretName
-		}
-		if def.Token != "" {
-			t.Config["token"] = def.Token
-		}
-		if def.DeployKey != "" {
-			t.Config["deploy_key"] = def.DeployKey
-		}
-
-		targets = append(targets, t)
+		targets = append(targets, c.withSchema(def.toTarget(name)))
 	}
 	return targets
 }
@@ -182,8 +268,22 @@ func (c *Config) GetTarget(name string) (*model.Target, bool) {
 	if !ok {
 		return nil, false
 	}
+	t := c.withSchema(def.toTarget(name))
+	return &t, true
+}
 
-	t := &model.Target{
+// withSchema passes the secret metadata to the target's provider, for
+// platforms that store sensitive and plain values differently
+func (c *Config) withSchema(t model.Target) model.Target {
+	if len(c.Schema) > 0 {
+		t.Config["_schema"] = c.Schema
+	}
+	return t
+}
+
+// toTarget converts a target definition to a model target
+func (def *TargetDef) toTarget(name string) model.Target {
+	t := model.Target{
 		Name:    name,
 		Type:    def.Type,
 		Mapping: def.Mapping,
@@ -194,53 +294,108 @@ func (c *Config) GetTarget(name string) (*model.Target, bool) {
 		},
 	}
 
-	if def.Project != "" {
-		t.Config["project"] = def.Project
-	}
-	if def.Deployment != "" {
-		t.Config["deployment"] = def.Deployment
+	// Copy provider-specific config
+	settings := map[string]string{
+		"project":         def.Project,
+		"deployment":      def.Deployment,
+		"project_id":      def.ProjectID,
+		"service_id":      def.ServiceID,
+		"project_ref":     def.ProjectRef,
+		"account_id":      def.AccountID,
+		"team_id":         def.TeamID,
+		"app_name":        def.AppName,
+		"app_id":          def.AppID,
+		"site_id":         def.SiteID,
+		"path":            def.Path,
+		"region":          def.Region,
+		"prefix":          def.Prefix,
+		"profile":         def.Profile,
+		"secret_name":     def.SecretName,
+		"token":           def.Token,
+		"deploy_key":      def.DeployKey,
+		"address":         def.Address,
+		"namespace":       def.Namespace,
+		"mount":           def.Mount,
+		"role_id":         def.RoleID,
+		"secret_id":       def.SecretID,
+		"script":          def.Script,
+		"pipeline":        def.Pipeline,
+		"config":          def.Config,
+		"component":       def.Component,
+		"vault_url":       def.VaultURL,
+		"tenant_id":       def.TenantID,
+		"client_id":       def.ClientID,
+		"client_secret":   def.ClientSecret,
+		"kms_key_id":      def.KMSKeyID,
+		"old_versions":    def.OldVersions,
+		"env_group":       def.EnvGroup,
+		"blueprint":       def.Blueprint,
+		"function":        def.Function,
+		"qualifier":       def.Qualifier,
+		"task_definition": def.TaskDefinition,
+		"container":       def.Container,
+		"context":         def.Context,
+		"kubeconfig":      def.Kubeconfig,
+		"mode":            def.Mode,
+		"sealed_secret":   def.SealedSecret,
+		"repository":      def.Repository,
+		"org":             def.Org,
+		"group":           def.Group,
+		"visibility":      def.Visibility,
+		"base_url":        def.BaseURL,
 	}
-	if def.ProjectID != "" {
-		t.Config["project_id"] = def.ProjectID
-	}
-	if def.ServiceID != "" {
-		t.Config["service_id"] = def.ServiceID
-	}
-	if def.ProjectRef != "" {
-		t.Config["project_ref"] = def.ProjectRef
+	for key, value := range settings {
+		if value != "" {
+			t.Config[key] = value
+		}
 	}
-	if def.AccountID != "" {
-		t.Config["account_id"] = def.AccountID
+	if def.PurgeOnDelete {
+		t.Config["purge_on_delete"] = true
 	}
-	if def.AppName != "" {
-		t.Config["app_name"] = def.AppName
+	if len(def.Tags) > 0 {
+		t.Config["tags"] = def.Tags
 	}
-	if def.SiteID != "" {
-		t.Config["site_id"] = def.SiteID
+	if len(def.Locations) > 0 {
+		t.Config["locations"] = def.Locations
 	}
-	if def.Path != "" {
-		t.Config["path"] = def.Path
+	if len(def.Services) > 0 {
+		t.Config["services"] = def.Services
 	}
-	if def.Region != "" {
-		t.Config["region"] = def.Region
+	if def.CreateEnvironments {
+		t.Config["create_environments"] = true
 	}
-	if def.Prefix != "" {
-		t.Config["prefix"] = def.Prefix
+	if def.DeferDeploy {
+		t.Config["defer_deploy"] = true
 	}
-	if def.Profile != "" {
-		t.Config["profile"] = def.Profile
+	if len(def.Scopes) > 0 {
+		t.Config["scopes"] = def.Scopes
 	}
-	if def.SecretName != "" {
-		t.Config["secret_name"] = def.SecretName
+	if def.KeepVersions > 0 {
+		t.Config["keep_versions"] = def.KeepVersions
 	}
-	if def.Token != "" {
-		t.Config["token"] = def.Token
+	if len(def.Environments) > 0 {
+		t.Config["environments"] = def.Environments
 	}
-	if def.DeployKey != "" {
-		t.Config["deploy_key"] = def.DeployKey
+
+	return t
+}
+
+// Secret returns the schema entry for a name, including any metadata
+func (c *Config) Secret(name string) model.Secret {
+	if s, ok := c.Schema[name]; ok {
+		s.Name = name
+		return s
 	}
+	return model.Secret{Name: name}
+}
 
-	return t, true
+// GetSchema returns every schema entry in declaration order
+func (c *Config) GetSchema() []model.Secret {
+	secrets := make([]model.Secret, 0, len(c.Secrets))
+	for _, name := range c.Secrets {
+		secrets = append(secrets, c.Secret(name))
+	}
+	return secrets
 }
 
 // HasSecret checks if a secret name is in the schema
@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
 )
 
 func TestLoad(t *testing.T) {
@@ -435,3 +438,113 @@ func TestGetTarget(t *testing.T) {
 		t.Error("GetTarget should return false for nonexistent")
 	}
 }
+
+func TestLoadSecretMetadata(t *testing.T) {
+	content := `
+version: 2
+secrets:
+  - API_KEY
+  - name: DATABASE_URL
+    description: Postgres connection string
+    group: Database
+    example: postgres://localhost/app
+`
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dotenvy.yaml")
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(cfg.Secrets) != 2 || cfg.Secrets[0] != "API_KEY" || cfg.Secrets[1] != "DATABASE_URL" {
+		t.Fatalf("Secrets = %v, want [API_KEY DATABASE_URL]", cfg.Secrets)
+	}
+
+	db := cfg.Secret("DATABASE_URL")
+	if db.Description != "Postgres connection string" {
+		t.Errorf("Description = %q", db.Description)
+	}
+	if db.Group != "Database" {
+		t.Errorf("Group = %q, want 'Database'", db.Group)
+	}
+	if db.Example != "postgres://localhost/app" {
+		t.Errorf("Example = %q", db.Example)
+	}
+	if cfg.Secret("API_KEY").HasMetadata() {
+		t.Error("API_KEY should have no metadata")
+	}
+
+	// Round trip keeps bare names bare and metadata intact
+	if err := Save(cfg, cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ := os.ReadFile(cfgPath)
+	if !strings.Contains(string(data), "- API_KEY\n") {
+		t.Errorf("expected bare API_KEY entry, got:\n%s", data)
+	}
+
+	reloaded, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if reloaded.Secret("DATABASE_URL").Group != "Database" {
+		t.Error("metadata lost on round trip")
+	}
+}
+
+func TestGetTargetsPassesSchema(t *testing.T) {
+	content := `
+version: 2
+secrets:
+  - API_KEY
+  - name: PUBLIC_URL
+    sensitive: false
+targets:
+  gh:
+    type: github-actions
+    repository: acme/web
+`
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dotenvy.yaml")
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Secret("PUBLIC_URL").IsSensitive() {
+		t.Error("PUBLIC_URL should not be sensitive")
+	}
+	if !cfg.Secret("API_KEY").IsSensitive() {
+		t.Error("API_KEY should be sensitive by default")
+	}
+
+	target, _ := cfg.GetTarget("gh")
+	schema, ok := target.Config["_schema"].(map[string]model.Secret)
+	if !ok || schema["PUBLIC_URL"].IsSensitive() {
+		t.Errorf("target schema = %v", target.Config["_schema"])
+	}
+}
+
+func TestLoadSecretMetadataMissingName(t *testing.T) {
+	content := `
+version: 2
+secrets:
+  - description: no name here
+`
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dotenvy.yaml")
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("expected error for entry without a name")
+	}
+}
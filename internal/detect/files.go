@@ -0,0 +1,263 @@
+package detect
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/source"
+)
+
+// FileMatch is a provider setting read from a platform's own project file.
+type FileMatch struct {
+	ProviderName string // e.g. "flyio"
+	File         string // e.g. "fly.toml"
+	Field        string // Target config field it fills, e.g. "app_name" (empty if the file only signals the provider)
+	Value        string // e.g. "my-app-staging"
+	Confidence   string // "strong" or "weak"
+	Reason       string // e.g. "app name from fly.toml"
+}
+
+// FileMatches is the set of matches found in a project directory.
+type FileMatches []FileMatch
+
+// Lookup returns the best match for a provider field. Strong matches win
+// over weak ones; otherwise the first match found wins.
+func (fm FileMatches) Lookup(providerName, field string) (FileMatch, bool) {
+	var best FileMatch
+	found := false
+	for _, m := range fm {
+		if m.ProviderName != providerName || m.Field != field || m.Value == "" {
+			continue
+		}
+		if !found || (best.Confidence != "strong" && m.Confidence == "strong") {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Providers returns the deduplicated provider names in discovery order.
+func (fm FileMatches) Providers() []string {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, m := range fm {
+		if !seen[m.ProviderName] {
+			seen[m.ProviderName] = true
+			providers = append(providers, m.ProviderName)
+		}
+	}
+	return providers
+}
+
+// fileDetector reads one platform file and reports what it found.
+type fileDetector struct {
+	path   string // Relative to the project root
+	detect func(data []byte) []FileMatch
+}
+
+var fileDetectors = []fileDetector{
+	{path: filepath.Join(".vercel", "project.json"), detect: detectVercelProject},
+	{path: "vercel.json", detect: detectVercelJSON},
+	{path: "fly.toml", detect: detectFlyToml},
+	{path: filepath.Join(".netlify", "state.json"), detect: detectNetlifyState},
+	{path: "netlify.toml", detect: presence("netlify", "Netlify config file")},
+	{path: "railway.json", detect: presence("railway", "Railway config-as-code file")},
+	{path: "railway.toml", detect: presence("railway", "Railway config-as-code file")},
+	{path: "render.yaml", detect: presence("render", "Render blueprint")},
+	{path: filepath.Join("supabase", ".temp", "project-ref"), detect: detectSupabaseRef},
+	{path: filepath.Join("supabase", "config.toml"), detect: detectSupabaseConfig},
+	{path: "convex.json", detect: presence("convex", "Convex config file")},
+	{path: ".env.local", detect: detectConvexDeployment},
+}
+
+// FromProjectFiles looks for platform config files in dir and extracts the
+// project identifiers they hold. Missing or unreadable files are skipped.
+func FromProjectFiles(dir string) FileMatches {
+	var matches FileMatches
+	for _, d := range fileDetectors {
+		data, err := os.ReadFile(filepath.Join(dir, d.path))
+		if err != nil {
+			continue
+		}
+		for _, m := range d.detect(data) {
+			m.File = filepath.ToSlash(d.path)
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// presence reports the provider for files that carry no usable identifier.
+func presence(providerName, reason string) func([]byte) []FileMatch {
+	return func([]byte) []FileMatch {
+		return []FileMatch{{ProviderName: providerName, Confidence: "weak", Reason: reason}}
+	}
+}
+
+// detectVercelProject reads the link written by `vercel link`.
+func detectVercelProject(data []byte) []FileMatch {
+	var link struct {
+		ProjectID string `json:"projectId"`
+		OrgID     string `json:"orgId"`
+	}
+	if err := json.Unmarshal(data, &link); err != nil || link.ProjectID == "" {
+		return nil
+	}
+	matches := []FileMatch{{
+		ProviderName: "vercel",
+		Field:        "project",
+		Value:        link.ProjectID,
+		Confidence:   "strong",
+		Reason:       "project linked with vercel link",
+	}}
+	// Personal accounts have user IDs here, which the API doesn't take as teamId
+	if strings.HasPrefix(link.OrgID, "team_") {
+		matches = append(matches, FileMatch{
+			ProviderName: "vercel",
+			Field:        "team_id",
+			Value:        link.OrgID,
+			Confidence:   "strong",
+			Reason:       "project linked with vercel link",
+		})
+	}
+	return matches
+}
+
+// detectVercelJSON reads the legacy project name from vercel.json.
+func detectVercelJSON(data []byte) []FileMatch {
+	var cfg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil
+	}
+	m := FileMatch{ProviderName: "vercel", Confidence: "weak", Reason: "Vercel config file"}
+	if cfg.Name != "" {
+		m.Field = "project"
+		m.Value = cfg.Name
+		m.Reason = "project name from vercel.json"
+	}
+	return []FileMatch{m}
+}
+
+func detectFlyToml(data []byte) []FileMatch {
+	app := tomlString(data, "app")
+	if app == "" {
+		return []FileMatch{{ProviderName: "flyio", Confidence: "weak", Reason: "Fly.io config file"}}
+	}
+	return []FileMatch{{
+		ProviderName: "flyio",
+		Field:        "app_name",
+		Value:        app,
+		Confidence:   "strong",
+		Reason:       "app name from fly.toml",
+	}}
+}
+
+// detectNetlifyState reads the site linked with `netlify link`.
+func detectNetlifyState(data []byte) []FileMatch {
+	var state struct {
+		SiteID string `json:"siteId"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil || state.SiteID == "" {
+		return nil
+	}
+	return []FileMatch{{
+		ProviderName: "netlify",
+		Field:        "site_id",
+		Value:        state.SiteID,
+		Confidence:   "strong",
+		Reason:       "site linked with netlify link",
+	}}
+}
+
+// detectSupabaseRef reads the project linked with `supabase link`.
+func detectSupabaseRef(data []byte) []FileMatch {
+	ref := strings.TrimSpace(string(data))
+	if ref == "" {
+		return nil
+	}
+	return []FileMatch{{
+		ProviderName: "supabase",
+		Field:        "project_ref",
+		Value:        ref,
+		Confidence:   "strong",
+		Reason:       "project linked with supabase link",
+	}}
+}
+
+// detectSupabaseConfig reads project_id from supabase/config.toml. It is
+// often just a local name, so it is only a weak guess at the project ref.
+func detectSupabaseConfig(data []byte) []FileMatch {
+	m := FileMatch{ProviderName: "supabase", Confidence: "weak", Reason: "Supabase config file"}
+	if id := tomlString(data, "project_id"); id != "" {
+		m.Field = "project_ref"
+		m.Value = id
+		m.Reason = "project_id from supabase/config.toml"
+	}
+	return []FileMatch{m}
+}
+
+// detectConvexDeployment reads CONVEX_DEPLOYMENT (e.g. "dev:happy-otter-123")
+// written to .env.local by `npx convex dev`.
+func detectConvexDeployment(data []byte) []FileMatch {
+	values, err := source.ParseEnv(data)
+	if err != nil {
+		return nil
+	}
+	deployment := values["CONVEX_DEPLOYMENT"]
+	// The Convex CLI appends "# team: ..., project: ..." to the line
+	if idx := strings.Index(deployment, " #"); idx != -1 {
+		deployment = strings.TrimSpace(deployment[:idx])
+	}
+	if deployment == "" {
+		return nil
+	}
+	kind := "dev"
+	if idx := strings.Index(deployment, ":"); idx != -1 {
+		kind = deployment[:idx]
+		deployment = deployment[idx+1:]
+	}
+	field := "deployment"
+	if kind == "prod" {
+		field = "prod_deployment"
+	}
+	return []FileMatch{{
+		ProviderName: "convex",
+		Field:        field,
+		Value:        deployment,
+		Confidence:   "strong",
+		Reason:       "CONVEX_DEPLOYMENT in .env.local",
+	}}
+}
+
+// tomlString returns a top-level string value (key = "value") from a TOML
+// document. Keys inside [tables] are ignored.
+func tomlString(data []byte, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			return ""
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.Index(line, "=")
+		if idx == -1 || strings.TrimSpace(line[:idx]) != key {
+			continue
+		}
+		value := strings.TrimSpace(line[idx+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			if end := strings.IndexByte(value[1:], value[0]); end != -1 {
+				return value[1 : end+1]
+			}
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProjectFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFile(t, dir, ".vercel/project.json", `{"projectId":"prj_abc123","orgId":"team_xyz"}`)
+	writeProjectFile(t, dir, "fly.toml", "# fly config\napp = \"my-app-staging\"\nprimary_region = \"iad\"\n\n[env]\n  app = \"not-this\"\n")
+	writeProjectFile(t, dir, ".netlify/state.json", `{"siteId":"site-123"}`)
+	writeProjectFile(t, dir, "supabase/.temp/project-ref", "abcdefghijklmnop\n")
+	writeProjectFile(t, dir, "supabase/config.toml", "project_id = \"local-name\"\n")
+	writeProjectFile(t, dir, ".env.local", "CONVEX_DEPLOYMENT=dev:happy-otter-123 # team: me\n")
+	writeProjectFile(t, dir, "render.yaml", "services: []\n")
+
+	fm := FromProjectFiles(dir)
+
+	tests := []struct {
+		provider, field, want, file string
+	}{
+		{"vercel", "project", "prj_abc123", ".vercel/project.json"},
+		{"vercel", "team_id", "team_xyz", ".vercel/project.json"},
+		{"flyio", "app_name", "my-app-staging", "fly.toml"},
+		{"netlify", "site_id", "site-123", ".netlify/state.json"},
+		{"supabase", "project_ref", "abcdefghijklmnop", "supabase/.temp/project-ref"},
+		{"convex", "deployment", "happy-otter-123", ".env.local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			m, ok := fm.Lookup(tt.provider, tt.field)
+			if !ok {
+				t.Fatalf("no match for %s.%s", tt.provider, tt.field)
+			}
+			if m.Value != tt.want {
+				t.Errorf("Value = %q, want %q", m.Value, tt.want)
+			}
+			if m.File != tt.file {
+				t.Errorf("File = %q, want %q", m.File, tt.file)
+			}
+			if m.Confidence != "strong" {
+				t.Errorf("Confidence = %q, want strong", m.Confidence)
+			}
+		})
+	}
+
+	providers := fm.Providers()
+	want := map[string]bool{"vercel": true, "flyio": true, "netlify": true, "render": true, "supabase": true, "convex": true}
+	if len(providers) != len(want) {
+		t.Errorf("Providers() = %v", providers)
+	}
+	for _, p := range providers {
+		if !want[p] {
+			t.Errorf("unexpected provider %q", p)
+		}
+	}
+}
+
+func TestFromProjectFiles_Empty(t *testing.T) {
+	if fm := FromProjectFiles(t.TempDir()); len(fm) != 0 {
+		t.Errorf("expected no matches, got %+v", fm)
+	}
+}
+
+func TestDetectConvexDeployment_Prod(t *testing.T) {
+	m := detectConvexDeployment([]byte("CONVEX_DEPLOYMENT=prod:brave-fox-456\n"))
+	if len(m) != 1 || m[0].Field != "prod_deployment" || m[0].Value != "brave-fox-456" {
+		t.Errorf("got %+v", m)
+	}
+}
+
+func TestTomlString(t *testing.T) {
+	data := []byte("app = 'single-quoted'\nother = 1\n")
+	if got := tomlString(data, "app"); got != "single-quoted" {
+		t.Errorf("tomlString = %q", got)
+	}
+	if got := tomlString(data, "missing"); got != "" {
+		t.Errorf("tomlString(missing) = %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+package guard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/source"
+)
+
+// HookMarker identifies hooks written by dotenvy
+const HookMarker = "# dotenvy guard"
+
+// hookScript is the pre-commit hook body. It only invokes dotenvy; no values
+// or fingerprints are written into the hook.
+const hookScript = HookMarker + "\n" + `if command -v dotenvy >/dev/null 2>&1; then
+  dotenvy guard --staged || exit 1
+else
+  echo "dotenvy not found on PATH, skipping secret guard" >&2
+fi
+`
+
+// git runs a git command and returns its stdout
+func git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return out, nil
+}
+
+// exampleEnvFiles are committed templates, not real env files
+var exampleEnvFiles = map[string]bool{
+	source.DefaultExampleFile: true,
+	".env.sample":             true,
+	".env.template":           true,
+}
+
+// IsEnvFile reports whether a path looks like a real (non-template) env file
+func IsEnvFile(path string) bool {
+	base := filepath.Base(path)
+	return (base == ".env" || strings.HasPrefix(base, ".env.")) && !exampleEnvFiles[base]
+}
+
+// StagedFiles returns paths added, copied, modified, or renamed in the index
+func StagedFiles() ([]string, error) {
+	out, err := git("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// StagedContent returns the content of a file as staged in the index
+func StagedContent(path string) ([]byte, error) {
+	return git("show", ":"+path)
+}
+
+// IsIgnored reports whether git ignores the path
+func IsIgnored(path string) (bool, error) {
+	cmd := exec.Command("git", "check-ignore", "-q", path)
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("git check-ignore %s: %w", path, err)
+}
+
+// shells are interpreters the guard can be appended to
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "ksh": true, "zsh": true}
+
+// InstallHook adds the guard to the repository's pre-commit hook. An
+// existing shell hook is kept and the guard is appended to it; a hook in
+// another language is refused. Returns false if the guard was already
+// installed.
+func InstallHook() (string, bool, error) {
+	out, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", false, err
+	}
+	hooksDir := strings.TrimSpace(string(out))
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create %s: %w", hooksDir, err)
+	}
+	path := filepath.Join(hooksDir, "pre-commit")
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if bytes.Contains(existing, []byte(HookMarker)) {
+		return path, false, nil
+	}
+
+	if interpreter := hookInterpreter(existing); interpreter != "" && !shells[interpreter] {
+		return "", false, fmt.Errorf("%s is a %s script; add \"dotenvy guard --staged\" to it yourself", path, interpreter)
+	}
+
+	var content string
+	if len(existing) == 0 {
+		content = "#!/bin/sh\n" + hookScript
+	} else {
+		content = string(existing)
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "\n" + hookScript
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return "", false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, true, nil
+}
+
+// hookInterpreter returns the program named by a hook's #! line, e.g. "sh"
+// for "#!/bin/sh" or "python3" for "#!/usr/bin/env python3"
+func hookInterpreter(hook []byte) string {
+	line, _, _ := strings.Cut(string(hook), "\n")
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if !strings.HasPrefix(line, "#!") || len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		return fields[1]
+	}
+	return filepath.Base(fields[0])
+}
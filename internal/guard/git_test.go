@@ -0,0 +1,120 @@
+package guard
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsEnvFile(t *testing.T) {
+	tests := map[string]bool{
+		".env":                true,
+		".env.production":     true,
+		"app/.env.local":      true,
+		".env.example":        false,
+		"config/.env.sample":  false,
+		".env.template":       false,
+		".envrc":              false,
+		"env.production":      false,
+		"docs/dotenv.md":      false,
+		"secrets/.env.test.1": true,
+	}
+	for path, want := range tests {
+		if got := IsEnvFile(path); got != want {
+			t.Errorf("IsEnvFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// newRepo creates an empty git repository and makes it the working directory
+func newRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if _, err := git("init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	newRepo(t)
+
+	path, installed, err := InstallHook()
+	if err != nil || !installed {
+		t.Fatalf("InstallHook() = %v, %v, want installed", installed, err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "#!/bin/sh\n"+HookMarker) {
+		t.Errorf("hook = %q", content)
+	}
+
+	// Reinstalling leaves the hook alone
+	if _, installed, err := InstallHook(); err != nil || installed {
+		t.Errorf("reinstall = %v, %v, want already installed", installed, err)
+	}
+	again, _ := os.ReadFile(path)
+	if string(again) != string(content) {
+		t.Errorf("reinstall changed the hook to %q", again)
+	}
+}
+
+func TestInstallHook_Existing(t *testing.T) {
+	newRepo(t)
+	hook := filepath.Join(".git", "hooks", "pre-commit")
+	if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A shell hook is kept and the guard chained after it
+	if err := os.WriteFile(hook, []byte("#!/usr/bin/env bash\nnpm run lint"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, installed, err := InstallHook(); err != nil || !installed {
+		t.Fatalf("InstallHook() = %v, %v, want installed", installed, err)
+	}
+	content, _ := os.ReadFile(hook)
+	if !strings.HasPrefix(string(content), "#!/usr/bin/env bash\nnpm run lint\n\n"+HookMarker) {
+		t.Errorf("hook = %q", content)
+	}
+
+	// Shell can't be appended to a hook in another language
+	python := "#!/usr/bin/env python3\nprint('lint')\n"
+	if err := os.WriteFile(hook, []byte(python), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := InstallHook(); err == nil || !strings.Contains(err.Error(), "python3") {
+		t.Errorf("InstallHook() error = %v, want python3 hook refused", err)
+	}
+	if content, _ := os.ReadFile(hook); string(content) != python {
+		t.Errorf("refused hook was changed to %q", content)
+	}
+}
+
+func TestStagedFiles_Renamed(t *testing.T) {
+	newRepo(t)
+	if err := os.WriteFile("secrets.txt", []byte("API_KEY=sk_live_abc123xyz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"add", "secrets.txt"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		{"mv", "secrets.txt", ".env.production"},
+	} {
+		if _, err := git(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := StagedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != ".env.production" {
+		t.Errorf("StagedFiles() = %v, want the renamed .env.production", files)
+	}
+}
@@ -0,0 +1,126 @@
+package guard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+)
+
+// DefaultMinLength is the shortest value worth guarding. Shorter values
+// ("true", "3000", "us-east-1") show up in code for innocent reasons.
+const DefaultMinLength = 8
+
+// Fingerprints holds SHA-256 hashes of secret values. Plaintext values are
+// hashed on Add and never stored, so a Fingerprints can be kept around or
+// logged without exposing secrets.
+type Fingerprints struct {
+	minLength int
+	hashes    map[[sha256.Size]byte][]string // hash -> labels (e.g. "STRIPE_KEY (.env.live)")
+	lengths   map[int]bool
+}
+
+// NewFingerprints creates an empty fingerprint set. Values shorter than
+// minLength are ignored.
+func NewFingerprints(minLength int) *Fingerprints {
+	return &Fingerprints{
+		minLength: minLength,
+		hashes:    make(map[[sha256.Size]byte][]string),
+		lengths:   make(map[int]bool),
+	}
+}
+
+// Add fingerprints a value under a label. Returns false if the value was too
+// short to guard.
+func (f *Fingerprints) Add(label, value string) bool {
+	if len(value) < f.minLength {
+		return false
+	}
+	h := sha256.Sum256([]byte(value))
+	for _, existing := range f.hashes[h] {
+		if existing == label {
+			return true
+		}
+	}
+	f.hashes[h] = append(f.hashes[h], label)
+	f.lengths[len(value)] = true
+	return true
+}
+
+// Len returns the number of distinct values being guarded.
+func (f *Fingerprints) Len() int {
+	return len(f.hashes)
+}
+
+// Finding is a guarded value found in scanned content.
+type Finding struct {
+	File   string
+	Line   int
+	Labels []string // Which secrets the value belongs to
+}
+
+// Scan looks for guarded values in content. A candidate must sit between
+// delimiters (quotes, whitespace, =, :, and similar) so values are matched
+// as whole tokens, and only its hash is compared.
+func (f *Fingerprints) Scan(file string, content []byte) []Finding {
+	if len(f.hashes) == 0 {
+		return nil
+	}
+
+	lengths := make([]int, 0, len(f.lengths))
+	for l := range f.lengths {
+		lengths = append(lengths, l)
+	}
+	sort.Ints(lengths)
+
+	var findings []Finding
+	reported := make(map[int]map[[sha256.Size]byte]bool) // line -> hashes already reported
+	line := 1
+	for start := 0; start < len(content); start++ {
+		if content[start] == '\n' {
+			line++
+			continue
+		}
+		if start > 0 && !isDelimiter(content[start-1]) {
+			continue
+		}
+		for _, l := range lengths {
+			end := start + l
+			if end > len(content) {
+				break
+			}
+			if end < len(content) && !isDelimiter(content[end]) {
+				continue
+			}
+			candidate := content[start:end]
+			if bytes.IndexByte(candidate, '\n') != -1 {
+				continue
+			}
+			h := sha256.Sum256(candidate)
+			labels, ok := f.hashes[h]
+			if !ok {
+				continue
+			}
+			if reported[line] == nil {
+				reported[line] = make(map[[sha256.Size]byte]bool)
+			}
+			if reported[line][h] {
+				continue
+			}
+			reported[line][h] = true
+			findings = append(findings, Finding{File: file, Line: line, Labels: labels})
+		}
+	}
+
+	return findings
+}
+
+// isDelimiter reports whether b can border a secret value in source or
+// config text, including the parts of a URL such as a password between
+// user: and @host.
+func isDelimiter(b byte) bool {
+	switch b {
+	case ' ', '\t', '\r', '\n', '"', '\'', '`', '=', ':', ',', ';', '(', ')', '[', ']', '{', '}', '<', '>', '/', '@', '.':
+		return true
+	}
+	return false
+}
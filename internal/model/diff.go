@@ -19,6 +19,7 @@ type SecretDiff struct {
 	NewValue    string
 	Environment string // Remote environment name
 	Sensitive   bool
+	Warning     string // Set when the value looks like it belongs to another environment
 }
 
 // TargetDiff represents all differences for a target
@@ -39,6 +40,17 @@ func (td TargetDiff) HasChanges() bool {
 	return false
 }
 
+// Warnings returns the diffs that carry a warning
+func (td TargetDiff) Warnings() []SecretDiff {
+	var warnings []SecretDiff
+	for _, d := range td.Diffs {
+		if d.Warning != "" {
+			warnings = append(warnings, d)
+		}
+	}
+	return warnings
+}
+
 // CountByType returns the count of diffs by type
 func (td TargetDiff) CountByType() map[DiffType]int {
 	counts := make(map[DiffType]int)
@@ -1,8 +1,27 @@
 package model
 
-// Secret represents a secret in the schema (name only, no value)
+// Secret represents a secret in the schema (metadata only, no value)
 type Secret struct {
-	Name string
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Group       string `yaml:"group,omitempty"`
+	Example     string `yaml:"example,omitempty"`      // Placeholder value for .env.example
+	AllowShared bool   `yaml:"allow_shared,omitempty"` // Same value is expected in test and live (e.g. a public URL)
+	Sensitive   *bool  `yaml:"sensitive,omitempty"`    // Defaults to true; false allows plain-text storage where a platform has it
+	Protected   bool   `yaml:"protected,omitempty"`    // CI only: expose to protected branches and tags only
+	Raw         bool   `yaml:"raw,omitempty"`          // CI only: don't expand $VAR references in the value
+}
+
+// HasMetadata returns true if the secret carries anything beyond its name
+func (s Secret) HasMetadata() bool {
+	return s.Description != "" || s.Group != "" || s.Example != "" || s.AllowShared || s.Sensitive != nil ||
+		s.Protected || s.Raw
+}
+
+// IsSensitive reports whether the value must be stored as a secret. Keys are
+// sensitive unless the schema says otherwise.
+func (s Secret) IsSensitive() bool {
+	return s.Sensitive == nil || *s.Sensitive
 }
 
 // SecretValue represents a secret with its value (used during sync)
@@ -10,6 +29,8 @@ type SecretValue struct {
 	Name        string
 	Value       string
 	Environment string
+	Digest      string // Set instead of Value by providers that can't read values back
+	Unreadable  bool   // This value can't be read back, on a provider that can read others
 }
 
 // SecretSet is a collection of secret values
@@ -0,0 +1,206 @@
+package scan
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Location is a single place in the source tree where a key is read.
+type Location struct {
+	File string // Path relative to the scan root
+	Line int
+}
+
+// Result holds every env var reference found in a tree.
+type Result struct {
+	Root       string
+	Files      int                   // Number of source files scanned
+	References map[string][]Location // Key -> where it is read
+}
+
+// Keys returns the referenced keys in sorted order.
+func (r *Result) Keys() []string {
+	keys := make([]string, 0, len(r.References))
+	for k := range r.References {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Report compares code references against the schema.
+type Report struct {
+	Missing []string // Read in code but not in the schema
+	Unused  []string // In the schema but never read in code
+}
+
+// Compare checks references against the schema names. Keys matching any of
+// the ignore glob patterns are left out of both lists.
+func (r *Result) Compare(schema []string, ignore []string) Report {
+	var report Report
+
+	inSchema := make(map[string]bool, len(schema))
+	for _, name := range schema {
+		inSchema[name] = true
+	}
+
+	for _, key := range r.Keys() {
+		if !inSchema[key] && !matchesAny(key, ignore) {
+			report.Missing = append(report.Missing, key)
+		}
+	}
+	for _, name := range schema {
+		if _, ok := r.References[name]; !ok && !matchesAny(name, ignore) {
+			report.Unused = append(report.Unused, name)
+		}
+	}
+
+	return report
+}
+
+// DefaultIgnore lists keys set by runtimes and build tools rather than by us.
+var DefaultIgnore = []string{
+	"NODE_ENV",
+	"MODE", "DEV", "PROD", "SSR", "BASE_URL", // Vite built-ins on import.meta.env
+}
+
+// keyPattern is the shape of an env var name.
+const keyPattern = `([A-Za-z_][A-Za-z0-9_]*)`
+
+// accessPatterns match the common ways code reads an env var. The first
+// capture group is always the key.
+var accessPatterns = []*regexp.Regexp{
+	// JavaScript / TypeScript
+	regexp.MustCompile(`process\.env\.` + keyPattern),
+	regexp.MustCompile(`process\.env\[\s*["'` + "`" + `]` + keyPattern + `["'` + "`" + `]\s*\]`),
+	regexp.MustCompile(`import\.meta\.env\.` + keyPattern),
+	// Go
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"` + keyPattern + `"\s*\)`),
+	// Python
+	regexp.MustCompile(`os\.environ\[\s*["']` + keyPattern + `["']\s*\]`),
+	regexp.MustCompile(`os\.(?:environ\.get|getenv)\(\s*["']` + keyPattern + `["']`),
+	// Ruby
+	regexp.MustCompile(`\bENV\[\s*["']` + keyPattern + `["']\s*\]`),
+	regexp.MustCompile(`\bENV\.fetch\(\s*["']` + keyPattern + `["']`),
+	// PHP (Laravel), Python (django-environ), Elixir-style helpers
+	regexp.MustCompile(`\benv\(\s*["']` + keyPattern + `["']`),
+}
+
+// sourceExtensions are the file types worth scanning.
+var sourceExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".vue": true, ".svelte": true, ".astro": true,
+	".go": true,
+	".py": true,
+	".rb": true, ".rake": true, ".erb": true,
+	".php": true,
+}
+
+// skipDirs are never descended into.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	".next":        true,
+	".nuxt":        true,
+	".svelte-kit":  true,
+	".vercel":      true,
+	".netlify":     true,
+	"coverage":     true,
+	"__pycache__":  true,
+	".venv":        true,
+	"venv":         true,
+}
+
+// maxFileSize skips generated bundles and other huge files.
+const maxFileSize = 1 << 20
+
+// Dir walks root and collects env var references from source files.
+func Dir(root string) (*Result, error) {
+	r := &Result{
+		Root:       root,
+		References: make(map[string][]Location),
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExtensions[filepath.Ext(path)] {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		r.Files++
+		return scanFile(path, rel, r.References)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// scanFile records every reference in a single file.
+func scanFile(path, rel string, refs map[string][]Location) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxFileSize)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		for _, key := range Line(scanner.Text()) {
+			refs[key] = append(refs[key], Location{File: rel, Line: lineNo})
+		}
+	}
+	return scanner.Err()
+}
+
+// Line returns the keys referenced on a single line of code. Pure function.
+func Line(line string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, re := range accessPatterns {
+		for _, m := range re.FindAllStringSubmatch(line, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				keys = append(keys, m[1])
+			}
+		}
+	}
+	return keys
+}
+
+// matchesAny checks if name matches any of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
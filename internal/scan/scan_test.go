@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLine_AccessPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`const url = process.env.DATABASE_URL;`, "DATABASE_URL"},
+		{`const key = process.env["STRIPE_KEY"]`, "STRIPE_KEY"},
+		{`const api = import.meta.env.VITE_API_URL`, "VITE_API_URL"},
+		{`token := os.Getenv("GITHUB_TOKEN")`, "GITHUB_TOKEN"},
+		{`v, ok := os.LookupEnv("OPTIONAL_FLAG")`, "OPTIONAL_FLAG"},
+		{`secret = os.environ["SECRET_KEY"]`, "SECRET_KEY"},
+		{`debug = os.environ.get('DEBUG_MODE', False)`, "DEBUG_MODE"},
+		{`region = os.getenv("AWS_REGION")`, "AWS_REGION"},
+		{`host = ENV["REDIS_HOST"]`, "REDIS_HOST"},
+		{`port = ENV.fetch("REDIS_PORT", 6379)`, "REDIS_PORT"},
+		{`'key' => env('APP_KEY'),`, "APP_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Line(tt.line)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("Line(%q) = %v, want [%s]", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLine_NoFalsePositives(t *testing.T) {
+	lines := []string{
+		`const environment = getEnvironment()`,
+		`// mention of env vars in prose`,
+		`myenv("NOT_A_MATCH")`,
+	}
+	for _, line := range lines {
+		if got := Line(line); len(got) != 0 {
+			t.Errorf("Line(%q) = %v, want none", line, got)
+		}
+	}
+}
+
+func TestLine_MultiplePerLine(t *testing.T) {
+	got := Line(`const a = process.env.A_KEY || process.env.B_KEY || process.env.A_KEY`)
+	if len(got) != 2 || got[0] != "A_KEY" || got[1] != "B_KEY" {
+		t.Errorf("got %v, want [A_KEY B_KEY]", got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src", "app.ts"), "import x from 'y'\nconst k = process.env.API_KEY\n")
+	writeFile(t, filepath.Join(root, "cmd", "main.go"), "package main\n\nvar _ = os.Getenv(\"NEW_FLAG\")\n")
+	writeFile(t, filepath.Join(root, "node_modules", "lib", "index.js"), "process.env.IGNORED_DEP\n")
+	writeFile(t, filepath.Join(root, "README.md"), "process.env.IN_DOCS\n")
+
+	r, err := Dir(root)
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+
+	if r.Files != 2 {
+		t.Errorf("Files = %d, want 2", r.Files)
+	}
+
+	locs := r.References["API_KEY"]
+	if len(locs) != 1 || locs[0].File != filepath.Join("src", "app.ts") || locs[0].Line != 2 {
+		t.Errorf("API_KEY locations = %+v", locs)
+	}
+	if _, ok := r.References["IGNORED_DEP"]; ok {
+		t.Error("node_modules should be skipped")
+	}
+	if _, ok := r.References["IN_DOCS"]; ok {
+		t.Error("non-source files should be skipped")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	r := &Result{References: map[string][]Location{
+		"API_KEY":  {{File: "a.ts", Line: 1}},
+		"NEW_FLAG": {{File: "b.go", Line: 3}},
+		"NODE_ENV": {{File: "c.js", Line: 9}},
+	}}
+
+	report := r.Compare([]string{"API_KEY", "OLD_KEY"}, DefaultIgnore)
+
+	if len(report.Missing) != 1 || report.Missing[0] != "NEW_FLAG" {
+		t.Errorf("Missing = %v, want [NEW_FLAG]", report.Missing)
+	}
+	if len(report.Unused) != 1 || report.Unused[0] != "OLD_KEY" {
+		t.Errorf("Unused = %v, want [OLD_KEY]", report.Unused)
+	}
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// DefaultExampleFile is the conventional name for the committed template
+const DefaultExampleFile = ".env.example"
+
+// RenderExample builds .env.example content from the schema. Secrets are
+// grouped by their group (in order of first appearance) and keep schema order
+// within a group. Ungrouped secrets that follow a group get an "Other"
+// header, so they don't read as part of it. Descriptions become comments and
+// examples become values.
+func RenderExample(secrets []model.Secret) string {
+	var groups []string
+	byGroup := make(map[string][]model.Secret)
+	for _, s := range secrets {
+		if _, ok := byGroup[s.Group]; !ok {
+			groups = append(groups, s.Group)
+		}
+		byGroup[s.Group] = append(byGroup[s.Group], s)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by dotenvy from dotenvy.yaml - do not edit by hand.\n")
+	b.WriteString("# Run 'dotenvy example' to regenerate.\n")
+
+	for i, group := range groups {
+		b.WriteString("\n")
+		switch {
+		case group != "":
+			fmt.Fprintf(&b, "# --- %s ---\n", group)
+		case i > 0:
+			b.WriteString("# --- Other ---\n")
+		}
+		for _, s := range byGroup[group] {
+			for _, line := range strings.Split(s.Description, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					fmt.Fprintf(&b, "# %s\n", line)
+				}
+			}
+			value := ""
+			if s.Example != "" {
+				value = quoteIfNeeded(s.Example)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", s.Name, value)
+		}
+	}
+
+	return b.String()
+}
+
+// ExampleDrift describes how an existing .env.example differs from the schema
+type ExampleDrift struct {
+	Missing []string // In the schema but not in the file
+	Stale   []string // In the file but no longer in the schema
+	Content bool     // Keys match but comments, order, or examples differ
+}
+
+// HasDrift returns true if the file is out of date
+func (d ExampleDrift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Stale) > 0 || d.Content
+}
+
+// CheckExample compares the file at path against the rendered schema. Line
+// endings don't count, so a checkout with CRLF endings isn't drift.
+func CheckExample(path string, secrets []model.Secret) (*ExampleDrift, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	existing, err := NewFileSource(path).ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &ExampleDrift{}
+	inSchema := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		inSchema[s.Name] = true
+		if _, ok := existing[s.Name]; !ok {
+			drift.Missing = append(drift.Missing, s.Name)
+		}
+	}
+	for name := range existing {
+		if !inSchema[name] {
+			drift.Stale = append(drift.Stale, name)
+		}
+	}
+	sort.Strings(drift.Stale)
+
+	if len(drift.Missing) == 0 && len(drift.Stale) == 0 {
+		content := strings.ReplaceAll(string(data), "\r\n", "\n")
+		drift.Content = content != RenderExample(secrets)
+	}
+
+	return drift, nil
+}
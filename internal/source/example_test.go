@@ -0,0 +1,109 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+func exampleSchema() []model.Secret {
+	return []model.Secret{
+		{Name: "API_KEY", Description: "Public API key"},
+		{Name: "DATABASE_URL", Group: "Database", Example: "postgres://localhost/app"},
+		{Name: "LOG_LEVEL", Example: "debug"},
+		{Name: "DATABASE_POOL", Group: "Database", Example: "max 10"},
+	}
+}
+
+func TestRenderExample(t *testing.T) {
+	out := RenderExample(exampleSchema())
+
+	// Ungrouped keys keep schema order, grouped keys follow their group header
+	apiIdx := strings.Index(out, "API_KEY=")
+	logIdx := strings.Index(out, "LOG_LEVEL=debug")
+	headerIdx := strings.Index(out, "# --- Database ---")
+	dbIdx := strings.Index(out, "DATABASE_URL=postgres://localhost/app")
+	poolIdx := strings.Index(out, `DATABASE_POOL="max 10"`)
+
+	for name, idx := range map[string]int{"API_KEY": apiIdx, "LOG_LEVEL": logIdx, "header": headerIdx, "DATABASE_URL": dbIdx, "DATABASE_POOL": poolIdx} {
+		if idx == -1 {
+			t.Fatalf("missing %s in output:\n%s", name, out)
+		}
+	}
+	if !(apiIdx < logIdx && logIdx < headerIdx && headerIdx < dbIdx && dbIdx < poolIdx) {
+		t.Errorf("unexpected ordering:\n%s", out)
+	}
+	if !strings.Contains(out, "# Public API key\nAPI_KEY=\n") {
+		t.Errorf("description comment missing:\n%s", out)
+	}
+}
+
+func TestRenderExample_UngroupedAfterGroup(t *testing.T) {
+	out := RenderExample([]model.Secret{
+		{Name: "DATABASE_URL", Group: "Database"},
+		{Name: "LOG_LEVEL"},
+	})
+
+	if !strings.Contains(out, "# --- Database ---\nDATABASE_URL=\n\n# --- Other ---\nLOG_LEVEL=\n") {
+		t.Errorf("ungrouped keys should get their own header:\n%s", out)
+	}
+	if strings.Count(RenderExample(exampleSchema()), "# --- Other ---") != 0 {
+		t.Error("ungrouped keys that come first need no header")
+	}
+}
+
+func TestCheckExample(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env.example")
+	schema := exampleSchema()
+
+	if err := os.WriteFile(path, []byte(RenderExample(schema)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	drift, err := CheckExample(path, schema)
+	if err != nil {
+		t.Fatalf("CheckExample failed: %v", err)
+	}
+	if drift.HasDrift() {
+		t.Errorf("freshly rendered file reported drift: %+v", drift)
+	}
+
+	// CRLF line endings alone aren't drift
+	crlf := strings.ReplaceAll(RenderExample(schema), "\n", "\r\n")
+	if err := os.WriteFile(path, []byte(crlf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	drift, err = CheckExample(path, schema)
+	if err != nil {
+		t.Fatalf("CheckExample failed: %v", err)
+	}
+	if drift.HasDrift() {
+		t.Errorf("CRLF file reported drift: %+v", drift)
+	}
+
+	// Schema gains a key and loses one
+	changed := append(schema[1:], model.Secret{Name: "NEW_FLAG"})
+	drift, err = CheckExample(path, changed)
+	if err != nil {
+		t.Fatalf("CheckExample failed: %v", err)
+	}
+	if len(drift.Missing) != 1 || drift.Missing[0] != "NEW_FLAG" {
+		t.Errorf("Missing = %v, want [NEW_FLAG]", drift.Missing)
+	}
+	if len(drift.Stale) != 1 || drift.Stale[0] != "API_KEY" {
+		t.Errorf("Stale = %v, want [API_KEY]", drift.Stale)
+	}
+
+	// Same keys, different description
+	schema[0].Description = "Changed"
+	drift, err = CheckExample(path, schema)
+	if err != nil {
+		t.Fatalf("CheckExample failed: %v", err)
+	}
+	if !drift.Content || len(drift.Missing) != 0 || len(drift.Stale) != 0 {
+		t.Errorf("expected content-only drift, got %+v", drift)
+	}
+}
@@ -2,6 +2,7 @@ package source
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
@@ -61,13 +62,25 @@ func (f *FileSource) load() error {
 		return nil
 	}
 
-	file, err := os.Open(f.path)
+	data, err := os.ReadFile(f.path)
 	if err != nil {
 		return fmt.Errorf("failed to open %s: %w", f.path, err)
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	secrets, err := ParseEnv(data)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	f.secrets = secrets
+	f.loaded = true
+	return nil
+}
+
+// ParseEnv parses dotenv content into a name -> value map
+func ParseEnv(data []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
@@ -88,11 +101,13 @@ func (f *FileSource) load() error {
 		// Remove surrounding quotes
 		value = unquote(value)
 
-		f.secrets[key] = value
+		secrets[key] = value
 	}
 
-	f.loaded = true
-	return scanner.Err()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secrets, nil
 }
 
 func (f *FileSource) Get(name string) string {
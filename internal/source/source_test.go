@@ -0,0 +1,24 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSource_LineTooLong(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	data := "A=1\nB=" + strings.Repeat("x", 70*1024) + "\nC=3\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A line over the scanner's limit fails the read instead of cutting it short
+	if _, err := ParseEnv([]byte(data)); err == nil {
+		t.Error("ParseEnv: expected error for a line over 64KB")
+	}
+	if got := NewFileSource(path).GetAll([]string{"A", "C"}); got != nil {
+		t.Errorf("GetAll = %v, want nil", got)
+	}
+}
@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/source"
+)
+
+// CrossCheck compares the values being synced against another environment's
+// values, to catch test keys pushed to live (and the reverse).
+type CrossCheck struct {
+	Environment string          // The other local environment, e.g. "live"
+	Source      source.Source   // Values for the other environment
+	Allowed     map[string]bool // Keys whose values may be shared (allow_shared in the schema)
+	MinLength   int             // Values shorter than this are not compared
+}
+
+// Check returns a warning for each of names whose value also appears in the
+// other environment, either under the same key or under any of schemaNames.
+func (c *CrossCheck) Check(schemaNames, names []string, values map[string]string) map[string]string {
+	other := c.Source.GetAll(schemaNames)
+
+	// Reverse lookup of the other environment's values
+	byValue := make(map[string][]string)
+	for name, value := range other {
+		if value != "" && !c.Allowed[name] {
+			byValue[value] = append(byValue[value], name)
+		}
+	}
+
+	warnings := make(map[string]string)
+	for _, name := range names {
+		value := values[name]
+		if value == "" || len(value) < c.MinLength || c.Allowed[name] {
+			continue
+		}
+		matches := byValue[value]
+		if len(matches) == 0 {
+			continue
+		}
+		if other[name] == value {
+			warnings[name] = fmt.Sprintf("same value as in %s", c.Environment)
+			continue
+		}
+		sort.Strings(matches)
+		warnings[name] = fmt.Sprintf("same value as %s in %s", strings.Join(matches, ", "), c.Environment)
+	}
+
+	return warnings
+}
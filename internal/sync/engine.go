@@ -3,6 +3,7 @@ package sync
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/auth"
 	"github.com/dotenvy-dev/dotenvy/internal/model"
@@ -11,18 +12,27 @@ import (
 )
 
 // Engine orchestrates the sync process
-type Engine struct{}
+type Engine struct {
+	crossCheck *CrossCheck
+}
 
 // NewEngine creates a new sync engine
 func NewEngine() *Engine {
 	return &Engine{}
 }
 
+// SetCrossCheck enables cross-environment checks in Preview. Pass nil to
+// disable them.
+func (e *Engine) SetCrossCheck(cc *CrossCheck) {
+	e.crossCheck = cc
+}
+
 // SyncOptions configures sync behavior
 type SyncOptions struct {
-	DryRun      bool
-	Environment string          // "test" or "live"
-	Progress    ProgressCallback
+	DryRun          bool
+	Environment     string // "test" or "live"
+	Progress        ProgressCallback
+	BlockOnWarnings bool // Refuse to write if Preview reported cross-environment warnings
 }
 
 // ProgressCallback is called during sync operations
@@ -78,8 +88,20 @@ func (e *Engine) Preview(ctx context.Context, secretNames []string, src source.S
 
 	// Build remote lookup
 	remoteMap := make(map[string]string)
+	remoteDigests := make(map[string]string)
+	unreadable := make(map[string]bool)
 	for _, s := range remoteSecrets {
 		remoteMap[s.Name] = s.Value
+		if s.Digest != "" {
+			remoteDigests[s.Name] = s.Digest
+		}
+		unreadable[s.Name] = writeOnly || s.Unreadable
+	}
+	digests, hasDigests := prov.(provider.DigestReader)
+
+	var warnings map[string]string
+	if e.crossCheck != nil {
+		warnings = e.crossCheck.Check(secretNames, filteredNames, sourceValues)
 	}
 
 	// Calculate diff
@@ -97,7 +119,14 @@ func (e *Engine) Preview(ctx context.Context, secretNames []string, src source.S
 		if !hasLocal || localValue == "" {
 			// No local value - skip (don't delete remote)
 			continue
-		} else if writeOnly && hasRemote {
+		} else if unreadable[name] && hasDigests && remoteDigests[name] != "" {
+			// Compare digests, since the value can't be read back
+			if digests.MatchesDigest(localValue, remoteDigests[name]) {
+				diffType = model.DiffUnchanged
+			} else {
+				diffType = model.DiffChange
+			}
+		} else if unreadable[name] {
 			diffType = model.DiffUnknown
 		} else if !hasRemote {
 			diffType = model.DiffAdd
@@ -113,6 +142,7 @@ func (e *Engine) Preview(ctx context.Context, secretNames []string, src source.S
 			OldValue:    remoteValue,
 			NewValue:    localValue,
 			Environment: remoteEnv,
+			Warning:     warnings[name],
 		})
 	}
 
@@ -132,6 +162,16 @@ func (e *Engine) Sync(ctx context.Context, secretNames []string, src source.Sour
 		return nil, err
 	}
 
+	if opts.BlockOnWarnings && !opts.DryRun {
+		if warnings := diff.Warnings(); len(warnings) > 0 {
+			names := make([]string, len(warnings))
+			for i, w := range warnings {
+				names[i] = w.Name
+			}
+			return nil, fmt.Errorf("blocked: values shared with another environment: %s", strings.Join(names, ", "))
+		}
+	}
+
 	if opts.DryRun {
 		// Just count what would happen
 		for _, d := range diff.Diffs {
@@ -161,11 +201,14 @@ func (e *Engine) Sync(ctx context.Context, secretNames []string, src source.Sour
 	}
 
 	// Apply changes
+	batch, isBatch := writer.(provider.BatchWriter)
+	var pending []model.SecretDiff
 	for _, d := range diff.Diffs {
 		if d.Type == model.DiffUnchanged {
 			result.Unchanged++
 			continue
 		}
+		pending = append(pending, d)
 
 		if opts.Progress != nil {
 			opts.Progress(ProgressEvent{
@@ -178,49 +221,71 @@ func (e *Engine) Sync(ctx context.Context, secretNames []string, src source.Sour
 			})
 		}
 
-		var syncErr error
-		switch d.Type {
-		case model.DiffAdd, model.DiffChange, model.DiffUnknown:
-			syncErr = writer.Set(ctx, d.Name, d.NewValue, remoteEnv)
+		if !isBatch {
+			recordSync(result, target.Name, remoteEnv, d, writer.Set(ctx, d.Name, d.NewValue, remoteEnv), opts)
 		}
+	}
+
+	// Batch writers store everything in one call, which succeeds or fails as a whole
+	if isBatch && len(pending) > 0 {
+		values := make(map[string]string, len(pending))
+		for _, d := range pending {
+			values[d.Name] = d.NewValue
+		}
+		err := batch.SetMany(ctx, values, remoteEnv)
+		for _, d := range pending {
+			recordSync(result, target.Name, remoteEnv, d, err, opts)
+		}
+	}
 
-		if syncErr != nil {
+	// Staged writes go live in one deploy once everything is written
+	if deployer, ok := writer.(provider.Deployer); ok && result.Added+result.Changed+result.Unknown > 0 {
+		if err := deployer.Deploy(ctx, remoteEnv); err != nil {
 			result.Failed++
-			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", d.Name, syncErr))
-			if opts.Progress != nil {
-				opts.Progress(ProgressEvent{
-					Phase:       "sync",
-					TargetName:  target.Name,
-					SecretName:  d.Name,
-					Environment: remoteEnv,
-					Success:     false,
-					Error:       syncErr,
-				})
-			}
-		} else {
-			switch d.Type {
-			case model.DiffAdd:
-				result.Added++
-			case model.DiffUnknown:
-				result.Unknown++
-			default:
-				result.Changed++
-			}
-			if opts.Progress != nil {
-				opts.Progress(ProgressEvent{
-					Phase:       "sync",
-					TargetName:  target.Name,
-					SecretName:  d.Name,
-					Environment: remoteEnv,
-					Success:     true,
-				})
-			}
+			result.Errors = append(result.Errors, fmt.Errorf("deploy: %w", err))
 		}
 	}
 
 	return result, nil
 }
 
+// recordSync counts the outcome of writing one secret and reports it
+func recordSync(result *SyncResult, targetName, remoteEnv string, d model.SecretDiff, syncErr error, opts SyncOptions) {
+	if syncErr != nil {
+		result.Failed++
+		result.Errors = append(result.Errors, fmt.Errorf("%s: %w", d.Name, syncErr))
+		if opts.Progress != nil {
+			opts.Progress(ProgressEvent{
+				Phase:       "sync",
+				TargetName:  targetName,
+				SecretName:  d.Name,
+				Environment: remoteEnv,
+				Success:     false,
+				Error:       syncErr,
+			})
+		}
+		return
+	}
+
+	switch d.Type {
+	case model.DiffAdd:
+		result.Added++
+	case model.DiffUnknown:
+		result.Unknown++
+	default:
+		result.Changed++
+	}
+	if opts.Progress != nil {
+		opts.Progress(ProgressEvent{
+			Phase:       "sync",
+			TargetName:  targetName,
+			SecretName:  d.Name,
+			Environment: remoteEnv,
+			Success:     true,
+		})
+	}
+}
+
 // CheckAuth validates authentication for a target
 func (e *Engine) CheckAuth(target model.Target) auth.AuthStatus {
 	return auth.CheckAuth(target.Name, target.Type, target.Config)
@@ -240,6 +305,9 @@ func (e *Engine) Pull(ctx context.Context, target model.Target, remoteEnv string
 
 	result := make(map[string]string)
 	for _, s := range secrets {
+		if s.Unreadable {
+			continue
+		}
 		result[s.Name] = s.Value
 	}
 
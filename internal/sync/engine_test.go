@@ -3,6 +3,7 @@ package sync
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
@@ -106,6 +107,106 @@ func init() {
 	})
 }
 
+// mockBatchProvider records SetMany calls instead of individual Sets
+type mockBatchProvider struct {
+	*mockProvider
+	batches  []map[string]string
+	batchErr error
+}
+
+func (m *mockBatchProvider) SetMany(ctx context.Context, values map[string]string, env string) error {
+	m.batches = append(m.batches, values)
+	if m.batchErr != nil {
+		return m.batchErr
+	}
+	for name, value := range values {
+		addMockSecret(env, name, value)
+	}
+	return nil
+}
+
+// lastBatchProvider is the most recent mock-batch instance, for assertions
+var lastBatchProvider *mockBatchProvider
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "mock-batch",
+		DisplayName: "Mock Batch Provider",
+		Factory: func(config map[string]any) (provider.SyncTarget, error) {
+			lastBatchProvider = &mockBatchProvider{mockProvider: newMockProvider("mock-batch")}
+			if msg, ok := config["fail"].(string); ok {
+				lastBatchProvider.batchErr = errors.New(msg)
+			}
+			return lastBatchProvider, nil
+		},
+	})
+}
+
+// mockMixedProvider can read values except those named SECRET_*
+type mockMixedProvider struct {
+	*mockProvider
+}
+
+func (m *mockMixedProvider) List(ctx context.Context, env string) ([]model.SecretValue, error) {
+	secrets, err := m.mockProvider.List(ctx, env)
+	for i := range secrets {
+		if strings.HasPrefix(secrets[i].Name, "SECRET_") {
+			secrets[i].Value, secrets[i].Unreadable = "", true
+		}
+	}
+	return secrets, err
+}
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "mock-mixed",
+		DisplayName: "Mock Mixed Provider",
+		Factory: func(config map[string]any) (provider.SyncTarget, error) {
+			return &mockMixedProvider{mockProvider: newMockProvider("mock-mixed")}, nil
+		},
+	})
+}
+
+// mockStagedProvider can't read values back; it lists digests instead and
+// makes writes live on Deploy
+type mockStagedProvider struct {
+	*mockBatchProvider
+	deploys []string
+}
+
+func (m *mockStagedProvider) List(ctx context.Context, env string) ([]model.SecretValue, error) {
+	secrets, err := m.mockProvider.List(ctx, env)
+	for i := range secrets {
+		secrets[i].Digest = "digest:" + secrets[i].Value
+		secrets[i].Value = ""
+	}
+	return secrets, err
+}
+
+func (m *mockStagedProvider) MatchesDigest(value, digest string) bool {
+	return digest == "digest:"+value
+}
+
+func (m *mockStagedProvider) Deploy(ctx context.Context, env string) error {
+	m.deploys = append(m.deploys, env)
+	return nil
+}
+
+// lastStagedProvider is the most recent mock-staged instance, for assertions
+var lastStagedProvider *mockStagedProvider
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "mock-staged",
+		DisplayName: "Mock Staged Provider",
+		Factory: func(config map[string]any) (provider.SyncTarget, error) {
+			lastStagedProvider = &mockStagedProvider{mockBatchProvider: &mockBatchProvider{mockProvider: newMockProvider("mock-staged")}}
+			return lastStagedProvider, nil
+		},
+		WriteOnly: true,
+	})
+}
+
 // mockSource provides secret values for testing
 type mockSource struct {
 	values map[string]string
@@ -280,6 +381,178 @@ func TestEngine_Sync_DryRun(t *testing.T) {
 	}
 }
 
+func TestEngine_Sync_BatchWriter(t *testing.T) {
+	clearMockSecrets()
+	addMockSecret("production", "KEY2", "old")
+	addMockSecret("production", "KEY3", "same")
+	engine := NewEngine()
+	ctx := context.Background()
+
+	src := newMockSource(map[string]string{"KEY1": "new", "KEY2": "changed", "KEY3": "same"})
+	target := model.Target{Name: "batch", Type: "mock-batch", Config: map[string]any{}}
+
+	result, err := engine.Sync(ctx, []string{"KEY1", "KEY2", "KEY3"}, src, target, "production", SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	p := lastBatchProvider
+	if len(p.setCalls) != 0 {
+		t.Errorf("Set called %d times, want 0", len(p.setCalls))
+	}
+	if len(p.batches) != 1 || len(p.batches[0]) != 2 || p.batches[0]["KEY1"] != "new" || p.batches[0]["KEY2"] != "changed" {
+		t.Errorf("batches = %v, want one batch with KEY1 and KEY2", p.batches)
+	}
+	if result.Added != 1 || result.Changed != 1 || result.Unchanged != 1 {
+		t.Errorf("result = %+v", result)
+	}
+
+	// A failed batch fails every key in it
+	target.Config["fail"] = "rate limited"
+	src.values["KEY3"] = "different"
+	result, err = engine.Sync(ctx, []string{"KEY1", "KEY2", "KEY3"}, src, target, "production", SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Failed != 1 || len(result.Errors) != 1 {
+		t.Errorf("result = %+v, want KEY3 failed", result)
+	}
+}
+
+func TestEngine_Preview_UnreadableKeys(t *testing.T) {
+	clearMockSecrets()
+	addMockSecret("production", "PLAIN", "same")
+	addMockSecret("production", "SECRET_KEY", "same")
+	engine := NewEngine()
+	ctx := context.Background()
+
+	src := newMockSource(map[string]string{"PLAIN": "same", "SECRET_KEY": "same"})
+	target := model.Target{Name: "mixed", Type: "mock-mixed", Config: map[string]any{}}
+
+	diff, err := engine.Preview(ctx, []string{"PLAIN", "SECRET_KEY"}, src, target, "production")
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	want := map[string]model.DiffType{"PLAIN": model.DiffUnchanged, "SECRET_KEY": model.DiffUnknown}
+	for _, d := range diff.Diffs {
+		if d.Type != want[d.Name] {
+			t.Errorf("%s: diff type = %v, want %v", d.Name, d.Type, want[d.Name])
+		}
+	}
+
+	// Unreadable values aren't pulled as empty strings
+	pulled, err := engine.Pull(ctx, target, "production")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if _, ok := pulled["SECRET_KEY"]; ok || pulled["PLAIN"] != "same" {
+		t.Errorf("Pull = %v, want PLAIN only", pulled)
+	}
+}
+
+func TestEngine_Sync_DigestsAndDeploy(t *testing.T) {
+	clearMockSecrets()
+	addMockSecret("production", "KEY2", "old")
+	addMockSecret("production", "KEY3", "same")
+	engine := NewEngine()
+	ctx := context.Background()
+
+	src := newMockSource(map[string]string{"KEY1": "new", "KEY2": "changed", "KEY3": "same"})
+	target := model.Target{Name: "staged", Type: "mock-staged", Config: map[string]any{}}
+
+	diff, err := engine.Preview(ctx, []string{"KEY1", "KEY2", "KEY3"}, src, target, "production")
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	want := map[string]model.DiffType{"KEY1": model.DiffAdd, "KEY2": model.DiffChange, "KEY3": model.DiffUnchanged}
+	for _, d := range diff.Diffs {
+		if d.Type != want[d.Name] {
+			t.Errorf("%s: diff type = %v, want %v", d.Name, d.Type, want[d.Name])
+		}
+	}
+
+	result, err := engine.Sync(ctx, []string{"KEY1", "KEY2", "KEY3"}, src, target, "production", SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Added != 1 || result.Changed != 1 || result.Unchanged != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if p := lastStagedProvider; len(p.deploys) != 1 || p.deploys[0] != "production" {
+		t.Errorf("deploys = %v, want one for production", p.deploys)
+	}
+
+	// Nothing written, nothing deployed
+	result, err = engine.Sync(ctx, []string{"KEY1", "KEY2", "KEY3"}, src, target, "production", SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Unchanged != 3 || len(lastStagedProvider.deploys) != 0 {
+		t.Errorf("result = %+v, deploys = %v, want all unchanged and no deploy", result, lastStagedProvider.deploys)
+	}
+}
+
+func TestEngine_Preview_CrossCheck(t *testing.T) {
+	clearMockSecrets()
+	engine := NewEngine()
+	engine.SetCrossCheck(&CrossCheck{
+		Environment: "test",
+		Source: newMockSource(map[string]string{
+			"STRIPE_KEY":  "sk_test_shared_123",
+			"WEBHOOK_KEY": "whsec_from_test_99",
+			"PUBLIC_URL":  "https://example.com",
+			"DEBUG":       "true",
+		}),
+		Allowed:   map[string]bool{"PUBLIC_URL": true},
+		MinLength: 8,
+	})
+	ctx := context.Background()
+
+	secretNames := []string{"STRIPE_KEY", "SIGNING_KEY", "WEBHOOK_KEY", "PUBLIC_URL", "DEBUG", "OTHER_KEY"}
+	src := newMockSource(map[string]string{
+		"STRIPE_KEY":  "sk_test_shared_123",  // Identical across environments
+		"SIGNING_KEY": "whsec_from_test_99",  // Test's WEBHOOK_KEY under another name
+		"PUBLIC_URL":  "https://example.com", // Allowed to be shared
+		"DEBUG":       "true",                // Too short to compare
+		"OTHER_KEY":   "sk_live_distinct_1",
+	})
+
+	target := model.Target{
+		Name:    "test-target",
+		Type:    "mock",
+		Mapping: map[string]string{"production": "live"},
+		Config:  map[string]any{"token": "test"},
+	}
+
+	diff, err := engine.Preview(ctx, secretNames, src, target, "production")
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+
+	warnings := make(map[string]string)
+	for _, d := range diff.Warnings() {
+		warnings[d.Name] = d.Warning
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warnings)
+	}
+	if warnings["STRIPE_KEY"] != "same value as in test" {
+		t.Errorf("STRIPE_KEY warning = %q", warnings["STRIPE_KEY"])
+	}
+	if warnings["SIGNING_KEY"] != "same value as WEBHOOK_KEY in test" {
+		t.Errorf("SIGNING_KEY warning = %q", warnings["SIGNING_KEY"])
+	}
+
+	// Blocking refuses to write anything
+	_, err = engine.Sync(ctx, secretNames, src, target, "production", SyncOptions{BlockOnWarnings: true})
+	if err == nil {
+		t.Fatal("expected Sync to be blocked")
+	}
+	if len(sharedMockSecrets["production"]) != 0 {
+		t.Errorf("blocked sync wrote secrets: %v", sharedMockSecrets["production"])
+	}
+}
+
 func TestEngine_CheckAuth(t *testing.T) {
 	engine := NewEngine()
 
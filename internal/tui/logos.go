@@ -184,3 +184,42 @@ func RenderDetectionSummary(result *detect.Result) string {
 
 	return b.String()
 }
+
+// RenderFileDetections renders the settings found in platform config files
+// (fly.toml, .vercel/project.json, ...) that will pre-fill the init prompts.
+func RenderFileDetections(matches detect.FileMatches) string {
+	if len(matches) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	headerStyle := lipgloss.NewStyle().Foreground(Muted)
+	b.WriteString(headerStyle.Render("  Found platform config files"))
+	b.WriteString("\n\n")
+
+	nameStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(Muted)
+	weakStyle := lipgloss.NewStyle().Foreground(Muted).Italic(true)
+
+	for _, m := range matches {
+		color, ok := providerColors[m.ProviderName]
+		if !ok {
+			color = lipgloss.Color("252")
+		}
+		provStyle := lipgloss.NewStyle().Foreground(color)
+
+		line := fmt.Sprintf("  %s %s", provStyle.Render("●"), nameStyle.Render(providerDisplayName(m.ProviderName)))
+		if m.Field != "" {
+			line += fmt.Sprintf("  %s = %s", m.Field, m.Value)
+		}
+		line += "  " + mutedStyle.Render(fmt.Sprintf("(%s)", m.Reason))
+		if m.Confidence != "strong" {
+			line += "  " + weakStyle.Render("(inferred)")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -28,6 +28,8 @@ type SyncConfig struct {
 	Tasks       []SyncTask
 	DryRun      bool
 	LocalEnv    string
+	CrossCheck  *sync.CrossCheck // Optional comparison against the other environment
+	BlockLeaks  bool             // Skip writes for targets with cross-check warnings
 }
 
 // SyncUI styles
@@ -181,9 +183,12 @@ func NewSyncModel(cfg SyncConfig) SyncModel {
 		}
 	}
 
+	engine := sync.NewEngine()
+	engine.SetCrossCheck(cfg.CrossCheck)
+
 	return SyncModel{
 		config:      cfg,
-		engine:      sync.NewEngine(),
+		engine:      engine,
 		ctx:         context.Background(),
 		taskResults: results,
 		phase:       "auth",
@@ -247,7 +252,7 @@ func (m SyncModel) runSync(taskIndex int) tea.Cmd {
 			m.config.Source,
 			task.Target,
 			task.RemoteEnv,
-			sync.SyncOptions{DryRun: m.config.DryRun},
+			sync.SyncOptions{DryRun: m.config.DryRun, BlockOnWarnings: m.config.BlockLeaks},
 		)
 		return syncDoneMsg{taskIndex: taskIndex, result: result, err: err}
 	}
@@ -492,6 +497,18 @@ func (m SyncModel) renderTask(index int, result TaskResult) string {
 		}
 	}
 
+	// Show cross-environment warnings
+	if result.Diff != nil {
+		for _, d := range result.Diff.Warnings() {
+			b.WriteString("\n")
+			b.WriteString("    ")
+			b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("!"))
+			b.WriteString(" ")
+			b.WriteString(d.Name)
+			b.WriteString(dimStyle.Render(" (" + d.Warning + ")"))
+		}
+	}
+
 	// Show error
 	if result.Status == TaskFailed && result.Error != nil {
 		b.WriteString("\n")
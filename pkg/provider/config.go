@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// EnvSetting returns a string setting for a remote environment. A value under
+// config["environments"][environment] overrides the top-level value.
+func EnvSetting(config map[string]any, environment, key string) string {
+	switch envs := config["environments"].(type) {
+	case map[string]map[string]string:
+		if v := envs[environment][key]; v != "" {
+			return v
+		}
+	case map[string]any:
+		if env, ok := envs[environment].(map[string]any); ok {
+			if v, _ := env[key].(string); v != "" {
+				return v
+			}
+		}
+	}
+	v, _ := config[key].(string)
+	return v
+}
+
+// ConfigOrEnv returns a string setting, expanding ${VAR} references, or the
+// envVar environment variable if the setting is absent
+func ConfigOrEnv(config map[string]any, key, envVar string) string {
+	if v, _ := config[key].(string); v != "" {
+		return os.ExpandEnv(v)
+	}
+	return os.Getenv(envVar)
+}
+
+// ExpandEnv replaces {env} in a setting with the remote environment name, so
+// one template like /myapp/{env}/ serves every environment
+func ExpandEnv(template, environment string) string {
+	return strings.ReplaceAll(template, "{env}", environment)
+}
+
+// MapSetting returns a string map setting such as tags, with {env} expanded
+// in the values. It returns nil if the setting is absent.
+func MapSetting(config map[string]any, key, environment string) map[string]string {
+	var result map[string]string
+	switch m := config[key].(type) {
+	case map[string]string:
+		result = make(map[string]string, len(m))
+		for k, v := range m {
+			result[k] = ExpandEnv(v, environment)
+		}
+	case map[string]any:
+		result = make(map[string]string, len(m))
+		for k, v := range m {
+			s, _ := v.(string)
+			result[k] = ExpandEnv(s, environment)
+		}
+	}
+	return result
+}
+
+// ConfiguredEnvironments returns the sorted environment names that have
+// overrides in config["environments"], or nil if there are none.
+func ConfiguredEnvironments(config map[string]any) []string {
+	var names []string
+	switch envs := config["environments"].(type) {
+	case map[string]map[string]string:
+		for name := range envs {
+			names = append(names, name)
+		}
+	case map[string]any:
+		for name := range envs {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SecretSchema returns the schema metadata for a secret, as passed in
+// config["_schema"]. Secrets without metadata get a bare entry.
+func SecretSchema(config map[string]any, name string) model.Secret {
+	if schema, ok := config["_schema"].(map[string]model.Secret); ok {
+		if s, ok := schema[name]; ok {
+			s.Name = name
+			return s
+		}
+	}
+	return model.Secret{Name: name}
+}
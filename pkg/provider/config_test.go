@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+func TestEnvSetting(t *testing.T) {
+	config := map[string]any{
+		"mount": "secret",
+		"path":  "myapp",
+		"environments": map[string]map[string]string{
+			"production": {"mount": "kv-prod"},
+		},
+	}
+
+	if got := EnvSetting(config, "production", "mount"); got != "kv-prod" {
+		t.Errorf("production mount = %q, want 'kv-prod'", got)
+	}
+	if got := EnvSetting(config, "production", "path"); got != "myapp" {
+		t.Errorf("production path = %q, want top-level 'myapp'", got)
+	}
+	if got := EnvSetting(config, "development", "mount"); got != "secret" {
+		t.Errorf("development mount = %q, want 'secret'", got)
+	}
+}
+
+func TestEnvSetting_GenericMap(t *testing.T) {
+	// Configs decoded without the typed TargetDef use map[string]any
+	config := map[string]any{
+		"environments": map[string]any{
+			"staging": map[string]any{"path": "myapp/staging"},
+		},
+	}
+
+	if got := EnvSetting(config, "staging", "path"); got != "myapp/staging" {
+		t.Errorf("staging path = %q, want 'myapp/staging'", got)
+	}
+	if got := ConfiguredEnvironments(config); len(got) != 1 || got[0] != "staging" {
+		t.Errorf("ConfiguredEnvironments() = %v, want [staging]", got)
+	}
+}
+
+func TestMapSetting(t *testing.T) {
+	config := map[string]any{
+		"tags":   map[string]string{"team": "shop", "stage": "{env}"},
+		"labels": map[string]any{"stage": "{env}"},
+	}
+
+	if got := MapSetting(config, "tags", "staging"); len(got) != 2 || got["team"] != "shop" || got["stage"] != "staging" {
+		t.Errorf("tags = %v", got)
+	}
+	if got := MapSetting(config, "labels", "prod"); got["stage"] != "prod" {
+		t.Errorf("labels = %v", got)
+	}
+	if got := MapSetting(config, "missing", "prod"); got != nil {
+		t.Errorf("missing = %v, want nil", got)
+	}
+	if got := ExpandEnv("/myapp/{env}/", "staging"); got != "/myapp/staging/" {
+		t.Errorf("ExpandEnv() = %q", got)
+	}
+}
+
+func TestConfigOrEnv(t *testing.T) {
+	t.Setenv("DOTENVY_TEST_ROLE", "from-env")
+	t.Setenv("DOTENVY_TEST_SECRET", "expanded")
+	config := map[string]any{"secret_id": "${DOTENVY_TEST_SECRET}"}
+
+	if got := ConfigOrEnv(config, "secret_id", "UNSET"); got != "expanded" {
+		t.Errorf("secret_id = %q, want 'expanded'", got)
+	}
+	if got := ConfigOrEnv(config, "role_id", "DOTENVY_TEST_ROLE"); got != "from-env" {
+		t.Errorf("role_id = %q, want 'from-env'", got)
+	}
+}
+
+func TestSecretSchema(t *testing.T) {
+	plain := false
+	config := map[string]any{
+		"_schema": map[string]model.Secret{
+			"PUBLIC_URL": {Sensitive: &plain},
+		},
+	}
+
+	if s := SecretSchema(config, "PUBLIC_URL"); s.Name != "PUBLIC_URL" || s.IsSensitive() {
+		t.Errorf("PUBLIC_URL = %+v, want non-sensitive", s)
+	}
+	if !SecretSchema(config, "API_KEY").IsSensitive() {
+		t.Error("keys without metadata should be sensitive")
+	}
+	if !SecretSchema(map[string]any{}, "API_KEY").IsSensitive() {
+		t.Error("keys should be sensitive without a schema")
+	}
+}
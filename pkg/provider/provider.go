@@ -36,6 +36,31 @@ type Writer interface {
 	Delete(ctx context.Context, name, environment string) error
 }
 
+// BatchWriter is a Writer that can store several secrets in one call. The
+// sync engine uses it instead of Set when a provider implements it.
+type BatchWriter interface {
+	Writer
+	// SetMany creates or updates secrets in one request
+	SetMany(ctx context.Context, values map[string]string, environment string) error
+}
+
+// DigestReader is a write-only Reader whose List reports a digest of each
+// stored value instead of the value. The sync engine uses it to tell
+// unchanged secrets from changed ones.
+type DigestReader interface {
+	Reader
+	// MatchesDigest reports whether value is the one a listed digest was made from
+	MatchesDigest(value, digest string) bool
+}
+
+// Deployer is a Writer whose writes are staged until Deploy. The sync engine
+// calls Deploy once after writing a target's changes.
+type Deployer interface {
+	Writer
+	// Deploy releases the staged changes
+	Deploy(ctx context.Context, environment string) error
+}
+
 // SyncTarget is a provider that supports both reading and writing
 type SyncTarget interface {
 	Reader
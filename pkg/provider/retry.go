@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+)
+
+// conflictRetries bounds how often a write is retried after a concurrent update
+const conflictRetries = 3
+
+// RetryOnConflict runs a read-modify-write, starting over while it fails
+// with conflict. If what it writes keeps changing, the error names it with
+// what, e.g. "koyeb: service svc-1".
+func RetryOnConflict(conflict error, what string, write func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := write()
+		if errors.Is(err, conflict) && attempt < conflictRetries {
+			continue
+		}
+		if errors.Is(err, conflict) {
+			return fmt.Errorf("%s kept changing during the write, try again", what)
+		}
+		return err
+	}
+}
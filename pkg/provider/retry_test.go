@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRetryOnConflict(t *testing.T) {
+	conflict := errors.New("conflict")
+
+	calls := 0
+	err := RetryOnConflict(conflict, "thing", func() error {
+		calls++
+		if calls < 3 {
+			return conflict
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("err = %v, calls = %d", err, calls)
+	}
+
+	calls = 0
+	err = RetryOnConflict(conflict, "thing", func() error {
+		calls++
+		return conflict
+	})
+	if err == nil || err.Error() != "thing kept changing during the write, try again" || calls != conflictRetries+1 {
+		t.Errorf("err = %v, calls = %d", err, calls)
+	}
+
+	other := errors.New("denied")
+	calls = 0
+	err = RetryOnConflict(conflict, "thing", func() error {
+		calls++
+		return other
+	})
+	if err != other || calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, calls)
+	}
+}
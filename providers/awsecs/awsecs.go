@@ -0,0 +1,381 @@
+package awsecs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+	"github.com/dotenvy-dev/dotenvy/providers/awsssm"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "aws-ecs",
+		DisplayName: "AWS ECS",
+		Factory:     New,
+		SdkAuth:     true,
+		Beta:        true,
+	})
+}
+
+// Provider implements the AWS ECS task definition provider. Each remote
+// environment is one container in a task definition family; every write
+// registers a new revision. With prefix set, sensitive values are stored
+// in SSM Parameter Store and referenced from the container's secrets.
+//
+// ECS can't register a revision conditionally, so Register checks that the
+// revision it edited is still the latest just before registering. A revision
+// registered by someone else in between is not detected and is superseded.
+type Provider struct {
+	client *client
+	config map[string]any
+}
+
+// New creates a new AWS ECS provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	region, _ := config["region"].(string)
+	if region == "" {
+		return nil, fmt.Errorf("aws-ecs: region is required")
+	}
+
+	p := &Provider{config: config}
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "task_definition") == "" {
+			return nil, fmt.Errorf("aws-ecs: task_definition is required for environment %s", env)
+		}
+	}
+
+	profile, _ := config["profile"].(string)
+
+	cfg, err := awsssm.LoadAWSConfig(context.Background(), region, profile)
+	if err != nil {
+		return nil, fmt.Errorf("aws-ecs: failed to load AWS config: %w", err)
+	}
+
+	p.client = newClient(ecs.NewFromConfig(cfg), ssm.NewFromConfig(cfg))
+	return p, nil
+}
+
+func (p *Provider) Name() string        { return "aws-ecs" }
+func (p *Provider) DisplayName() string { return "AWS ECS" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"default"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"default": "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	for _, env := range p.Environments() {
+		if _, _, _, err := p.describe(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describe returns the latest revision of an environment's family, its tags
+// and the environment's container in it
+func (p *Provider) describe(ctx context.Context, environment string) (*ecstypes.TaskDefinition, []ecstypes.Tag, *ecstypes.ContainerDefinition, error) {
+	def, tags, err := p.client.Describe(ctx, provider.EnvSetting(p.config, environment, "task_definition"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	container, err := findContainer(def, provider.EnvSetting(p.config, environment, "container"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return def, tags, container, nil
+}
+
+// findContainer returns the named container, or the only one if name is
+// empty
+func findContainer(def *ecstypes.TaskDefinition, name string) (*ecstypes.ContainerDefinition, error) {
+	family := aws.ToString(def.Family)
+	if name == "" {
+		if len(def.ContainerDefinitions) != 1 {
+			return nil, fmt.Errorf("aws-ecs: task definition %s has %d containers, set container", family, len(def.ContainerDefinitions))
+		}
+		return &def.ContainerDefinitions[0], nil
+	}
+	for i := range def.ContainerDefinitions {
+		if aws.ToString(def.ContainerDefinitions[i].Name) == name {
+			return &def.ContainerDefinitions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("aws-ecs: task definition %s has no container named %q", family, name)
+}
+
+// List returns the container's environment entries and its secrets. Secrets
+// backed by SSM parameters are resolved; others (e.g. Secrets Manager) are
+// listed without a value.
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	_, _, container, err := p.describe(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for _, kv := range container.Environment {
+		secrets = append(secrets, model.SecretValue{
+			Name:        aws.ToString(kv.Name),
+			Value:       aws.ToString(kv.Value),
+			Environment: environment,
+		})
+	}
+
+	var refs []string
+	for _, s := range container.Secrets {
+		if ref := aws.ToString(s.ValueFrom); isParameterRef(ref) {
+			refs = append(refs, ref)
+		}
+	}
+	values, err := p.client.GetParameters(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	// Secrets Manager secrets and parameters that can't be read list as
+	// unreadable, so they aren't compared against an empty value
+	for _, s := range container.Secrets {
+		value, ok := values[aws.ToString(s.ValueFrom)]
+		secrets = append(secrets, model.SecretValue{
+			Name:        aws.ToString(s.Name),
+			Value:       value,
+			Environment: environment,
+			Unreadable:  !ok,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values with at most one new revision
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// edit is the outcome of merging changes into a container definition
+type edit struct {
+	environment []ecstypes.KeyValuePair
+	secrets     []ecstypes.Secret
+	parameters  map[string]string // SSM parameters to write
+	orphans     []string          // Managed parameters no longer referenced
+	changed     bool              // Whether a new revision is needed
+}
+
+// update applies changes to an environment's container. A nil value
+// deletes the entry. Values already backed by a managed parameter only
+// update the parameter; a revision is registered only when the container
+// definition itself changes.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	family := provider.EnvSetting(p.config, environment, "task_definition")
+	var orphans []string
+	err := provider.RetryOnConflict(errConflict, "aws-ecs: task definition "+family, func() error {
+		def, tags, container, err := p.describe(ctx, environment)
+		if err != nil {
+			return err
+		}
+		e, err := p.merge(container, changes, p.parameters(def, environment))
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(e.parameters))
+		for name := range e.parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := p.client.PutParameter(ctx, name, e.parameters[name]); err != nil {
+				return err
+			}
+		}
+		if !e.changed {
+			return nil
+		}
+
+		container.Environment = e.environment
+		container.Secrets = e.secrets
+		orphans = e.orphans
+		_, err = p.client.Register(ctx, def, tags)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range orphans {
+		if err := p.client.DeleteParameter(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge applies changes to a container's environment and secrets, keeping
+// the order of existing entries. Sensitive values go to a managed parameter
+// when params is set, everything else to the environment. An entry read from
+// secrets is never turned into plain text: its managed parameter is updated,
+// and a reference dotenvy doesn't manage is refused.
+func (p *Provider) merge(container *ecstypes.ContainerDefinition, changes map[string]*string, params *parameters) (edit, error) {
+	e := edit{parameters: make(map[string]string)}
+	seen := make(map[string]bool)
+
+	for _, kv := range container.Environment {
+		name := aws.ToString(kv.Name)
+		seen[name] = true
+		value, ok := changes[name]
+		switch {
+		case !ok:
+			e.environment = append(e.environment, kv)
+		case value == nil:
+			e.changed = true
+		case p.managed(name, params):
+			e.parameters[params.name(name)] = *value
+			e.secrets = append(e.secrets, ecstypes.Secret{Name: kv.Name, ValueFrom: aws.String(params.arn(name))})
+			e.changed = true
+		default:
+			if aws.ToString(kv.Value) != *value {
+				kv.Value = aws.String(*value)
+				e.changed = true
+			}
+			e.environment = append(e.environment, kv)
+		}
+	}
+
+	for _, s := range container.Secrets {
+		name := aws.ToString(s.Name)
+		seen[name] = true
+		ours := params != nil && params.owns(name, aws.ToString(s.ValueFrom))
+		value, ok := changes[name]
+		switch {
+		case !ok:
+			e.secrets = append(e.secrets, s)
+		case value == nil:
+			if ours {
+				e.orphans = append(e.orphans, params.name(name))
+			}
+			e.changed = true
+		case p.managed(name, params):
+			e.parameters[params.name(name)] = *value
+			if !ours {
+				s.ValueFrom = aws.String(params.arn(name))
+				e.changed = true
+			}
+			e.secrets = append(e.secrets, s)
+		case ours:
+			e.parameters[params.name(name)] = *value
+			e.secrets = append(e.secrets, s)
+		default:
+			return edit{}, fmt.Errorf("aws-ecs: %s is read from %s, which dotenvy doesn't manage; change it there, or set a prefix and mark it sensitive", name, aws.ToString(s.ValueFrom))
+		}
+	}
+
+	var added []string
+	for name, value := range changes {
+		if value != nil && !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		if p.managed(name, params) {
+			e.parameters[params.name(name)] = *changes[name]
+			e.secrets = append(e.secrets, ecstypes.Secret{Name: aws.String(name), ValueFrom: aws.String(params.arn(name))})
+		} else {
+			e.environment = append(e.environment, ecstypes.KeyValuePair{Name: aws.String(name), Value: aws.String(*changes[name])})
+		}
+		e.changed = true
+	}
+
+	return e, nil
+}
+
+// managed reports whether a key's value belongs in a managed parameter
+func (p *Provider) managed(name string, params *parameters) bool {
+	return params != nil && provider.SecretSchema(p.config, name).IsSensitive()
+}
+
+// parameters returns where an environment's managed parameters live, or nil
+// if no prefix is configured
+func (p *Provider) parameters(def *ecstypes.TaskDefinition, environment string) *parameters {
+	prefix := provider.EnvSetting(p.config, environment, "prefix")
+	if prefix == "" {
+		return nil
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	// arn:aws:ecs:us-east-1:123456789012:task-definition/web:7
+	parts := strings.Split(aws.ToString(def.TaskDefinitionArn), ":")
+	arnBase := ""
+	if len(parts) >= 5 {
+		arnBase = fmt.Sprintf("arn:%s:ssm:%s:%s:parameter", parts[1], parts[3], parts[4])
+	}
+	return &parameters{prefix: prefix, arnBase: arnBase}
+}
+
+// parameters names the SSM parameters dotenvy manages for a container
+type parameters struct {
+	prefix  string // e.g. /myapp/prod/
+	arnBase string // e.g. arn:aws:ssm:us-east-1:123456789012:parameter
+}
+
+func (ps *parameters) name(key string) string {
+	return ps.prefix + key
+}
+
+// arn returns the reference used in the task definition. ECS accepts a bare
+// name only for parameters in the task's region, so the ARN is preferred.
+func (ps *parameters) arn(key string) string {
+	if ps.arnBase == "" {
+		return ps.name(key)
+	}
+	return ps.arnBase + ps.name(key)
+}
+
+// owns reports whether a secret reference points at the managed parameter
+// for key
+func (ps *parameters) owns(key, ref string) bool {
+	return ref == ps.name(key) || ref == ps.arn(key)
+}
+
+// isParameterRef reports whether a secret's valueFrom is an SSM parameter
+// rather than a Secrets Manager secret
+func isParameterRef(ref string) bool {
+	if strings.HasPrefix(ref, "arn:") {
+		return strings.Contains(ref, ":ssm:")
+	}
+	return ref != ""
+}
@@ -0,0 +1,308 @@
+package awsecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+const testARNBase = "arn:aws:ssm:us-east-1:123456789012:parameter"
+
+// mockECSAPI implements ecsAPI for one task definition family
+type mockECSAPI struct {
+	revisions    []ecstypes.TaskDefinition
+	tags         []ecstypes.Tag
+	registerTags []ecstypes.Tag
+	raceReads    int // describes that trigger a concurrent registration
+}
+
+func newMockECSAPI() *mockECSAPI {
+	m := &mockECSAPI{tags: []ecstypes.Tag{{Key: aws.String("team"), Value: aws.String("shop")}}}
+	m.add(ecstypes.TaskDefinition{
+		Family:           aws.String("web"),
+		Cpu:              aws.String("256"),
+		ExecutionRoleArn: aws.String("arn:aws:iam::123456789012:role/exec"),
+		ContainerDefinitions: []ecstypes.ContainerDefinition{{
+			Name:        aws.String("app"),
+			Image:       aws.String("web:1"),
+			Environment: []ecstypes.KeyValuePair{{Name: aws.String("LOG_LEVEL"), Value: aws.String("info")}},
+			Secrets: []ecstypes.Secret{
+				{Name: aws.String("DB_URL"), ValueFrom: aws.String(testARNBase + "/web/prod/DB_URL")},
+				{Name: aws.String("STRIPE_KEY"), ValueFrom: aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:stripe")},
+			},
+		}},
+	})
+	return m
+}
+
+func (m *mockECSAPI) add(def ecstypes.TaskDefinition) {
+	def.Revision = int32(len(m.revisions) + 1)
+	def.TaskDefinitionArn = aws.String(fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/%s:%d", aws.ToString(def.Family), def.Revision))
+	m.revisions = append(m.revisions, def)
+}
+
+func (m *mockECSAPI) latest() ecstypes.ContainerDefinition {
+	return m.revisions[len(m.revisions)-1].ContainerDefinitions[0]
+}
+
+func (m *mockECSAPI) DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error) {
+	if aws.ToString(params.TaskDefinition) != "web" {
+		return nil, fmt.Errorf("unable to describe task definition")
+	}
+	// Hand out a deep enough copy that edits don't leak into the stored revision
+	def := m.revisions[len(m.revisions)-1]
+	def.ContainerDefinitions = append([]ecstypes.ContainerDefinition(nil), def.ContainerDefinitions...)
+	if m.raceReads > 0 {
+		m.raceReads--
+		m.add(m.revisions[len(m.revisions)-1])
+	}
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: &def, Tags: m.tags}, nil
+}
+
+func (m *mockECSAPI) RegisterTaskDefinition(ctx context.Context, params *ecs.RegisterTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.RegisterTaskDefinitionOutput, error) {
+	m.registerTags = params.Tags
+	m.add(ecstypes.TaskDefinition{
+		Family:               params.Family,
+		Cpu:                  params.Cpu,
+		ExecutionRoleArn:     params.ExecutionRoleArn,
+		ContainerDefinitions: params.ContainerDefinitions,
+	})
+	return &ecs.RegisterTaskDefinitionOutput{TaskDefinition: &m.revisions[len(m.revisions)-1]}, nil
+}
+
+// mockSSMAPI implements ssmAPI
+type mockSSMAPI struct {
+	params map[string]string
+	puts   int
+}
+
+func (m *mockSSMAPI) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	if len(params.Names) > ssmBatchSize {
+		return nil, fmt.Errorf("too many names")
+	}
+	out := &ssm.GetParametersOutput{}
+	for _, ref := range params.Names {
+		name := strings.TrimPrefix(ref, testARNBase)
+		if v, ok := m.params[name]; ok {
+			out.Parameters = append(out.Parameters, ssmtypes.Parameter{Name: aws.String(name), ARN: aws.String(testARNBase + name), Value: aws.String(v)})
+		} else {
+			out.InvalidParameters = append(out.InvalidParameters, ref)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockSSMAPI) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	if params.Type != ssmtypes.ParameterTypeSecureString {
+		return nil, fmt.Errorf("expected SecureString")
+	}
+	m.params[aws.ToString(params.Name)] = aws.ToString(params.Value)
+	m.puts++
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (m *mockSSMAPI) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	name := aws.ToString(params.Name)
+	if _, ok := m.params[name]; !ok {
+		return nil, &ssmtypes.ParameterNotFound{}
+	}
+	delete(m.params, name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+func newTestProvider(config map[string]any) (*Provider, *mockECSAPI, *mockSSMAPI) {
+	ecsMock := newMockECSAPI()
+	ssmMock := &mockSSMAPI{params: map[string]string{"/web/prod/DB_URL": "postgres://"}}
+	config["task_definition"] = "web"
+	return &Provider{client: newClient(ecsMock, ssmMock), config: config}, ecsMock, ssmMock
+}
+
+func entries(c ecstypes.ContainerDefinition) string {
+	var parts []string
+	for _, kv := range c.Environment {
+		parts = append(parts, aws.ToString(kv.Name)+"="+aws.ToString(kv.Value))
+	}
+	for _, s := range c.Secrets {
+		parts = append(parts, aws.ToString(s.Name)+"<-"+strings.TrimPrefix(aws.ToString(s.ValueFrom), testARNBase))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"task_definition": "web"}); err == nil {
+		t.Error("expected error for missing region")
+	}
+	if _, err := New(map[string]any{"region": "us-east-1"}); err == nil {
+		t.Error("expected error for missing task_definition")
+	}
+}
+
+func TestProvider_ListResolvesParameters(t *testing.T) {
+	p, _, _ := newTestProvider(map[string]any{})
+
+	secrets, err := p.List(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+		if s.Unreadable != (s.Name == "STRIPE_KEY") {
+			t.Errorf("%s unreadable = %v", s.Name, s.Unreadable)
+		}
+	}
+	want := map[string]string{"LOG_LEVEL": "info", "DB_URL": "postgres://", "STRIPE_KEY": ""}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestProvider_KeepsSecretReferences(t *testing.T) {
+	notSensitive := false
+	p, ecsMock, ssmMock := newTestProvider(map[string]any{
+		"prefix":  "/web/prod",
+		"_schema": map[string]model.Secret{"DB_URL": {Sensitive: &notSensitive}, "STRIPE_KEY": {Sensitive: &notSensitive}},
+	})
+	ctx := context.Background()
+
+	// A managed parameter stays a parameter even for a non-sensitive key
+	if err := p.Set(ctx, "DB_URL", "postgres://new", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if len(ecsMock.revisions) != 1 || ssmMock.params["/web/prod/DB_URL"] != "postgres://new" {
+		t.Errorf("revisions = %d, params = %v", len(ecsMock.revisions), ssmMock.params)
+	}
+
+	// A Secrets Manager reference is never turned into plain text
+	err := p.Set(ctx, "STRIPE_KEY", "sk_live", "default")
+	if err == nil || !strings.Contains(err.Error(), "doesn't manage") {
+		t.Errorf("Set() error = %v", err)
+	}
+	if len(ecsMock.revisions) != 1 {
+		t.Errorf("revisions = %d, want 1", len(ecsMock.revisions))
+	}
+}
+
+func TestProvider_SetManyWithPrefix(t *testing.T) {
+	notSensitive := false
+	p, ecsMock, ssmMock := newTestProvider(map[string]any{
+		"prefix":  "web/prod",
+		"_schema": map[string]model.Secret{"LOG_LEVEL": {Sensitive: &notSensitive}, "PORT": {Sensitive: &notSensitive}},
+	})
+
+	values := map[string]string{"LOG_LEVEL": "debug", "PORT": "8080", "STRIPE_KEY": "sk_live", "API_TOKEN": "t0k"}
+	if err := p.SetMany(context.Background(), values, "default"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if len(ecsMock.revisions) != 2 {
+		t.Fatalf("revisions = %d, want 2", len(ecsMock.revisions))
+	}
+
+	want := "LOG_LEVEL=debug PORT=8080 DB_URL<-/web/prod/DB_URL STRIPE_KEY<-/web/prod/STRIPE_KEY API_TOKEN<-/web/prod/API_TOKEN"
+	if got := entries(ecsMock.latest()); got != want {
+		t.Errorf("container = %s\nwant        %s", got, want)
+	}
+	if ssmMock.params["/web/prod/STRIPE_KEY"] != "sk_live" || ssmMock.params["/web/prod/API_TOKEN"] != "t0k" {
+		t.Errorf("params = %v", ssmMock.params)
+	}
+	if aws.ToString(ecsMock.latest().Image) != "web:1" || aws.ToString(ecsMock.revisions[1].Cpu) != "256" || len(ecsMock.registerTags) != 1 {
+		t.Error("task definition fields or tags were not carried over")
+	}
+}
+
+func TestProvider_ParameterUpdateSkipsRevision(t *testing.T) {
+	p, ecsMock, ssmMock := newTestProvider(map[string]any{"prefix": "/web/prod/"})
+
+	if err := p.Set(context.Background(), "DB_URL", "postgres://new", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if len(ecsMock.revisions) != 1 {
+		t.Errorf("revisions = %d, want 1", len(ecsMock.revisions))
+	}
+	if ssmMock.params["/web/prod/DB_URL"] != "postgres://new" {
+		t.Errorf("params = %v", ssmMock.params)
+	}
+}
+
+func TestProvider_WithoutPrefixUsesEnvironment(t *testing.T) {
+	p, ecsMock, ssmMock := newTestProvider(map[string]any{})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_TOKEN", "t0k", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "STRIPE_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	want := "LOG_LEVEL=info API_TOKEN=t0k DB_URL<-/web/prod/DB_URL"
+	if got := entries(ecsMock.latest()); got != want {
+		t.Errorf("container = %s, want %s", got, want)
+	}
+	if ssmMock.puts != 0 {
+		t.Errorf("puts = %d, want 0", ssmMock.puts)
+	}
+}
+
+func TestProvider_DeleteRemovesManagedParameter(t *testing.T) {
+	p, ecsMock, ssmMock := newTestProvider(map[string]any{"prefix": "/web/prod"})
+
+	if err := p.Delete(context.Background(), "DB_URL", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := ssmMock.params["/web/prod/DB_URL"]; ok {
+		t.Error("managed parameter should be deleted")
+	}
+	if got := entries(ecsMock.latest()); strings.Contains(got, "DB_URL") {
+		t.Errorf("container = %s", got)
+	}
+}
+
+func TestProvider_Container(t *testing.T) {
+	p, ecsMock, _ := newTestProvider(map[string]any{"container": "sidecar"})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err == nil || !strings.Contains(err.Error(), `no container named "sidecar"`) {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	def := &ecsMock.revisions[0]
+	def.ContainerDefinitions = append(def.ContainerDefinitions, ecstypes.ContainerDefinition{Name: aws.String("sidecar")})
+	if err := p.Set(ctx, "A", "1", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := entries(ecsMock.revisions[1].ContainerDefinitions[1]); got != "A=1" {
+		t.Errorf("sidecar = %s", got)
+	}
+
+	delete(p.config, "container")
+	if err := p.Validate(ctx); err == nil || !strings.Contains(err.Error(), "has 2 containers") {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestProvider_ConflictRetries(t *testing.T) {
+	p, ecsMock, _ := newTestProvider(map[string]any{})
+
+	ecsMock.raceReads = 1
+	if err := p.Set(context.Background(), "A", "1", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !strings.Contains(entries(ecsMock.latest()), "A=1") {
+		t.Errorf("container = %s", entries(ecsMock.latest()))
+	}
+
+	ecsMock.raceReads = 100
+	err := p.Set(context.Background(), "A", "2", "default")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package awsecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// errConflict is returned when a newer revision was registered between read
+// and write
+var errConflict = errors.New("aws-ecs: task definition was modified concurrently")
+
+// ssmBatchSize is the most parameters GetParameters accepts per call
+const ssmBatchSize = 10
+
+// ecsAPI defines the ECS operations used by the client
+type ecsAPI interface {
+	DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error)
+	RegisterTaskDefinition(ctx context.Context, params *ecs.RegisterTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.RegisterTaskDefinitionOutput, error)
+}
+
+// ssmAPI defines the SSM operations used for secret-backed values
+type ssmAPI interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
+}
+
+// client wraps the ECS and SSM APIs
+type client struct {
+	ecs ecsAPI
+	ssm ssmAPI
+}
+
+// newClient creates a new ECS client wrapper
+func newClient(ecsAPI ecsAPI, ssmAPI ssmAPI) *client {
+	return &client{ecs: ecsAPI, ssm: ssmAPI}
+}
+
+// Describe returns the latest active revision of a task definition family
+// and its tags
+func (c *client) Describe(ctx context.Context, family string) (*ecstypes.TaskDefinition, []ecstypes.Tag, error) {
+	out, err := c.ecs.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(family),
+		Include:        []ecstypes.TaskDefinitionField{ecstypes.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("aws-ecs: failed to describe task definition %s: %w", family, err)
+	}
+	return out.TaskDefinition, out.Tags, nil
+}
+
+// Register registers a copy of def as a new revision of its family. It
+// returns errConflict if def is no longer the latest revision.
+func (c *client) Register(ctx context.Context, def *ecstypes.TaskDefinition, tags []ecstypes.Tag) (int32, error) {
+	family := aws.ToString(def.Family)
+	latest, _, err := c.Describe(ctx, family)
+	if err != nil {
+		return 0, err
+	}
+	if latest.Revision != def.Revision {
+		return 0, errConflict
+	}
+
+	out, err := c.ecs.RegisterTaskDefinition(ctx, &ecs.RegisterTaskDefinitionInput{
+		Family:                  def.Family,
+		ContainerDefinitions:    def.ContainerDefinitions,
+		Cpu:                     def.Cpu,
+		Memory:                  def.Memory,
+		NetworkMode:             def.NetworkMode,
+		RequiresCompatibilities: def.RequiresCompatibilities,
+		TaskRoleArn:             def.TaskRoleArn,
+		ExecutionRoleArn:        def.ExecutionRoleArn,
+		Volumes:                 def.Volumes,
+		PlacementConstraints:    def.PlacementConstraints,
+		ProxyConfiguration:      def.ProxyConfiguration,
+		InferenceAccelerators:   def.InferenceAccelerators,
+		PidMode:                 def.PidMode,
+		IpcMode:                 def.IpcMode,
+		EphemeralStorage:        def.EphemeralStorage,
+		RuntimePlatform:         def.RuntimePlatform,
+		EnableFaultInjection:    def.EnableFaultInjection,
+		Tags:                    tags,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("aws-ecs: failed to register task definition %s: %w", family, err)
+	}
+	return out.TaskDefinition.Revision, nil
+}
+
+// GetParameters returns the decrypted values of SSM parameters, keyed by the
+// name or ARN they were requested with. Missing parameters are left out.
+func (c *client) GetParameters(ctx context.Context, refs []string) (map[string]string, error) {
+	result := make(map[string]string, len(refs))
+	for start := 0; start < len(refs); start += ssmBatchSize {
+		batch := refs[start:min(start+ssmBatchSize, len(refs))]
+		out, err := c.ssm.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("aws-ecs: failed to get parameters: %w", err)
+		}
+
+		for _, p := range out.Parameters {
+			value := aws.ToString(p.Value)
+			result[aws.ToString(p.Name)] = value
+			result[aws.ToString(p.ARN)] = value
+		}
+	}
+	return result, nil
+}
+
+// PutParameter creates or overwrites a SecureString parameter
+func (c *client) PutParameter(ctx context.Context, name, value string) error {
+	_, err := c.ssm.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      ssmtypes.ParameterTypeSecureString,
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("aws-ecs: failed to put parameter %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteParameter deletes a parameter. Missing parameters are ignored.
+func (c *client) DeleteParameter(ctx context.Context, name string) error {
+	_, err := c.ssm.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(name),
+	})
+	var notFound *ssmtypes.ParameterNotFound
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("aws-ecs: failed to delete parameter %s: %w", name, err)
+	}
+	return nil
+}
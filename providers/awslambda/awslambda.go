@@ -0,0 +1,232 @@
+package awslambda
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+	"github.com/dotenvy-dev/dotenvy/providers/awsssm"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "aws-lambda",
+		DisplayName: "AWS Lambda",
+		Factory:     New,
+		SdkAuth:     true,
+		Beta:        true,
+	})
+}
+
+// Provider implements the AWS Lambda provider. Each remote environment is a
+// function qualifier: $LATEST, an alias, or (read-only) a published version.
+//
+// Lambda can only publish a version from $LATEST, so writing to an alias
+// briefly gives $LATEST the alias's variables and then restores its own.
+// Every step is conditional on the revision read before it, but if the
+// process dies between the two writes, $LATEST keeps the alias's variables
+// until the next write to it.
+type Provider struct {
+	client *client
+	config map[string]any
+}
+
+// New creates a new AWS Lambda provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	region, _ := config["region"].(string)
+	if region == "" {
+		return nil, fmt.Errorf("aws-lambda: region is required")
+	}
+
+	p := &Provider{config: config}
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "function") == "" {
+			return nil, fmt.Errorf("aws-lambda: function is required for environment %s", env)
+		}
+	}
+
+	profile, _ := config["profile"].(string)
+
+	cfg, err := awsssm.LoadAWSConfig(context.Background(), region, profile)
+	if err != nil {
+		return nil, fmt.Errorf("aws-lambda: failed to load AWS config: %w", err)
+	}
+
+	p.client = newClient(lambda.NewFromConfig(cfg))
+	return p, nil
+}
+
+func (p *Provider) Name() string        { return "aws-lambda" }
+func (p *Provider) DisplayName() string { return "AWS Lambda" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{latestQualifier}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		latestQualifier: "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	for _, env := range p.Environments() {
+		function, qualifier := p.target(env)
+		if _, err := p.client.GetConfig(ctx, function, qualifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// target returns the function and qualifier of an environment. The
+// qualifier defaults to the environment name, so environments can be named
+// after aliases.
+func (p *Provider) target(environment string) (string, string) {
+	qualifier := provider.EnvSetting(p.config, environment, "qualifier")
+	if qualifier == "" {
+		qualifier = environment
+	}
+	return provider.EnvSetting(p.config, environment, "function"), qualifier
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	function, qualifier := p.target(environment)
+	cfg, err := p.client.GetConfig(ctx, function, qualifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for name, value := range cfg.Variables {
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one configuration update, publishing at most
+// one version
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// update applies changes to an environment's variables. A nil value deletes
+// the variable. Nothing is written if the variables would be unchanged.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	function, qualifier := p.target(environment)
+	if _, err := strconv.Atoi(qualifier); err == nil {
+		return fmt.Errorf("aws-lambda: version %s of %s is immutable, target an alias or $LATEST instead", qualifier, function)
+	}
+
+	return provider.RetryOnConflict(errConflict, "aws-lambda: function "+function, func() error {
+		if qualifier == latestQualifier {
+			return p.updateLatest(ctx, function, changes)
+		}
+		return p.updateAlias(ctx, function, qualifier, changes)
+	})
+}
+
+func (p *Provider) updateLatest(ctx context.Context, function string, changes map[string]*string) error {
+	latest, err := p.client.GetConfig(ctx, function, latestQualifier)
+	if err != nil {
+		return err
+	}
+	vars, changed := applyChanges(latest.Variables, changes)
+	if !changed {
+		return nil
+	}
+	_, err = p.client.UpdateVariables(ctx, function, vars, latest.RevisionID)
+	return err
+}
+
+// updateAlias publishes a version with the alias's current variables plus
+// changes, and moves the alias to it. Versions are published from $LATEST,
+// so its code must match the code behind the alias, and its own variables
+// are put back afterwards.
+func (p *Provider) updateAlias(ctx context.Context, function, alias string, changes map[string]*string) error {
+	version, aliasRevision, err := p.client.GetAlias(ctx, function, alias)
+	if err != nil {
+		return err
+	}
+	current, err := p.client.GetConfig(ctx, function, version)
+	if err != nil {
+		return err
+	}
+	vars, changed := applyChanges(current.Variables, changes)
+	if !changed {
+		return nil
+	}
+
+	latest, err := p.client.GetConfig(ctx, function, latestQualifier)
+	if err != nil {
+		return err
+	}
+	if latest.CodeSha256 != current.CodeSha256 {
+		return fmt.Errorf("aws-lambda: $LATEST code of %s differs from version %s behind alias %s; publishing would deploy it, so deploy the code first", function, version, alias)
+	}
+
+	updated, err := p.client.UpdateVariables(ctx, function, vars, latest.RevisionID)
+	if err != nil {
+		return err
+	}
+	published, err := p.client.Publish(ctx, function, updated.CodeSha256, updated.RevisionID)
+	if err != nil {
+		return p.restoreLatest(ctx, function, latest.Variables, err)
+	}
+	if err := p.client.PointAlias(ctx, function, alias, published, aliasRevision); err != nil {
+		return p.restoreLatest(ctx, function, latest.Variables, err)
+	}
+	return p.restoreLatest(ctx, function, latest.Variables, nil)
+}
+
+// restoreLatest puts back the variables $LATEST had before a version was
+// published from it, then returns cause. A failed restore is reported
+// instead, without errConflict, so the publish isn't retried on top of it.
+func (p *Provider) restoreLatest(ctx context.Context, function string, vars map[string]string, cause error) error {
+	latest, err := p.client.GetConfig(ctx, function, latestQualifier)
+	if err == nil && !maps.Equal(latest.Variables, vars) {
+		_, err = p.client.UpdateVariables(ctx, function, vars, latest.RevisionID)
+	}
+	if err != nil {
+		return fmt.Errorf("aws-lambda: $LATEST of %s still has the variables published for the alias; failed to restore its own: %v", function, err)
+	}
+	return cause
+}
+
+// applyChanges returns vars with changes applied, and whether they differ
+func applyChanges(vars map[string]string, changes map[string]*string) (map[string]string, bool) {
+	out := maps.Clone(vars)
+	for name, value := range changes {
+		if value == nil {
+			delete(out, name)
+		} else {
+			out[name] = *value
+		}
+	}
+	return out, !maps.Equal(vars, out)
+}
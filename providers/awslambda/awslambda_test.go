@@ -0,0 +1,262 @@
+package awslambda
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func init() {
+	pollInterval = 0
+}
+
+type mockVersion struct {
+	code string
+	vars map[string]string
+}
+
+type mockAlias struct {
+	version  string
+	revision int
+}
+
+// mockLambdaAPI implements lambdaAPI for one function
+type mockLambdaAPI struct {
+	latest     mockVersion
+	revision   int
+	pending    int // polls left before an update finishes
+	versions   map[string]mockVersion
+	aliases    map[string]*mockAlias
+	updates    int
+	publishes  int
+	raceUpdate int // updates to reject as concurrent
+}
+
+func newMockLambdaAPI() *mockLambdaAPI {
+	return &mockLambdaAPI{
+		latest:   mockVersion{code: "sha-1", vars: map[string]string{"LOG_LEVEL": "info"}},
+		versions: map[string]mockVersion{"1": {code: "sha-1", vars: map[string]string{"LOG_LEVEL": "warn", "API_KEY": "sk_live"}}},
+		aliases:  map[string]*mockAlias{"production": {version: "1"}},
+	}
+}
+
+func (m *mockLambdaAPI) GetFunctionConfiguration(ctx context.Context, params *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	qualifier := aws.ToString(params.Qualifier)
+	if a, ok := m.aliases[qualifier]; ok {
+		qualifier = a.version
+	}
+
+	out := &lambda.GetFunctionConfigurationOutput{Version: aws.String(qualifier)}
+	var v mockVersion
+	switch {
+	case qualifier == latestQualifier:
+		v = m.latest
+		out.RevisionId = aws.String(fmt.Sprint(m.revision))
+		out.LastUpdateStatus = types.LastUpdateStatusSuccessful
+		if m.pending > 0 {
+			m.pending--
+			out.LastUpdateStatus = types.LastUpdateStatusInProgress
+		}
+	case m.versions[qualifier].code != "":
+		v = m.versions[qualifier]
+	default:
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Function not found: " + qualifier)}
+	}
+	out.CodeSha256 = aws.String(v.code)
+	out.Environment = &types.EnvironmentResponse{Variables: maps.Clone(v.vars)}
+	return out, nil
+}
+
+func (m *mockLambdaAPI) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	if m.raceUpdate > 0 {
+		m.raceUpdate--
+		m.revision++
+	}
+	if aws.ToString(params.RevisionId) != fmt.Sprint(m.revision) {
+		return nil, &types.PreconditionFailedException{Message: aws.String("revision mismatch")}
+	}
+	m.latest.vars = maps.Clone(params.Environment.Variables)
+	m.revision++
+	m.pending = 1
+	m.updates++
+	return &lambda.UpdateFunctionConfigurationOutput{}, nil
+}
+
+func (m *mockLambdaAPI) PublishVersion(ctx context.Context, params *lambda.PublishVersionInput, optFns ...func(*lambda.Options)) (*lambda.PublishVersionOutput, error) {
+	if aws.ToString(params.RevisionId) != fmt.Sprint(m.revision) || aws.ToString(params.CodeSha256) != m.latest.code {
+		return nil, &types.PreconditionFailedException{Message: aws.String("revision mismatch")}
+	}
+	version := fmt.Sprint(len(m.versions) + 1)
+	m.versions[version] = mockVersion{code: m.latest.code, vars: maps.Clone(m.latest.vars)}
+	m.publishes++
+	return &lambda.PublishVersionOutput{Version: aws.String(version)}, nil
+}
+
+func (m *mockLambdaAPI) GetAlias(ctx context.Context, params *lambda.GetAliasInput, optFns ...func(*lambda.Options)) (*lambda.GetAliasOutput, error) {
+	a, ok := m.aliases[aws.ToString(params.Name)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Alias not found")}
+	}
+	return &lambda.GetAliasOutput{FunctionVersion: aws.String(a.version), RevisionId: aws.String(fmt.Sprint(a.revision))}, nil
+}
+
+func (m *mockLambdaAPI) UpdateAlias(ctx context.Context, params *lambda.UpdateAliasInput, optFns ...func(*lambda.Options)) (*lambda.UpdateAliasOutput, error) {
+	a := m.aliases[aws.ToString(params.Name)]
+	if aws.ToString(params.RevisionId) != fmt.Sprint(a.revision) {
+		return nil, &types.PreconditionFailedException{Message: aws.String("revision mismatch")}
+	}
+	a.version = aws.ToString(params.FunctionVersion)
+	a.revision++
+	return &lambda.UpdateAliasOutput{}, nil
+}
+
+func newTestProvider(config map[string]any) (*Provider, *mockLambdaAPI) {
+	mock := newMockLambdaAPI()
+	if config["function"] == nil {
+		config["function"] = "checkout"
+	}
+	return &Provider{client: newClient(mock), config: config}, mock
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"function": "checkout"}); err == nil {
+		t.Error("expected error for missing region")
+	}
+	if _, err := New(map[string]any{"region": "us-east-1"}); err == nil {
+		t.Error("expected error for missing function")
+	}
+}
+
+func TestProvider_ListAlias(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{
+		"environments": map[string]map[string]string{"production": {}},
+	})
+
+	secrets, err := p.List(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	if !maps.Equal(got, mock.versions["1"].vars) {
+		t.Errorf("List() = %v", got)
+	}
+}
+
+func TestProvider_SetManyLatest(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{})
+	ctx := context.Background()
+
+	if got := p.Environments(); len(got) != 1 || got[0] != "$LATEST" {
+		t.Errorf("Environments() = %v", got)
+	}
+	if err := p.SetMany(ctx, map[string]string{"A": "1", "B": "2"}, "$LATEST"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if err := p.Set(ctx, "A", "1", "$LATEST"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "LOG_LEVEL", "$LATEST"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	want := map[string]string{"A": "1", "B": "2"}
+	if mock.updates != 2 || !maps.Equal(mock.latest.vars, want) {
+		t.Errorf("updates = %d, vars = %v", mock.updates, mock.latest.vars)
+	}
+	if mock.publishes != 0 {
+		t.Errorf("publishes = %d, want 0", mock.publishes)
+	}
+}
+
+func TestProvider_SetAliasPublishesVersion(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{
+		"environments": map[string]map[string]string{"prod": {"qualifier": "production"}},
+	})
+
+	if err := p.Set(context.Background(), "API_KEY", "sk_new", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	alias := mock.aliases["production"]
+	if mock.publishes != 1 || alias.version != "2" {
+		t.Fatalf("publishes = %d, alias -> %s", mock.publishes, alias.version)
+	}
+	want := map[string]string{"LOG_LEVEL": "warn", "API_KEY": "sk_new"}
+	if !maps.Equal(mock.versions["2"].vars, want) {
+		t.Errorf("published vars = %v, want %v", mock.versions["2"].vars, want)
+	}
+}
+
+func TestProvider_SetAliasKeepsLatestVars(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{
+		"environments": map[string]map[string]string{"production": {}},
+	})
+	mock.latest.vars = map[string]string{"LOG_LEVEL": "debug", "FEATURE_X": "on"}
+
+	if err := p.Set(context.Background(), "API_KEY", "sk_new", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	// The version gets the alias's vars, and $LATEST keeps its own
+	want := map[string]string{"LOG_LEVEL": "warn", "API_KEY": "sk_new"}
+	if !maps.Equal(mock.versions["2"].vars, want) {
+		t.Errorf("published vars = %v, want %v", mock.versions["2"].vars, want)
+	}
+	wantLatest := map[string]string{"LOG_LEVEL": "debug", "FEATURE_X": "on"}
+	if !maps.Equal(mock.latest.vars, wantLatest) {
+		t.Errorf("$LATEST vars = %v, want %v", mock.latest.vars, wantLatest)
+	}
+}
+
+func TestProvider_SetAliasRefusesDifferentCode(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{
+		"environments": map[string]map[string]string{"production": {}},
+	})
+	mock.latest.code = "sha-2"
+
+	err := p.Set(context.Background(), "API_KEY", "sk_new", "production")
+	if err == nil || !strings.Contains(err.Error(), "deploy the code first") {
+		t.Errorf("Set() error = %v", err)
+	}
+	if mock.updates != 0 {
+		t.Errorf("updates = %d, want 0", mock.updates)
+	}
+}
+
+func TestProvider_VersionsAreReadOnly(t *testing.T) {
+	p, _ := newTestProvider(map[string]any{
+		"environments": map[string]map[string]string{"v1": {"qualifier": "1"}},
+	})
+
+	if _, err := p.List(context.Background(), "v1"); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	err := p.Set(context.Background(), "A", "1", "v1")
+	if err == nil || !strings.Contains(err.Error(), "immutable") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
+
+func TestProvider_ConflictRetries(t *testing.T) {
+	p, mock := newTestProvider(map[string]any{})
+
+	mock.raceUpdate = 2
+	if err := p.Set(context.Background(), "A", "1", "$LATEST"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if mock.latest.vars["A"] != "1" {
+		t.Errorf("vars = %v", mock.latest.vars)
+	}
+
+	mock.raceUpdate = 100
+	err := p.Set(context.Background(), "A", "2", "$LATEST")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
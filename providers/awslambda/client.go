@@ -0,0 +1,178 @@
+package awslambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// latestQualifier is the unpublished version of a function, the only one
+// whose configuration can change
+const latestQualifier = "$LATEST"
+
+// errConflict is returned when a function or alias changed between read and
+// write, or another update is still in progress
+var errConflict = errors.New("aws-lambda: function was modified concurrently")
+
+// pollInterval is how often a configuration update is checked for completion
+var pollInterval = 2 * time.Second
+
+// updateTimeout bounds the wait for a configuration update to finish
+const updateTimeout = 5 * time.Minute
+
+// lambdaAPI defines the Lambda operations used by the client
+type lambdaAPI interface {
+	GetFunctionConfiguration(ctx context.Context, params *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error)
+	UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
+	PublishVersion(ctx context.Context, params *lambda.PublishVersionInput, optFns ...func(*lambda.Options)) (*lambda.PublishVersionOutput, error)
+	GetAlias(ctx context.Context, params *lambda.GetAliasInput, optFns ...func(*lambda.Options)) (*lambda.GetAliasOutput, error)
+	UpdateAlias(ctx context.Context, params *lambda.UpdateAliasInput, optFns ...func(*lambda.Options)) (*lambda.UpdateAliasOutput, error)
+}
+
+// client wraps the Lambda API for environment variable operations
+type client struct {
+	api lambdaAPI
+}
+
+// newClient creates a new Lambda client wrapper
+func newClient(api lambdaAPI) *client {
+	return &client{api: api}
+}
+
+// functionConfig is the part of a function version's configuration dotenvy
+// reads
+type functionConfig struct {
+	Version    string
+	RevisionID string
+	CodeSha256 string
+	Status     types.LastUpdateStatus
+	Reason     string
+	Variables  map[string]string
+}
+
+// GetConfig returns the configuration of a function version or alias
+func (c *client) GetConfig(ctx context.Context, function, qualifier string) (*functionConfig, error) {
+	out, err := c.api.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(function),
+		Qualifier:    aws.String(qualifier),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aws-lambda: failed to get configuration of %s:%s: %w", function, qualifier, err)
+	}
+
+	cfg := &functionConfig{
+		Version:    aws.ToString(out.Version),
+		RevisionID: aws.ToString(out.RevisionId),
+		CodeSha256: aws.ToString(out.CodeSha256),
+		Status:     out.LastUpdateStatus,
+		Reason:     aws.ToString(out.LastUpdateStatusReason),
+		Variables:  map[string]string{},
+	}
+	if out.Environment != nil {
+		if out.Environment.Error != nil {
+			return nil, fmt.Errorf("aws-lambda: failed to read environment of %s:%s: %s", function, qualifier, aws.ToString(out.Environment.Error.Message))
+		}
+		for k, v := range out.Environment.Variables {
+			cfg.Variables[k] = v
+		}
+	}
+	return cfg, nil
+}
+
+// UpdateVariables replaces the environment of $LATEST and waits for the
+// update to finish. The write fails with errConflict unless $LATEST is
+// still at revisionID.
+func (c *client) UpdateVariables(ctx context.Context, function string, vars map[string]string, revisionID string) (*functionConfig, error) {
+	_, err := c.api.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(function),
+		Environment:  &types.Environment{Variables: vars},
+		RevisionId:   aws.String(revisionID),
+	})
+	if err != nil {
+		return nil, wrapError("update configuration of "+function, err)
+	}
+	return c.waitForUpdate(ctx, function)
+}
+
+// waitForUpdate polls $LATEST until its last update has finished
+func (c *client) waitForUpdate(ctx context.Context, function string) (*functionConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	for {
+		cfg, err := c.GetConfig(ctx, function, latestQualifier)
+		if err != nil {
+			return nil, err
+		}
+		switch cfg.Status {
+		case types.LastUpdateStatusInProgress:
+		case types.LastUpdateStatusFailed:
+			return nil, fmt.Errorf("aws-lambda: configuration update of %s failed: %s", function, cfg.Reason)
+		default:
+			return cfg, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("aws-lambda: timed out waiting for %s to finish updating: %w", function, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Publish creates a version from $LATEST, which must still have the given
+// code and revision
+func (c *client) Publish(ctx context.Context, function, codeSha256, revisionID string) (string, error) {
+	out, err := c.api.PublishVersion(ctx, &lambda.PublishVersionInput{
+		FunctionName: aws.String(function),
+		CodeSha256:   aws.String(codeSha256),
+		RevisionId:   aws.String(revisionID),
+		Description:  aws.String("Environment updated by dotenvy"),
+	})
+	if err != nil {
+		return "", wrapError("publish version of "+function, err)
+	}
+	return aws.ToString(out.Version), nil
+}
+
+// GetAlias returns the version an alias points to and the alias revision
+func (c *client) GetAlias(ctx context.Context, function, alias string) (string, string, error) {
+	out, err := c.api.GetAlias(ctx, &lambda.GetAliasInput{
+		FunctionName: aws.String(function),
+		Name:         aws.String(alias),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("aws-lambda: failed to get alias %s of %s: %w", alias, function, err)
+	}
+	return aws.ToString(out.FunctionVersion), aws.ToString(out.RevisionId), nil
+}
+
+// PointAlias moves an alias to a version. The write fails with errConflict
+// unless the alias is still at revisionID.
+func (c *client) PointAlias(ctx context.Context, function, alias, version, revisionID string) error {
+	_, err := c.api.UpdateAlias(ctx, &lambda.UpdateAliasInput{
+		FunctionName:    aws.String(function),
+		Name:            aws.String(alias),
+		FunctionVersion: aws.String(version),
+		RevisionId:      aws.String(revisionID),
+	})
+	if err != nil {
+		return wrapError("update alias "+alias+" of "+function, err)
+	}
+	return nil
+}
+
+// wrapError maps revision mismatches and concurrent updates to errConflict
+func wrapError(action string, err error) error {
+	var precondition *types.PreconditionFailedException
+	var conflict *types.ResourceConflictException
+	if errors.As(err, &precondition) || errors.As(err, &conflict) {
+		return errConflict
+	}
+	return fmt.Errorf("aws-lambda: failed to %s: %w", action, err)
+}
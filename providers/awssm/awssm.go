@@ -2,13 +2,17 @@ package awssm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"strings"
 
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+	"github.com/dotenvy-dev/dotenvy/providers/awsssm"
 )
 
 func init() {
@@ -21,11 +25,12 @@ func init() {
 	})
 }
 
-// Provider implements the AWS Secrets Manager provider (JSON blob mode)
+// Provider implements the AWS Secrets Manager provider. Each remote
+// environment is either one JSON secret named by secret_name, or one secret
+// per key under prefix. Both may contain {env}.
 type Provider struct {
-	client     *client
-	region     string
-	secretName string
+	client *client
+	config map[string]any
 }
 
 // New creates a new AWS Secrets Manager provider
@@ -35,39 +40,36 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 		return nil, fmt.Errorf("aws-secretsmanager: region is required")
 	}
 
-	secretName, _ := config["secret_name"].(string)
-	if secretName == "" {
-		return nil, fmt.Errorf("aws-secretsmanager: secret_name is required")
+	p := &Provider{config: config}
+	for _, env := range p.Environments() {
+		secretName := provider.EnvSetting(config, env, "secret_name")
+		prefix := provider.EnvSetting(config, env, "prefix")
+		if secretName == "" && prefix == "" {
+			return nil, fmt.Errorf("aws-secretsmanager: secret_name or prefix is required for environment %s", env)
+		}
+		if secretName != "" && prefix != "" {
+			return nil, fmt.Errorf("aws-secretsmanager: set either secret_name or prefix for environment %s, not both", env)
+		}
 	}
 
 	profile, _ := config["profile"].(string)
 
-	// Build AWS config options
-	opts := []func(*awsconfig.LoadOptions) error{
-		awsconfig.WithRegion(region),
-	}
-	if profile != "" {
-		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
-	}
-
-	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	cfg, err := awsssm.LoadAWSConfig(context.Background(), region, profile)
 	if err != nil {
 		return nil, fmt.Errorf("aws-secretsmanager: failed to load AWS config: %w", err)
 	}
 
-	smClient := secretsmanager.NewFromConfig(cfg)
-
-	return &Provider{
-		client:     newClient(smClient, secretName),
-		region:     region,
-		secretName: secretName,
-	}, nil
+	p.client = newClient(secretsmanager.NewFromConfig(cfg))
+	return p, nil
 }
 
 func (p *Provider) Name() string        { return "aws-secretsmanager" }
 func (p *Provider) DisplayName() string { return "AWS Secrets Manager" }
 
 func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
 	return []string{"default"}
 }
 
@@ -77,14 +79,75 @@ func (p *Provider) DefaultMapping() map[string]string {
 	}
 }
 
+// Validate checks access to every environment. A missing JSON secret is
+// created empty.
 func (p *Provider) Validate(ctx context.Context) error {
-	return p.client.Validate(ctx)
+	for _, env := range p.Environments() {
+		if prefix, ok := p.prefix(env); ok {
+			if _, err := p.client.List(ctx, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := p.secretName(env)
+		s, err := p.client.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			if err := p.client.Write(ctx, name, "{}", nil, p.options(env)); err != nil && !errors.Is(err, errConflict) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// secretName returns the JSON secret of an environment
+func (p *Provider) secretName(environment string) string {
+	return provider.ExpandEnv(provider.EnvSetting(p.config, environment, "secret_name"), environment)
+}
+
+// prefix returns the name prefix of an environment in one-secret-per-key
+// mode
+func (p *Provider) prefix(environment string) (string, bool) {
+	prefix := provider.EnvSetting(p.config, environment, "prefix")
+	if prefix == "" {
+		return "", false
+	}
+	return provider.ExpandEnv(prefix, environment), true
+}
+
+func (p *Provider) options(environment string) writeOptions {
+	return writeOptions{
+		KeyID: provider.EnvSetting(p.config, environment, "kms_key_id"),
+		Tags:  provider.MapSetting(p.config, "tags", environment),
+	}
 }
 
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	data, err := p.client.GetJSON(ctx)
-	if err != nil {
-		return nil, err
+	var data map[string]string
+	if prefix, ok := p.prefix(environment); ok {
+		secrets, err := p.client.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		data = make(map[string]string, len(secrets))
+		for name, s := range secrets {
+			// The API matches prefixes case-insensitively and includes
+			// nested paths
+			key, ok := strings.CutPrefix(name, prefix)
+			if ok && key != "" && !strings.Contains(key, "/") {
+				data[key] = s.Value
+			}
+		}
+	} else {
+		var err error
+		data, _, err = p.getJSON(ctx, p.secretName(environment))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var secrets []model.SecretValue
@@ -100,21 +163,101 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 }
 
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	data, err := p.client.GetJSON(ctx)
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values; in JSON mode as a single new version
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// update applies changes to an environment. A nil value deletes the key.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	prefix, perKey := p.prefix(environment)
+	if !perKey {
+		return p.retry(p.secretName(environment), func() error {
+			return p.updateJSON(ctx, environment, changes)
+		})
+	}
+
+	force, _ := p.config["purge_on_delete"].(bool)
+	for key, value := range changes {
+		name := prefix + key
+		err := p.retry(name, func() error {
+			if value == nil {
+				return p.client.Delete(ctx, name, force)
+			}
+			current, err := p.client.Get(ctx, name)
+			if err != nil {
+				return err
+			}
+			if current != nil && current.Value == *value {
+				return nil
+			}
+			return p.client.Write(ctx, name, *value, current, p.options(environment))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateJSON applies changes to an environment's JSON secret, writing a new
+// version only if the contents change
+func (p *Provider) updateJSON(ctx context.Context, environment string, changes map[string]*string) error {
+	name := p.secretName(environment)
+	data, current, err := p.getJSON(ctx, name)
 	if err != nil {
 		return err
 	}
 
-	data[name] = value
-	return p.client.PutJSON(ctx, data)
+	updated := maps.Clone(data)
+	for key, value := range changes {
+		if value == nil {
+			delete(updated, key)
+		} else {
+			updated[key] = *value
+		}
+	}
+	if current != nil && maps.Equal(data, updated) {
+		return nil
+	}
+
+	jsonBytes, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("aws-secretsmanager: failed to marshal JSON: %w", err)
+	}
+	return p.client.Write(ctx, name, string(jsonBytes), current, p.options(environment))
 }
 
-func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	data, err := p.client.GetJSON(ctx)
+// getJSON returns the parsed contents of a JSON secret and its current
+// version, which is nil if the secret doesn't exist
+func (p *Provider) getJSON(ctx context.Context, name string) (map[string]string, *secret, error) {
+	s, err := p.client.Get(ctx, name)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	data := make(map[string]string)
+	if s == nil || s.Value == "" {
+		return data, s, nil
 	}
+	if err := json.Unmarshal([]byte(s.Value), &data); err != nil {
+		return nil, nil, fmt.Errorf("aws-secretsmanager: failed to parse secret JSON: %w", err)
+	}
+	return data, s, nil
+}
 
-	delete(data, name)
-	return p.client.PutJSON(ctx, data)
+// retry runs a read-modify-write, starting over if the secret changed
+// concurrently
+func (p *Provider) retry(name string, write func() error) error {
+	return provider.RetryOnConflict(errConflict, "aws-secretsmanager: secret "+name, write)
 }
@@ -4,73 +4,229 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 )
 
-// mockSMAPI implements smAPI for testing
-type mockSMAPI struct {
-	secretString string
-	exists       bool
-	getErr       error
-	putErr       error
-	createErr    error
-	created      bool
-	lastPut      string
+// fakeSecret is a secret with its versions and the stage labels on them
+type fakeSecret struct {
+	versions map[string]string // version ID -> value
+	stages   map[string]string // stage label -> version ID
+	kmsKeyID string
+	tags     map[string]string
+	deleted  bool
 }
 
-func newMockSMAPI(data map[string]string) *mockSMAPI {
-	m := &mockSMAPI{exists: true}
-	if data != nil {
+// fakeSMAPI implements smAPI in memory
+type fakeSMAPI struct {
+	secrets    map[string]*fakeSecret
+	nextID     int
+	getErr     error
+	putErr     error
+	puts       int
+	forced     []string
+	raceWrites int // writes by another client to slip in before ours
+}
+
+func newFakeSMAPI() *fakeSMAPI {
+	return &fakeSMAPI{secrets: make(map[string]*fakeSecret)}
+}
+
+// seed creates a secret whose current value is data as JSON, or the string
+// itself
+func (f *fakeSMAPI) seed(name string, data any) {
+	value, ok := data.(string)
+	if !ok {
 		b, _ := json.Marshal(data)
-		m.secretString = string(b)
-	} else {
-		m.exists = false
+		value = string(b)
 	}
-	return m
+	s := &fakeSecret{versions: map[string]string{}, stages: map[string]string{}, tags: map[string]string{}}
+	f.secrets[name] = s
+	f.addVersion(s, value, stageCurrent)
 }
 
-func (m *mockSMAPI) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
-	if m.getErr != nil {
-		return nil, m.getErr
-	}
-	if !m.exists {
+func (f *fakeSMAPI) addVersion(s *fakeSecret, value string, stages ...string) string {
+	f.nextID++
+	id := fmt.Sprintf("v%d", f.nextID)
+	s.versions[id] = value
+	for _, stage := range stages {
+		if stage == stageCurrent && s.stages[stageCurrent] != "" {
+			s.stages["AWSPREVIOUS"] = s.stages[stageCurrent]
+		}
+		s.stages[stage] = id
+	}
+	return id
+}
+
+// current returns the current value of a secret as JSON
+func (f *fakeSMAPI) current(name string) map[string]string {
+	s := f.secrets[name]
+	var data map[string]string
+	json.Unmarshal([]byte(s.versions[s.stages[stageCurrent]]), &data)
+	return data
+}
+
+func (f *fakeSMAPI) find(id *string) (*fakeSecret, error) {
+	s, ok := f.secrets[aws.ToString(id)]
+	if !ok || s.deleted {
 		return nil, &smtypes.ResourceNotFoundException{Message: aws.String("not found")}
 	}
+	return s, nil
+}
+
+func (f *fakeSMAPI) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	s, err := f.find(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+	id := s.stages[aws.ToString(params.VersionStage)]
 	return &secretsmanager.GetSecretValueOutput{
-		SecretString: aws.String(m.secretString),
+		SecretString: aws.String(s.versions[id]),
+		VersionId:    aws.String(id),
 	}, nil
 }
 
-func (m *mockSMAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
-	if m.putErr != nil {
-		return nil, m.putErr
+func (f *fakeSMAPI) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	prefix := strings.ToLower(params.Filters[0].Values[0])
+	out := &secretsmanager.BatchGetSecretValueOutput{}
+	for name, s := range f.secrets {
+		if s.deleted || !strings.HasPrefix(strings.ToLower(name), prefix) {
+			continue
+		}
+		id := s.stages[stageCurrent]
+		out.SecretValues = append(out.SecretValues, smtypes.SecretValueEntry{
+			Name:         aws.String(name),
+			SecretString: aws.String(s.versions[id]),
+			VersionId:    aws.String(id),
+		})
+	}
+	return out, nil
+}
+
+func (f *fakeSMAPI) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	s, ok := f.secrets[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, &smtypes.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	out := &secretsmanager.DescribeSecretOutput{VersionIdsToStages: map[string][]string{}}
+	for stage, id := range s.stages {
+		out.VersionIdsToStages[id] = append(out.VersionIdsToStages[id], stage)
 	}
-	m.secretString = aws.ToString(params.SecretString)
-	m.lastPut = m.secretString
-	return &secretsmanager.PutSecretValueOutput{}, nil
+	if s.deleted {
+		out.DeletedDate = aws.Time(time.Now())
+	}
+	return out, nil
 }
 
-func (m *mockSMAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
-	if m.createErr != nil {
-		return nil, m.createErr
+func (f *fakeSMAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	s, err := f.find(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+	if f.raceWrites > 0 {
+		f.raceWrites--
+		f.addVersion(s, `{"OTHER":"from another run"}`, stageCurrent)
+	}
+	stages := params.VersionStages
+	if len(stages) == 0 {
+		stages = []string{stageCurrent}
+	}
+	f.puts++
+	id := f.addVersion(s, aws.ToString(params.SecretString), stages...)
+	return &secretsmanager.PutSecretValueOutput{VersionId: aws.String(id)}, nil
+}
+
+func (f *fakeSMAPI) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	s, err := f.find(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+	stage := aws.ToString(params.VersionStage)
+	if params.RemoveFromVersionId != nil && s.stages[stage] != aws.ToString(params.RemoveFromVersionId) {
+		return nil, &smtypes.InvalidParameterException{Message: aws.String("stage is not attached to that version")}
+	}
+	if params.MoveToVersionId == nil {
+		delete(s.stages, stage)
+	} else {
+		if stage == stageCurrent {
+			s.stages["AWSPREVIOUS"] = s.stages[stageCurrent]
+		}
+		s.stages[stage] = aws.ToString(params.MoveToVersionId)
+	}
+	return &secretsmanager.UpdateSecretVersionStageOutput{}, nil
+}
+
+func (f *fakeSMAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	name := aws.ToString(params.Name)
+	if s, ok := f.secrets[name]; ok {
+		if s.deleted {
+			return nil, &smtypes.InvalidRequestException{Message: aws.String("secret is scheduled for deletion")}
+		}
+		return nil, &smtypes.ResourceExistsException{Message: aws.String("secret exists")}
+	}
+	f.seed(name, aws.ToString(params.SecretString))
+	s := f.secrets[name]
+	s.kmsKeyID = aws.ToString(params.KmsKeyId)
+	for _, tag := range params.Tags {
+		s.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
 	}
-	m.exists = true
-	m.secretString = aws.ToString(params.SecretString)
-	m.created = true
 	return &secretsmanager.CreateSecretOutput{}, nil
 }
 
-func TestNew_ValidConfig(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	p := &Provider{
-		client:     newClient(mock, "myapp-dev"),
-		region:     "us-east-1",
-		secretName: "myapp-dev",
+func (f *fakeSMAPI) DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	s, err := f.find(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToBool(params.ForceDeleteWithoutRecovery) {
+		f.forced = append(f.forced, aws.ToString(params.SecretId))
+		delete(f.secrets, aws.ToString(params.SecretId))
+	} else {
+		s.deleted = true
+	}
+	return &secretsmanager.DeleteSecretOutput{}, nil
+}
+
+func (f *fakeSMAPI) RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error) {
+	f.secrets[aws.ToString(params.SecretId)].deleted = false
+	return &secretsmanager.RestoreSecretOutput{}, nil
+}
+
+func (f *fakeSMAPI) TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	s, err := f.find(params.SecretId)
+	if err != nil {
+		return nil, err
 	}
+	for _, tag := range params.Tags {
+		s.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func newTestProvider(fake *fakeSMAPI, config map[string]any) *Provider {
+	if config["secret_name"] == nil && config["prefix"] == nil {
+		config["secret_name"] = "test"
+	}
+	return &Provider{client: newClient(fake), config: config}
+}
+
+func TestNew_ValidConfig(t *testing.T) {
+	p := newTestProvider(newFakeSMAPI(), map[string]any{"secret_name": "myapp-dev"})
 
 	if p.Name() != "aws-secretsmanager" {
 		t.Errorf("Name() = %q, want 'aws-secretsmanager'", p.Name())
@@ -98,9 +254,19 @@ func TestNew_MissingSecretName(t *testing.T) {
 	}
 }
 
+func TestNew_SecretNameAndPrefix(t *testing.T) {
+	_, err := New(map[string]any{
+		"region":       "us-east-1",
+		"secret_name":  "myapp/{env}",
+		"environments": map[string]map[string]string{"prod": {"prefix": "myapp/prod/"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("New() error = %v", err)
+	}
+}
+
 func TestProvider_Environments(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(newFakeSMAPI(), map[string]any{})
 
 	envs := p.Environments()
 	if len(envs) != 1 || envs[0] != "default" {
@@ -109,8 +275,7 @@ func TestProvider_Environments(t *testing.T) {
 }
 
 func TestProvider_DefaultMapping(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(newFakeSMAPI(), map[string]any{})
 
 	mapping := p.DefaultMapping()
 	if mapping["default"] != "test" {
@@ -119,8 +284,9 @@ func TestProvider_DefaultMapping(t *testing.T) {
 }
 
 func TestProvider_Validate_ExistingSecret(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{"KEY": "val"})
-	p := &Provider{client: newClient(mock, "test")}
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{"KEY": "val"})
+	p := newTestProvider(fake, map[string]any{})
 
 	if err := p.Validate(context.Background()); err != nil {
 		t.Errorf("Validate() error = %v", err)
@@ -128,24 +294,25 @@ func TestProvider_Validate_ExistingSecret(t *testing.T) {
 }
 
 func TestProvider_Validate_CreatesSecret(t *testing.T) {
-	mock := newMockSMAPI(nil) // secret doesn't exist
-	p := &Provider{client: newClient(mock, "test")}
+	fake := newFakeSMAPI() // secret doesn't exist
+	p := newTestProvider(fake, map[string]any{})
 
 	if err := p.Validate(context.Background()); err != nil {
 		t.Errorf("Validate() error = %v", err)
 	}
 
-	if !mock.created {
+	if fake.secrets["test"] == nil {
 		t.Error("expected secret to be created")
 	}
 }
 
 func TestProvider_List(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{
 		"DB_URL":  "postgres://localhost",
 		"API_KEY": "sk_test_123",
 	})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(fake, map[string]any{})
 
 	secrets, err := p.List(context.Background(), "default")
 	if err != nil {
@@ -170,8 +337,7 @@ func TestProvider_List(t *testing.T) {
 }
 
 func TestProvider_List_EmptySecret(t *testing.T) {
-	mock := newMockSMAPI(nil) // secret doesn't exist
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(newFakeSMAPI(), map[string]any{}) // secret doesn't exist
 
 	secrets, err := p.List(context.Background(), "default")
 	if err != nil {
@@ -183,11 +349,40 @@ func TestProvider_List_EmptySecret(t *testing.T) {
 	}
 }
 
+func TestProvider_SecretNameTemplate(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("myapp/staging", map[string]string{"KEY": "staging"})
+	fake.seed("legacy-prod", map[string]string{"KEY": "prod"})
+	p := newTestProvider(fake, map[string]any{
+		"secret_name":  "myapp/{env}",
+		"environments": map[string]map[string]string{"prod": {"secret_name": "legacy-prod"}},
+	})
+	ctx := context.Background()
+
+	for env, want := range map[string]string{"staging": "staging", "prod": "prod"} {
+		secrets, err := p.List(ctx, env)
+		if err != nil {
+			t.Fatalf("List(%s) error = %v", env, err)
+		}
+		if len(secrets) != 1 || secrets[0].Value != want {
+			t.Errorf("List(%s) = %+v", env, secrets)
+		}
+	}
+
+	if err := p.Set(ctx, "KEY", "new", "prod"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if fake.current("legacy-prod")["KEY"] != "new" || fake.current("myapp/staging")["KEY"] != "staging" {
+		t.Error("Set() wrote to the wrong secret")
+	}
+}
+
 func TestProvider_Set_NewKey(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{
 		"EXISTING": "val",
 	})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(fake, map[string]any{})
 
 	err := p.Set(context.Background(), "NEW_KEY", "new_value", "default")
 	if err != nil {
@@ -195,8 +390,7 @@ func TestProvider_Set_NewKey(t *testing.T) {
 	}
 
 	// Verify the JSON was updated
-	var data map[string]string
-	json.Unmarshal([]byte(mock.lastPut), &data)
+	data := fake.current("test")
 
 	if data["EXISTING"] != "val" {
 		t.Errorf("EXISTING = %q, want 'val'", data["EXISTING"])
@@ -204,41 +398,61 @@ func TestProvider_Set_NewKey(t *testing.T) {
 	if data["NEW_KEY"] != "new_value" {
 		t.Errorf("NEW_KEY = %q, want 'new_value'", data["NEW_KEY"])
 	}
+	if _, ok := fake.secrets["test"].stages[stageWriting]; ok {
+		t.Error("writing stage should be removed after the write")
+	}
 }
 
 func TestProvider_Set_UpdateKey(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{
 		"KEY": "old_value",
 	})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(fake, map[string]any{})
 
 	err := p.Set(context.Background(), "KEY", "new_value", "default")
 	if err != nil {
 		t.Fatalf("Set() error = %v", err)
 	}
 
-	var data map[string]string
-	json.Unmarshal([]byte(mock.lastPut), &data)
+	data := fake.current("test")
 
 	if data["KEY"] != "new_value" {
 		t.Errorf("KEY = %q, want 'new_value'", data["KEY"])
 	}
 }
 
+func TestProvider_SetMany_OneVersion(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{"A": "1"})
+	p := newTestProvider(fake, map[string]any{})
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"A": "2", "B": "3"}, "default"); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	if err := p.SetMany(ctx, map[string]string{"A": "2"}, "default"); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	if fake.puts != 1 {
+		t.Errorf("puts = %d, want 1", fake.puts)
+	}
+}
+
 func TestProvider_Delete(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{
 		"KEY1": "val1",
 		"KEY2": "val2",
 	})
-	p := &Provider{client: newClient(mock, "test")}
+	p := newTestProvider(fake, map[string]any{})
 
 	err := p.Delete(context.Background(), "KEY1", "default")
 	if err != nil {
 		t.Fatalf("Delete() error = %v", err)
 	}
 
-	var data map[string]string
-	json.Unmarshal([]byte(mock.lastPut), &data)
+	data := fake.current("test")
 
 	if _, exists := data["KEY1"]; exists {
 		t.Error("KEY1 should have been deleted")
@@ -248,10 +462,119 @@ func TestProvider_Delete(t *testing.T) {
 	}
 }
 
+func TestProvider_ConcurrentWrite(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{"KEY": "val"})
+	p := newTestProvider(fake, map[string]any{})
+
+	fake.raceWrites = 1
+	if err := p.Set(context.Background(), "KEY", "new", "default"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	data := fake.current("test")
+	if data["KEY"] != "new" || data["OTHER"] != "from another run" {
+		t.Errorf("data = %v, want both writes", data)
+	}
+
+	fake.raceWrites = 100
+	err := p.Set(context.Background(), "KEY", "newer", "default")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
+
+func TestProvider_RefusesDuringRotation(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{"KEY": "val"})
+	fake.addVersion(fake.secrets["test"], `{"KEY":"rotated"}`, stagePending)
+	p := newTestProvider(fake, map[string]any{})
+
+	err := p.Set(context.Background(), "KEY", "new", "default")
+	if err == nil || !strings.Contains(err.Error(), "rotation in progress") {
+		t.Errorf("Set() error = %v", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d, want 0", fake.puts)
+	}
+}
+
+func TestProvider_PerKey(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("myapp/prod/DB_URL", "postgres://prod")
+	fake.seed("myapp/prod/nested/KEY", "skip")
+	fake.seed("MyApp/prod/OTHER", "skip")
+	fake.seed("myapp/staging/DB_URL", "postgres://staging")
+	p := newTestProvider(fake, map[string]any{
+		"prefix":     "myapp/{env}/",
+		"kms_key_id": "alias/myapp",
+		"tags":       map[string]string{"stage": "{env}"},
+	})
+	ctx := context.Background()
+
+	secrets, err := p.List(ctx, "prod")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "DB_URL" || secrets[0].Value != "postgres://prod" {
+		t.Errorf("List() = %+v", secrets)
+	}
+
+	if err := p.SetMany(ctx, map[string]string{"DB_URL": "postgres://prod", "API_KEY": "sk_live"}, "prod"); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	created := fake.secrets["myapp/prod/API_KEY"]
+	if created == nil || created.kmsKeyID != "alias/myapp" || created.tags["stage"] != "prod" {
+		t.Fatalf("API_KEY = %+v", created)
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d, want 0 for an unchanged value", fake.puts)
+	}
+
+	if err := p.Set(ctx, "DB_URL", "postgres://new", "prod"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	s := fake.secrets["myapp/prod/DB_URL"]
+	if s.versions[s.stages[stageCurrent]] != "postgres://new" || s.tags["stage"] != "prod" {
+		t.Errorf("DB_URL = %+v", s)
+	}
+
+	// Deleting keeps the recovery window; writing again restores the secret
+	if err := p.Delete(ctx, "API_KEY", "prod"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if !fake.secrets["myapp/prod/API_KEY"].deleted {
+		t.Fatal("API_KEY should be scheduled for deletion")
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_new", "prod"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	s = fake.secrets["myapp/prod/API_KEY"]
+	if s.deleted || s.versions[s.stages[stageCurrent]] != "sk_new" {
+		t.Errorf("API_KEY = %+v", s)
+	}
+}
+
+func TestProvider_PerKeyPurge(t *testing.T) {
+	fake := newFakeSMAPI()
+	fake.seed("myapp/KEY", "val")
+	p := newTestProvider(fake, map[string]any{"prefix": "myapp/", "purge_on_delete": true})
+
+	if err := p.Delete(context.Background(), "KEY", "default"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := p.Delete(context.Background(), "MISSING", "default"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	sort.Strings(fake.forced)
+	if len(fake.forced) != 1 || fake.forced[0] != "myapp/KEY" {
+		t.Errorf("forced = %v", fake.forced)
+	}
+}
+
 func TestProvider_List_Error(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	mock.getErr = fmt.Errorf("access denied")
-	p := &Provider{client: newClient(mock, "test")}
+	fake := newFakeSMAPI()
+	fake.getErr = fmt.Errorf("access denied")
+	p := newTestProvider(fake, map[string]any{})
 
 	_, err := p.List(context.Background(), "default")
 	if err == nil {
@@ -260,9 +583,9 @@ func TestProvider_List_Error(t *testing.T) {
 }
 
 func TestProvider_Set_GetError(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	mock.getErr = fmt.Errorf("access denied")
-	p := &Provider{client: newClient(mock, "test")}
+	fake := newFakeSMAPI()
+	fake.getErr = fmt.Errorf("access denied")
+	p := newTestProvider(fake, map[string]any{})
 
 	err := p.Set(context.Background(), "KEY", "val", "default")
 	if err == nil {
@@ -271,9 +594,10 @@ func TestProvider_Set_GetError(t *testing.T) {
 }
 
 func TestProvider_Set_PutError(t *testing.T) {
-	mock := newMockSMAPI(map[string]string{})
-	mock.putErr = fmt.Errorf("access denied")
-	p := &Provider{client: newClient(mock, "test")}
+	fake := newFakeSMAPI()
+	fake.seed("test", map[string]string{})
+	fake.putErr = fmt.Errorf("access denied")
+	p := newTestProvider(fake, map[string]any{})
 
 	err := p.Set(context.Background(), "KEY", "val", "default")
 	if err == nil {
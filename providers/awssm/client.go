@@ -2,106 +2,283 @@ package awssm
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 )
 
+// Version stages managed by Secrets Manager. Rotation stages a new version
+// as AWSPENDING before moving AWSCURRENT to it.
+const (
+	stageCurrent = "AWSCURRENT"
+	stagePending = "AWSPENDING"
+)
+
+// stageWriting labels a version dotenvy has written but not yet made
+// current. A version needs at least one label to survive until then.
+const stageWriting = "DOTENVY_WRITING"
+
+var (
+	// errConflict is returned when the secret's current version changed
+	// between read and write
+	errConflict = errors.New("aws-secretsmanager: secret was modified concurrently")
+
+	// errRotating is returned when a rotation has staged a pending version.
+	// Finishing the rotation would replace anything written meanwhile.
+	errRotating = errors.New("rotation in progress")
+)
+
 // smAPI defines the Secrets Manager operations used by the client
 type smAPI interface {
 	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
 	PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+	UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error)
 	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
+	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+	RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error)
+	TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
 }
 
 // client wraps the Secrets Manager API
 type client struct {
-	api        smAPI
-	secretName string
+	api smAPI
 }
 
 // newClient creates a new Secrets Manager client wrapper
-func newClient(api smAPI, secretName string) *client {
-	return &client{api: api, secretName: secretName}
+func newClient(api smAPI) *client {
+	return &client{api: api}
+}
+
+// secret is the AWSCURRENT version of a secret
+type secret struct {
+	Value     string
+	VersionID string
 }
 
-// Validate checks that credentials work and the secret exists (or can be created)
-func (c *client) Validate(ctx context.Context) error {
-	_, err := c.api.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(c.secretName),
+// writeOptions controls how a secret is created and tagged
+type writeOptions struct {
+	KeyID string            // KMS key for new secrets (default: aws/secretsmanager)
+	Tags  map[string]string // Added to the secret after each write
+}
+
+// Get returns the current version of a secret, or nil if it doesn't exist
+func (c *client) Get(ctx context.Context, name string) (*secret, error) {
+	output, err := c.api.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(name),
+		VersionStage: aws.String(stageCurrent),
 	})
 	if err != nil {
-		// If secret doesn't exist, try to create it with empty JSON
 		var notFound *smtypes.ResourceNotFoundException
 		if errors.As(err, &notFound) {
-			return c.createSecret(ctx, "{}")
+			return nil, nil
 		}
-		return fmt.Errorf("aws-secretsmanager: failed to validate: %w", err)
+		return nil, fmt.Errorf("aws-secretsmanager: failed to get secret %s: %w", name, err)
 	}
-	return nil
+	return &secret{Value: aws.ToString(output.SecretString), VersionID: aws.ToString(output.VersionId)}, nil
 }
 
-// GetJSON retrieves and parses the secret's JSON value
-func (c *client) GetJSON(ctx context.Context) (map[string]string, error) {
-	output, err := c.api.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(c.secretName),
-	})
-	if err != nil {
-		var notFound *smtypes.ResourceNotFoundException
-		if errors.As(err, &notFound) {
-			return make(map[string]string), nil
+// List returns the current values of all string secrets whose names start
+// with prefix, keyed by full name
+func (c *client) List(ctx context.Context, prefix string) (map[string]*secret, error) {
+	result := make(map[string]*secret)
+	var nextToken *string
+
+	for {
+		output, err := c.api.BatchGetSecretValue(ctx, &secretsmanager.BatchGetSecretValueInput{
+			Filters: []smtypes.Filter{{
+				Key:    smtypes.FilterNameStringTypeName,
+				Values: []string{prefix},
+			}},
+			MaxResults: aws.Int32(20),
+			NextToken:  nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("aws-secretsmanager: failed to list secrets: %w", err)
+		}
+		if len(output.Errors) > 0 {
+			e := output.Errors[0]
+			return nil, fmt.Errorf("aws-secretsmanager: failed to get secret %s: %s", aws.ToString(e.SecretId), aws.ToString(e.Message))
+		}
+
+		for _, s := range output.SecretValues {
+			if s.SecretString == nil {
+				continue
+			}
+			result[aws.ToString(s.Name)] = &secret{Value: aws.ToString(s.SecretString), VersionID: aws.ToString(s.VersionId)}
 		}
-		return nil, fmt.Errorf("aws-secretsmanager: failed to get secret: %w", err)
+
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// Write makes value the current version of a secret. previous is the
+// version the value was derived from, or nil to create the secret. The new
+// version is staged first and only made current if previous still is, so
+// concurrent writers get errConflict instead of overwriting each other.
+func (c *client) Write(ctx context.Context, name, value string, previous *secret, opts writeOptions) error {
+	if previous == nil {
+		return c.create(ctx, name, value, opts)
 	}
 
-	secretString := aws.ToString(output.SecretString)
-	if secretString == "" {
-		return make(map[string]string), nil
+	current, err := c.currentVersion(ctx, name)
+	if err != nil {
+		return err
+	}
+	if current != previous.VersionID {
+		return errConflict
 	}
 
-	var data map[string]string
-	if err := json.Unmarshal([]byte(secretString), &data); err != nil {
-		return nil, fmt.Errorf("aws-secretsmanager: failed to parse secret JSON: %w", err)
+	put, err := c.api.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:      aws.String(name),
+		SecretString:  aws.String(value),
+		VersionStages: []string{stageWriting},
+	})
+	if err != nil {
+		return fmt.Errorf("aws-secretsmanager: failed to put secret value %s: %w", name, err)
 	}
 
-	return data, nil
-}
+	// Moving AWSCURRENT fails unless it is still on the previous version
+	_, err = c.api.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(name),
+		VersionStage:        aws.String(stageCurrent),
+		MoveToVersionId:     put.VersionId,
+		RemoveFromVersionId: aws.String(previous.VersionID),
+	})
+	if err != nil {
+		if current, cerr := c.currentVersion(ctx, name); cerr == nil && current != previous.VersionID {
+			return errConflict
+		}
+		return fmt.Errorf("aws-secretsmanager: failed to update secret %s: %w", name, err)
+	}
 
-// PutJSON writes the map as a JSON string to the secret
-func (c *client) PutJSON(ctx context.Context, data map[string]string) error {
-	jsonBytes, err := json.Marshal(data)
+	_, err = c.api.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(name),
+		VersionStage:        aws.String(stageWriting),
+		RemoveFromVersionId: put.VersionId,
+	})
 	if err != nil {
-		return fmt.Errorf("aws-secretsmanager: failed to marshal JSON: %w", err)
+		return fmt.Errorf("aws-secretsmanager: failed to update secret %s: %w", name, err)
 	}
 
-	_, err = c.api.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
-		SecretId:     aws.String(c.secretName),
-		SecretString: aws.String(string(jsonBytes)),
+	return c.tag(ctx, name, opts.Tags)
+}
+
+// currentVersion returns the version ID labelled AWSCURRENT. It returns
+// errRotating if a rotation has staged a pending version.
+func (c *client) currentVersion(ctx context.Context, name string) (string, error) {
+	output, err := c.api.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
 	})
 	if err != nil {
-		// If secret doesn't exist, create it
-		var notFound *smtypes.ResourceNotFoundException
-		if errors.As(err, &notFound) {
-			return c.createSecret(ctx, string(jsonBytes))
+		return "", fmt.Errorf("aws-secretsmanager: failed to describe secret %s: %w", name, err)
+	}
+
+	current := ""
+	for id, stages := range output.VersionIdsToStages {
+		isCurrent, isPending := false, false
+		for _, stage := range stages {
+			isCurrent = isCurrent || stage == stageCurrent
+			isPending = isPending || stage == stagePending
+		}
+		if isPending && !isCurrent {
+			return "", fmt.Errorf("aws-secretsmanager: secret %s: %w, try again once it finishes", name, errRotating)
 		}
-		return fmt.Errorf("aws-secretsmanager: failed to put secret value: %w", err)
+		if isCurrent {
+			current = id
+		}
+	}
+	return current, nil
+}
+
+// create creates a secret. A secret that is scheduled for deletion is
+// restored instead, and errConflict returned so the caller writes again
+// from its restored value.
+func (c *client) create(ctx context.Context, name, value string, opts writeOptions) error {
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+		Tags:         sortedTags(opts.Tags),
+	}
+	if opts.KeyID != "" {
+		input.KmsKeyId = aws.String(opts.KeyID)
 	}
 
+	_, err := c.api.CreateSecret(ctx, input)
+	if err == nil {
+		return nil
+	}
+
+	var exists *smtypes.ResourceExistsException
+	if errors.As(err, &exists) {
+		return errConflict
+	}
+	var invalid *smtypes.InvalidRequestException
+	if errors.As(err, &invalid) {
+		output, derr := c.api.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(name)})
+		if derr == nil && output.DeletedDate != nil {
+			if _, rerr := c.api.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{SecretId: aws.String(name)}); rerr != nil {
+				return fmt.Errorf("aws-secretsmanager: failed to restore secret %s: %w", name, rerr)
+			}
+			return errConflict
+		}
+	}
+	return fmt.Errorf("aws-secretsmanager: failed to create secret %s: %w", name, err)
+}
+
+// Delete deletes a secret, with the default recovery window unless force is
+// set. Missing secrets are ignored.
+func (c *client) Delete(ctx context.Context, name string, force bool) error {
+	input := &secretsmanager.DeleteSecretInput{SecretId: aws.String(name)}
+	if force {
+		input.ForceDeleteWithoutRecovery = aws.Bool(true)
+	}
+	_, err := c.api.DeleteSecret(ctx, input)
+	var notFound *smtypes.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("aws-secretsmanager: failed to delete secret %s: %w", name, err)
+	}
 	return nil
 }
 
-// createSecret creates a new secret with the given string value
-func (c *client) createSecret(ctx context.Context, value string) error {
-	_, err := c.api.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
-		Name:         aws.String(c.secretName),
-		SecretString: aws.String(value),
+// tag adds tags to a secret
+func (c *client) tag(ctx context.Context, name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := c.api.TagResource(ctx, &secretsmanager.TagResourceInput{
+		SecretId: aws.String(name),
+		Tags:     sortedTags(tags),
 	})
 	if err != nil {
-		return fmt.Errorf("aws-secretsmanager: failed to create secret: %w", err)
+		return fmt.Errorf("aws-secretsmanager: failed to tag secret %s: %w", name, err)
 	}
 	return nil
 }
+
+// sortedTags converts a tag map to API tags in key order
+func sortedTags(tags map[string]string) []smtypes.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]smtypes.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, smtypes.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
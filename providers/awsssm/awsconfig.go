@@ -0,0 +1,21 @@
+package awsssm
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// LoadAWSConfig loads SDK credentials and settings for a region, using a
+// named profile from the shared config if one is given. The other AWS
+// providers (Lambda, ECS) load their config the same way.
+func LoadAWSConfig(ctx context.Context, region, profile string) (aws.Config, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(region),
+	}
+	if profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
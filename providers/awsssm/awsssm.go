@@ -3,8 +3,8 @@ package awsssm
 import (
 	"context"
 	"fmt"
+	"strings"
 
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
@@ -21,11 +21,13 @@ func init() {
 	})
 }
 
-// Provider implements the AWS SSM Parameter Store provider
+// Provider implements the AWS SSM Parameter Store provider. The prefix may
+// contain {env}, so each remote environment gets its own parameter path.
 type Provider struct {
 	client *client
 	region string
 	prefix string
+	config map[string]any
 }
 
 // New creates a new AWS SSM provider
@@ -42,15 +44,7 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 
 	profile, _ := config["profile"].(string)
 
-	// Build AWS config options
-	opts := []func(*awsconfig.LoadOptions) error{
-		awsconfig.WithRegion(region),
-	}
-	if profile != "" {
-		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
-	}
-
-	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	cfg, err := LoadAWSConfig(context.Background(), region, profile)
 	if err != nil {
 		return nil, fmt.Errorf("aws-ssm: failed to load AWS config: %w", err)
 	}
@@ -58,9 +52,10 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 	ssmClient := ssm.NewFromConfig(cfg)
 
 	return &Provider{
-		client: newClient(ssmClient, prefix),
+		client: newClient(ssmClient),
 		region: region,
 		prefix: prefix,
+		config: config,
 	}, nil
 }
 
@@ -68,6 +63,9 @@ func (p *Provider) Name() string        { return "aws-ssm" }
 func (p *Provider) DisplayName() string { return "AWS SSM Parameter Store" }
 
 func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
 	return []string{"default"}
 }
 
@@ -81,8 +79,22 @@ func (p *Provider) Validate(ctx context.Context) error {
 	return p.client.Validate(ctx)
 }
 
+// path returns the parameter path of an environment: its prefix, which may
+// be overridden per environment, with {env} expanded and a trailing slash
+func (p *Provider) path(environment string) string {
+	prefix := p.prefix
+	if override := provider.EnvSetting(p.config, environment, "prefix"); override != "" {
+		prefix = override
+	}
+	prefix = provider.ExpandEnv(prefix, environment)
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	params, err := p.client.ListParameters(ctx)
+	params, err := p.client.ListParameters(ctx, p.path(environment))
 	if err != nil {
 		return nil, err
 	}
@@ -99,10 +111,16 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 	return secrets, nil
 }
 
+// Set writes a parameter: SecureString for sensitive secrets, encrypted with
+// the environment's kms_key_id if set, and String otherwise
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	return p.client.PutParameter(ctx, name, value)
+	return p.client.PutParameter(ctx, p.path(environment)+name, value, parameterOptions{
+		Secure: provider.SecretSchema(p.config, name).IsSensitive(),
+		KeyID:  provider.EnvSetting(p.config, environment, "kms_key_id"),
+		Tags:   provider.MapSetting(p.config, "tags", environment),
+	})
 }
 
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	return p.client.DeleteParameter(ctx, name)
+	return p.client.DeleteParameter(ctx, p.path(environment)+name)
 }
@@ -3,11 +3,18 @@ package awsssm
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"golang.org/x/time/rate"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
 )
 
 // mockSSMAPI implements ssmAPI for testing
@@ -15,14 +22,21 @@ type mockSSMAPI struct {
 	params         map[string]string
 	describeErr    error
 	getByPathErr   error
+	getErr         error
+	latency        time.Duration // Simulated round trip of list and get calls
 	putErr         error
 	deleteErr      error
 	deletedParams  []string
+	puts           []*ssm.PutParameterInput
+	types          map[string]types.ParameterType
+	tags           map[string]map[string]string
 }
 
 func newMockSSMAPI() *mockSSMAPI {
 	return &mockSSMAPI{
 		params: make(map[string]string),
+		types:  make(map[string]types.ParameterType),
+		tags:   make(map[string]map[string]string),
 	}
 }
 
@@ -30,37 +44,100 @@ func (m *mockSSMAPI) DescribeParameters(ctx context.Context, params *ssm.Describ
 	if m.describeErr != nil {
 		return nil, m.describeErr
 	}
-	return &ssm.DescribeParametersOutput{}, nil
+	time.Sleep(m.latency)
+	if len(params.ParameterFilters) == 0 {
+		return &ssm.DescribeParametersOutput{}, nil
+	}
+
+	names := m.children(strings.TrimSuffix(params.ParameterFilters[0].Values[0], "/") + "/")
+	start, _ := strconv.Atoi(aws.ToString(params.NextToken))
+	end := min(start+int(aws.ToInt32(params.MaxResults)), len(names))
+	out := &ssm.DescribeParametersOutput{}
+	for _, name := range names[start:end] {
+		out.Parameters = append(out.Parameters, types.ParameterMetadata{Name: aws.String(name)})
+	}
+	if end < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (m *mockSSMAPI) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	if len(params.Names) > 10 || !aws.ToBool(params.WithDecryption) {
+		return nil, fmt.Errorf("invalid request")
+	}
+	time.Sleep(m.latency)
+	out := &ssm.GetParametersOutput{}
+	for _, name := range params.Names {
+		if value, ok := m.params[name]; ok {
+			out.Parameters = append(out.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value)})
+		}
+	}
+	return out, nil
+}
+
+// children returns the sorted names of the parameters directly under prefix
+func (m *mockSSMAPI) children(prefix string) []string {
+	var names []string
+	for name := range m.params {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if ok && !strings.Contains(rest, "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (m *mockSSMAPI) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
 	if m.getByPathErr != nil {
 		return nil, m.getByPathErr
 	}
-
-	prefix := aws.ToString(params.Path)
-	var parameters []types.Parameter
-	for name, value := range m.params {
-		parameters = append(parameters, types.Parameter{
-			Name:  aws.String(prefix + name),
-			Value: aws.String(value),
-		})
+	if size := aws.ToInt32(params.MaxResults); size < 1 || size > 10 || !aws.ToBool(params.WithDecryption) {
+		return nil, fmt.Errorf("invalid request")
 	}
+	time.Sleep(m.latency)
 
-	return &ssm.GetParametersByPathOutput{
-		Parameters: parameters,
-	}, nil
+	// Parameters are stored by full name; return the direct children of the path
+	names := m.children(aws.ToString(params.Path))
+	start, _ := strconv.Atoi(aws.ToString(params.NextToken))
+	end := min(start+int(aws.ToInt32(params.MaxResults)), len(names))
+	out := &ssm.GetParametersByPathOutput{}
+	for _, name := range names[start:end] {
+		out.Parameters = append(out.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(m.params[name])})
+	}
+	if end < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
 }
 
 func (m *mockSSMAPI) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
 	if m.putErr != nil {
 		return nil, m.putErr
 	}
-	// Store without prefix (the caller adds prefix)
-	m.params[aws.ToString(params.Name)] = aws.ToString(params.Value)
+	name := aws.ToString(params.Name)
+	if t, ok := m.types[name]; ok && t != params.Type {
+		return nil, &types.HierarchyTypeMismatchException{Message: aws.String("type change not supported")}
+	}
+	m.puts = append(m.puts, params)
+	m.params[name] = aws.ToString(params.Value)
+	m.types[name] = params.Type
 	return &ssm.PutParameterOutput{}, nil
 }
 
+func (m *mockSSMAPI) AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	tags := make(map[string]string)
+	for _, tag := range params.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	m.tags[aws.ToString(params.ResourceId)] = tags
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
 func (m *mockSSMAPI) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
 	if m.deleteErr != nil {
 		return nil, m.deleteErr
@@ -68,6 +145,7 @@ func (m *mockSSMAPI) DeleteParameter(ctx context.Context, params *ssm.DeletePara
 	name := aws.ToString(params.Name)
 	m.deletedParams = append(m.deletedParams, name)
 	delete(m.params, name)
+	delete(m.types, name)
 	return &ssm.DeleteParameterOutput{}, nil
 }
 
@@ -76,7 +154,7 @@ func TestNew_ValidConfig(t *testing.T) {
 	// but we can test the provider interface with a mock.
 	mock := newMockSSMAPI()
 	p := &Provider{
-		client: newClient(mock, "/myapp/dev/"),
+		client: newClient(mock),
 		region: "us-east-1",
 		prefix: "/myapp/dev/",
 	}
@@ -98,7 +176,7 @@ func TestNew_MissingRegion(t *testing.T) {
 
 func TestProvider_Environments(t *testing.T) {
 	mock := newMockSSMAPI()
-	p := &Provider{client: newClient(mock, "/")}
+	p := &Provider{client: newClient(mock)}
 
 	envs := p.Environments()
 	if len(envs) != 1 || envs[0] != "default" {
@@ -108,7 +186,7 @@ func TestProvider_Environments(t *testing.T) {
 
 func TestProvider_DefaultMapping(t *testing.T) {
 	mock := newMockSSMAPI()
-	p := &Provider{client: newClient(mock, "/")}
+	p := &Provider{client: newClient(mock)}
 
 	mapping := p.DefaultMapping()
 	if mapping["default"] != "test" {
@@ -118,7 +196,7 @@ func TestProvider_DefaultMapping(t *testing.T) {
 
 func TestProvider_Validate(t *testing.T) {
 	mock := newMockSSMAPI()
-	p := &Provider{client: newClient(mock, "/")}
+	p := &Provider{client: newClient(mock)}
 
 	if err := p.Validate(context.Background()); err != nil {
 		t.Errorf("Validate() error = %v", err)
@@ -128,7 +206,7 @@ func TestProvider_Validate(t *testing.T) {
 func TestProvider_Validate_Error(t *testing.T) {
 	mock := newMockSSMAPI()
 	mock.describeErr = fmt.Errorf("access denied")
-	p := &Provider{client: newClient(mock, "/")}
+	p := &Provider{client: newClient(mock)}
 
 	if err := p.Validate(context.Background()); err == nil {
 		t.Error("expected error from Validate()")
@@ -137,11 +215,11 @@ func TestProvider_Validate_Error(t *testing.T) {
 
 func TestProvider_List(t *testing.T) {
 	mock := newMockSSMAPI()
-	mock.params["DB_URL"] = "postgres://localhost"
-	mock.params["API_KEY"] = "sk_test_123"
+	mock.params["/myapp/DB_URL"] = "postgres://localhost"
+	mock.params["/myapp/API_KEY"] = "sk_test_123"
 
 	p := &Provider{
-		client: newClient(mock, "/myapp/"),
+		client: newClient(mock),
 		prefix: "/myapp/",
 	}
 
@@ -170,7 +248,7 @@ func TestProvider_List(t *testing.T) {
 func TestProvider_Set(t *testing.T) {
 	mock := newMockSSMAPI()
 	p := &Provider{
-		client: newClient(mock, "/myapp/"),
+		client: newClient(mock),
 		prefix: "/myapp/",
 	}
 
@@ -188,7 +266,7 @@ func TestProvider_Set(t *testing.T) {
 func TestProvider_Delete(t *testing.T) {
 	mock := newMockSSMAPI()
 	p := &Provider{
-		client: newClient(mock, "/myapp/"),
+		client: newClient(mock),
 		prefix: "/myapp/",
 	}
 
@@ -206,7 +284,7 @@ func TestProvider_List_Error(t *testing.T) {
 	mock := newMockSSMAPI()
 	mock.getByPathErr = fmt.Errorf("access denied")
 	p := &Provider{
-		client: newClient(mock, "/"),
+		client: newClient(mock),
 		prefix: "/",
 	}
 
@@ -220,7 +298,7 @@ func TestProvider_Set_Error(t *testing.T) {
 	mock := newMockSSMAPI()
 	mock.putErr = fmt.Errorf("access denied")
 	p := &Provider{
-		client: newClient(mock, "/"),
+		client: newClient(mock),
 		prefix: "/",
 	}
 
@@ -234,7 +312,7 @@ func TestProvider_Delete_Error(t *testing.T) {
 	mock := newMockSSMAPI()
 	mock.deleteErr = fmt.Errorf("access denied")
 	p := &Provider{
-		client: newClient(mock, "/"),
+		client: newClient(mock),
 		prefix: "/",
 	}
 
@@ -243,3 +321,158 @@ func TestProvider_Delete_Error(t *testing.T) {
 		t.Error("expected error from Delete()")
 	}
 }
+
+func TestProvider_EnvTemplatedPrefix(t *testing.T) {
+	mock := newMockSSMAPI()
+	mock.params["/myapp/staging/DB_URL"] = "postgres://staging"
+	mock.params["/myapp/production/DB_URL"] = "postgres://prod"
+	mock.params["/legacy/DB_URL"] = "postgres://legacy"
+	p := &Provider{
+		client: newClient(mock),
+		prefix: "/myapp/{env}",
+		config: map[string]any{
+			"environments": map[string]map[string]string{
+				"legacy": {"prefix": "/legacy/"},
+			},
+		},
+	}
+	ctx := context.Background()
+
+	for env, want := range map[string]string{"staging": "postgres://staging", "production": "postgres://prod", "legacy": "postgres://legacy"} {
+		secrets, err := p.List(ctx, env)
+		if err != nil {
+			t.Fatalf("List(%s) error = %v", env, err)
+		}
+		if len(secrets) != 1 || secrets[0].Name != "DB_URL" || secrets[0].Value != want {
+			t.Errorf("List(%s) = %+v, want DB_URL=%s", env, secrets, want)
+		}
+	}
+
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if mock.params["/myapp/production/API_KEY"] != "sk_live" {
+		t.Errorf("params = %v", mock.params)
+	}
+	if err := p.Delete(ctx, "DB_URL", "staging"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := mock.params["/myapp/staging/DB_URL"]; ok {
+		t.Error("staging DB_URL should be deleted")
+	}
+}
+
+func TestProvider_SetTypeKeyAndTags(t *testing.T) {
+	mock := newMockSSMAPI()
+	plain := false
+	p := &Provider{
+		client: newClient(mock),
+		prefix: "/myapp/{env}/",
+		config: map[string]any{
+			"kms_key_id": "alias/myapp",
+			"tags":       map[string]string{"team": "shop", "stage": "{env}"},
+			"environments": map[string]map[string]string{
+				"production": {"kms_key_id": "alias/myapp-prod"},
+			},
+			"_schema": map[string]model.Secret{"PUBLIC_URL": {Sensitive: &plain}},
+		},
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := p.Set(ctx, "PUBLIC_URL", "https://example.com", "staging"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	secure, plainPut := mock.puts[0], mock.puts[1]
+	if secure.Type != types.ParameterTypeSecureString || aws.ToString(secure.KeyId) != "alias/myapp-prod" {
+		t.Errorf("API_KEY put as %s with key %q", secure.Type, aws.ToString(secure.KeyId))
+	}
+	if plainPut.Type != types.ParameterTypeString || plainPut.KeyId != nil {
+		t.Errorf("PUBLIC_URL put as %s with key %q", plainPut.Type, aws.ToString(plainPut.KeyId))
+	}
+	if secure.Tier != types.ParameterTierIntelligentTiering {
+		t.Errorf("Tier = %s, want Intelligent-Tiering", secure.Tier)
+	}
+	if tags := mock.tags["/myapp/staging/PUBLIC_URL"]; tags["team"] != "shop" || tags["stage"] != "staging" {
+		t.Errorf("tags = %v", tags)
+	}
+}
+
+func TestProvider_SetRecreatesOnTypeChange(t *testing.T) {
+	mock := newMockSSMAPI()
+	mock.params["/myapp/PUBLIC_URL"] = "https://old.example.com"
+	mock.types["/myapp/PUBLIC_URL"] = types.ParameterTypeSecureString
+	plain := false
+	p := &Provider{
+		client: newClient(mock),
+		prefix: "/myapp/",
+		config: map[string]any{"_schema": map[string]model.Secret{"PUBLIC_URL": {Sensitive: &plain}}},
+	}
+
+	if err := p.Set(context.Background(), "PUBLIC_URL", "https://example.com", "default"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if mock.types["/myapp/PUBLIC_URL"] != types.ParameterTypeString || len(mock.deletedParams) != 1 {
+		t.Errorf("type = %s, deleted = %v", mock.types["/myapp/PUBLIC_URL"], mock.deletedParams)
+	}
+}
+
+func TestProvider_ListManyPages(t *testing.T) {
+	mock := newMockSSMAPI()
+	for i := range 123 {
+		mock.params[fmt.Sprintf("/myapp/KEY_%03d", i)] = fmt.Sprint(i)
+	}
+	mock.params["/myapp/nested/KEY"] = "skip"
+
+	// Concurrent batches after the first page, and by path only
+	for _, concurrency := range []int{fetchConcurrency, 1} {
+		p := &Provider{client: newClient(mock), prefix: "/myapp/"}
+		p.client.concurrency = concurrency
+		p.client.limiter = rate.NewLimiter(rate.Inf, 0)
+
+		secrets, err := p.List(context.Background(), "default")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(secrets) != 123 {
+			t.Fatalf("List() returned %d secrets, want 123", len(secrets))
+		}
+		for _, s := range secrets {
+			if want := mock.params["/myapp/"+s.Name]; s.Value != want {
+				t.Errorf("%s = %q, want %q", s.Name, s.Value, want)
+			}
+		}
+	}
+
+	mock.getErr = fmt.Errorf("access denied")
+	p := &Provider{client: newClient(mock), prefix: "/myapp/"}
+	if _, err := p.List(context.Background(), "default"); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("List() error = %v", err)
+	}
+}
+
+// BenchmarkListParameters lists 200 parameters with 5ms per API call, by
+// path one page at a time and with the default concurrency
+func BenchmarkListParameters(b *testing.B) {
+	mock := newMockSSMAPI()
+	for i := range 200 {
+		mock.params[fmt.Sprintf("/myapp/KEY_%03d", i)] = "value"
+	}
+	mock.latency = 5 * time.Millisecond
+
+	for _, concurrency := range []int{1, fetchConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			c := newClient(mock)
+			c.concurrency = concurrency
+			c.limiter = rate.NewLimiter(rate.Inf, 0)
+			for b.Loop() {
+				if _, err := c.ListParameters(context.Background(), "/myapp/"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
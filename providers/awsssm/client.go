@@ -2,30 +2,66 @@ package awsssm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// pageSize is the most parameters GetParametersByPath returns per page,
+	// and GetParameters accepts per call
+	pageSize = 10
+
+	// describePageSize is the most parameters DescribeParameters returns per page
+	describePageSize = 50
+
+	// fetchConcurrency bounds how many GetParameters calls run at once
+	fetchConcurrency = 5
+
+	// requestRate keeps listing below the default SSM throughput of 40
+	// transactions per second, leaving room for other clients
+	requestRate rate.Limit = 20
 )
 
 // ssmAPI defines the SSM operations used by the client
 type ssmAPI interface {
 	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
 	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
 	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
 	DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+	AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
 }
 
 // client wraps the SSM API for parameter operations
 type client struct {
-	api    ssmAPI
-	prefix string
+	api         ssmAPI
+	concurrency int           // Batches fetched at once; 1 pages by path only
+	limiter     *rate.Limiter // Shared by all list and fetch calls
 }
 
 // newClient creates a new SSM client wrapper
-func newClient(api ssmAPI, prefix string) *client {
-	return &client{api: api, prefix: prefix}
+func newClient(api ssmAPI) *client {
+	return &client{
+		api:         api,
+		concurrency: fetchConcurrency,
+		limiter:     rate.NewLimiter(requestRate, fetchConcurrency),
+	}
+}
+
+// parameterOptions controls how a parameter is written
+type parameterOptions struct {
+	Secure bool              // SecureString rather than String
+	KeyID  string            // KMS key for SecureString values (default: aws/ssm)
+	Tags   map[string]string // Added to the parameter after each write
 }
 
 // Validate checks that credentials and access are working
@@ -39,16 +75,25 @@ func (c *client) Validate(ctx context.Context) error {
 	return nil
 }
 
-// ListParameters returns all parameters under the configured prefix
-func (c *client) ListParameters(ctx context.Context) (map[string]string, error) {
+// ListParameters returns all parameters directly under a path prefix, keyed
+// by name without the prefix. The first page comes from GetParametersByPath
+// with decryption. GetParametersByPath returns at most 10 parameters per page
+// and each page needs the previous one's token, so for a longer path the
+// remaining names are listed with DescribeParameters in pages of 50 and their
+// values fetched in concurrent GetParameters batches.
+func (c *client) ListParameters(ctx context.Context, prefix string) (map[string]string, error) {
 	result := make(map[string]string)
 	var nextToken *string
 
 	for {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
 		output, err := c.api.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
-			Path:           aws.String(c.prefix),
+			Path:           aws.String(prefix),
 			WithDecryption: aws.Bool(true),
 			Recursive:      aws.Bool(false),
+			MaxResults:     aws.Int32(pageSize),
 			NextToken:      nextToken,
 		})
 		if err != nil {
@@ -56,41 +101,163 @@ func (c *client) ListParameters(ctx context.Context) (map[string]string, error)
 		}
 
 		for _, param := range output.Parameters {
-			name := aws.ToString(param.Name)
 			// Strip prefix to get the bare secret name
-			if len(name) > len(c.prefix) {
-				name = name[len(c.prefix):]
-			}
+			name := strings.TrimPrefix(aws.ToString(param.Name), prefix)
 			result[name] = aws.ToString(param.Value)
 		}
 
 		nextToken = output.NextToken
 		if nextToken == nil {
+			return result, nil
+		}
+		if c.concurrency > 1 {
 			break
 		}
 	}
 
+	names, err := c.describeNames(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var remaining []string
+	for _, name := range names {
+		if _, ok := result[strings.TrimPrefix(name, prefix)]; !ok {
+			remaining = append(remaining, name)
+		}
+	}
+	if err := c.getParameters(ctx, prefix, remaining, result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-// PutParameter creates or updates a parameter
-func (c *client) PutParameter(ctx context.Context, name, value string) error {
-	_, err := c.api.PutParameter(ctx, &ssm.PutParameterInput{
-		Name:      aws.String(c.prefix + name),
+// describeNames returns the full names of the parameters directly under a
+// path prefix
+func (c *client) describeNames(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	var nextToken *string
+
+	path := prefix
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	for {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		output, err := c.api.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+			ParameterFilters: []types.ParameterStringFilter{{
+				Key:    aws.String("Path"),
+				Option: aws.String("OneLevel"),
+				Values: []string{path},
+			}},
+			MaxResults: aws.Int32(describePageSize),
+			NextToken:  nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("aws-ssm: failed to list parameters: %w", err)
+		}
+
+		for _, param := range output.Parameters {
+			names = append(names, aws.ToString(param.Name))
+		}
+
+		nextToken = output.NextToken
+		if nextToken == nil {
+			return names, nil
+		}
+	}
+}
+
+// getParameters fetches the decrypted values of names into result, in
+// batches of up to 10 with at most c.concurrency calls in flight
+func (c *client) getParameters(ctx context.Context, prefix string, names []string, result map[string]string) error {
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for start := 0; start < len(names); start += pageSize {
+		batch := names[start:min(start+pageSize, len(names))]
+		g.Go(func() error {
+			if err := c.limiter.Wait(gctx); err != nil {
+				return err
+			}
+			output, err := c.api.GetParameters(gctx, &ssm.GetParametersInput{
+				Names:          batch,
+				WithDecryption: aws.Bool(true),
+			})
+			if err != nil {
+				return fmt.Errorf("aws-ssm: failed to get parameters: %w", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, param := range output.Parameters {
+				result[strings.TrimPrefix(aws.ToString(param.Name), prefix)] = aws.ToString(param.Value)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// PutParameter creates or updates a parameter. Intelligent-Tiering stores
+// values over 4 KB in the Advanced tier and never downgrades a parameter.
+// SSM can't change a parameter's type in place, so one whose type changes
+// is deleted and created again.
+func (c *client) PutParameter(ctx context.Context, name, value string, opts parameterOptions) error {
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(name),
 		Value:     aws.String(value),
-		Type:      types.ParameterTypeSecureString,
+		Type:      types.ParameterTypeString,
+		Tier:      types.ParameterTierIntelligentTiering,
 		Overwrite: aws.Bool(true),
-	})
+	}
+	if opts.Secure {
+		input.Type = types.ParameterTypeSecureString
+		if opts.KeyID != "" {
+			input.KeyId = aws.String(opts.KeyID)
+		}
+	}
+
+	_, err := c.api.PutParameter(ctx, input)
+	var mismatch *types.HierarchyTypeMismatchException
+	if errors.As(err, &mismatch) {
+		if err := c.DeleteParameter(ctx, name); err != nil {
+			return err
+		}
+		_, err = c.api.PutParameter(ctx, input)
+	}
 	if err != nil {
 		return fmt.Errorf("aws-ssm: failed to put parameter %s: %w", name, err)
 	}
+
+	if len(opts.Tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(opts.Tags))
+	for k := range opts.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, types.Tag{Key: aws.String(k), Value: aws.String(opts.Tags[k])})
+	}
+	_, err = c.api.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   aws.String(name),
+		Tags:         tags,
+	})
+	if err != nil {
+		return fmt.Errorf("aws-ssm: failed to tag parameter %s: %w", name, err)
+	}
 	return nil
 }
 
 // DeleteParameter removes a parameter
 func (c *client) DeleteParameter(ctx context.Context, name string) error {
 	_, err := c.api.DeleteParameter(ctx, &ssm.DeleteParameterInput{
-		Name: aws.String(c.prefix + name),
+		Name: aws.String(name),
 	})
 	if err != nil {
 		return fmt.Errorf("aws-ssm: failed to delete parameter %s: %w", name, err)
@@ -0,0 +1,155 @@
+package azurekv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+// vaultResource is the audience for Key Vault data-plane tokens
+const vaultResource = "https://vault.azure.net"
+
+// defaultAuthorityHost is the Microsoft Entra ID endpoint for the public cloud
+const defaultAuthorityHost = "https://login.microsoftonline.com"
+
+// tokenSource returns a bearer token for Key Vault requests
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// cachedToken is a token and its expiry
+type cachedToken struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// get returns the cached token if it is still valid for at least a minute
+func (c *cachedToken) get() string {
+	if c.token != "" && time.Until(c.expiresAt) > time.Minute {
+		return c.token
+	}
+	return ""
+}
+
+// clientCredentials gets tokens with a service principal's client secret
+type clientCredentials struct {
+	authorityHost string
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	http          *http.Client
+	cache         cachedToken
+}
+
+func (c *clientCredentials) Token(ctx context.Context) (string, error) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if token := c.cache.get(); token != "" {
+		return token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"scope":         {vaultResource + "/.default"},
+	}
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimRight(c.authorityHost, "/"), c.tenantID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("azure-keyvault: client credentials login failed: %s", result.ErrorDescription)
+	}
+
+	c.cache.token = result.AccessToken
+	c.cache.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.cache.token, nil
+}
+
+// cliCredentials gets tokens from a logged-in Azure CLI (`az login`)
+type cliCredentials struct {
+	cache cachedToken
+}
+
+func (c *cliCredentials) Token(ctx context.Context) (string, error) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if token := c.cache.get(); token != "" {
+		return token, nil
+	}
+
+	out, err := exec.CommandContext(ctx, "az", "account", "get-access-token",
+		"--resource", vaultResource, "--output", "json").Output()
+	if err != nil {
+		return "", fmt.Errorf("azure-keyvault: no client credentials and Azure CLI token unavailable (run az login): %w", err)
+	}
+
+	var result struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresOn   int64  `json:"expires_on"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil || result.AccessToken == "" {
+		return "", fmt.Errorf("azure-keyvault: failed to parse Azure CLI token")
+	}
+
+	c.cache.token = result.AccessToken
+	c.cache.expiresAt = time.Unix(result.ExpiresOn, 0)
+	if result.ExpiresOn == 0 {
+		// Older CLI versions only report a local-time expiresOn string
+		c.cache.expiresAt = time.Now().Add(5 * time.Minute)
+	}
+	return c.cache.token, nil
+}
+
+// newTokenSource picks client credentials when they are configured, and
+// falls back to the Azure CLI otherwise.
+func newTokenSource(config map[string]any) tokenSource {
+	tenantID := provider.ConfigOrEnv(config, "tenant_id", "AZURE_TENANT_ID")
+	clientID := provider.ConfigOrEnv(config, "client_id", "AZURE_CLIENT_ID")
+	clientSecret := provider.ConfigOrEnv(config, "client_secret", "AZURE_CLIENT_SECRET")
+
+	if tenantID != "" && clientID != "" && clientSecret != "" {
+		authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+		if authorityHost == "" {
+			authorityHost = defaultAuthorityHost
+		}
+		return &clientCredentials{
+			authorityHost: authorityHost,
+			tenantID:      tenantID,
+			clientID:      clientID,
+			clientSecret:  clientSecret,
+			http:          &http.Client{},
+		}
+	}
+
+	return &cliCredentials{}
+}
@@ -0,0 +1,179 @@
+package azurekv
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "azure-keyvault",
+		DisplayName: "Azure Key Vault",
+		Factory:     New,
+		SdkAuth:     true,
+		Beta:        true,
+	})
+}
+
+// nameTag records the original env var name on each secret, since Key Vault
+// names can't hold underscores
+const nameTag = "dotenvy-name"
+
+// validName matches env var names that can be mapped to Key Vault names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Provider implements the Azure Key Vault provider. Each env var is stored
+// as its own secret, optionally under a per-environment prefix or vault.
+type Provider struct {
+	config map[string]any
+	tokens tokenSource
+	purge  bool
+
+	mu      sync.Mutex
+	clients map[string]*Client // vault URL -> client
+}
+
+// New creates a new Azure Key Vault provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	envs := provider.ConfiguredEnvironments(config)
+	if len(envs) == 0 {
+		envs = []string{"default"}
+	}
+	for _, env := range envs {
+		if provider.EnvSetting(config, env, "vault_url") == "" {
+			return nil, fmt.Errorf("azure-keyvault: vault_url is required (environment %q)", env)
+		}
+	}
+
+	purge, _ := config["purge_on_delete"].(bool)
+
+	return &Provider{
+		config:  config,
+		tokens:  newTokenSource(config),
+		purge:   purge,
+		clients: make(map[string]*Client),
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "azure-keyvault" }
+func (p *Provider) DisplayName() string { return "Azure Key Vault" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"default"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"default": "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	for _, env := range p.Environments() {
+		client, _ := p.clientFor(env)
+		if _, err := client.ListSecrets(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clientFor returns the client and name prefix for a remote environment
+func (p *Provider) clientFor(environment string) (*Client, string) {
+	vaultURL := provider.EnvSetting(p.config, environment, "vault_url")
+	prefix := provider.EnvSetting(p.config, environment, "prefix")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	client, ok := p.clients[vaultURL]
+	if !ok {
+		client = NewClient(vaultURL, p.tokens)
+		p.clients[vaultURL] = client
+	}
+	return client, prefix
+}
+
+// SecretName maps an env var name to a Key Vault secret name: underscores
+// become dashes (the only separator Key Vault allows).
+func SecretName(prefix, name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("azure-keyvault: %q can't be stored in Key Vault (only letters, digits, _ and - are allowed)", name)
+	}
+	secretName := strings.ReplaceAll(prefix+name, "_", "-")
+	if len(secretName) > 127 {
+		return "", fmt.Errorf("azure-keyvault: %q is longer than 127 characters", secretName)
+	}
+	return secretName, nil
+}
+
+// envName maps a Key Vault secret back to an env var name. The name tag
+// written by Set wins; otherwise dashes are read back as underscores.
+func envName(item SecretItem, prefix string) (string, bool) {
+	secretName := item.Name()
+	keyPrefix := strings.ReplaceAll(prefix, "_", "-")
+	if !strings.HasPrefix(strings.ToLower(secretName), strings.ToLower(keyPrefix)) {
+		return "", false
+	}
+	if name := item.Tags[nameTag]; name != "" {
+		return name, true
+	}
+	return strings.ReplaceAll(secretName[len(keyPrefix):], "-", "_"), true
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	client, prefix := p.clientFor(environment)
+
+	items, err := client.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for _, item := range items {
+		if !item.Attributes.Enabled {
+			continue
+		}
+		name, ok := envName(item, prefix)
+		if !ok {
+			continue
+		}
+		value, err := client.GetSecret(ctx, item.Name())
+		if err != nil {
+			return nil, fmt.Errorf("azure-keyvault: failed to read %s: %w", item.Name(), err)
+		}
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	client, prefix := p.clientFor(environment)
+	secretName, err := SecretName(prefix, name)
+	if err != nil {
+		return err
+	}
+	return client.SetSecret(ctx, secretName, value, map[string]string{nameTag: name})
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	client, prefix := p.clientFor(environment)
+	secretName, err := SecretName(prefix, name)
+	if err != nil {
+		return err
+	}
+	return client.DeleteSecret(ctx, secretName, p.purge)
+}
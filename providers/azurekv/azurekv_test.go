@@ -0,0 +1,322 @@
+package azurekv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSecret is a stored secret in the fake vault
+type fakeSecret struct {
+	Value string
+	Tags  map[string]string
+}
+
+// fakeKeyVault is an in-memory stand-in for the Key Vault data plane and the
+// Entra ID token endpoint
+type fakeKeyVault struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	secrets  map[string]fakeSecret
+	deleted  map[string]fakeSecret // Soft-deleted (recoverable) secrets
+	purged   []string
+	logins   int
+	pending  int // GETs on a deleted secret that 404 before it appears
+	lastAuth string
+}
+
+func newFakeKeyVault(t *testing.T) *fakeKeyVault {
+	f := &fakeKeyVault{
+		secrets: make(map[string]fakeSecret),
+		deleted: make(map[string]fakeSecret),
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+
+	oldInterval := pollInterval
+	pollInterval = 0
+	t.Cleanup(func() { pollInterval = oldInterval })
+
+	return f
+}
+
+func writeError(w http.ResponseWriter, status int, code, inner, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": code, "message": message, "innererror": map[string]string{"code": inner}},
+	})
+}
+
+func (f *fakeKeyVault) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+		_ = r.ParseForm()
+		if r.Form.Get("client_secret") != "sp-secret" || r.Form.Get("scope") != vaultResource+"/.default" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error_description":"invalid client secret"}`))
+			return
+		}
+		f.logins++
+		_, _ = w.Write([]byte(`{"access_token":"kv-token","expires_in":3600}`))
+		return
+	}
+
+	f.lastAuth = r.Header.Get("Authorization")
+	if f.lastAuth != "Bearer kv-token" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "", "AKV10000: Request is missing a Bearer token")
+		return
+	}
+	if r.URL.Query().Get("api-version") != apiVersion {
+		writeError(w, http.StatusBadRequest, "BadParameter", "", "missing api-version")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "secrets" && r.Method == "GET":
+		var items []map[string]any
+		for name, s := range f.secrets {
+			items = append(items, map[string]any{
+				"id":         f.srv.URL + "/secrets/" + name,
+				"tags":       s.Tags,
+				"attributes": map[string]bool{"enabled": true},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"value": items})
+
+	case len(parts) == 2 && parts[0] == "secrets":
+		name := parts[1]
+		switch r.Method {
+		case "GET":
+			s, ok := f.secrets[name]
+			if !ok {
+				writeError(w, http.StatusNotFound, "SecretNotFound", "", "not found")
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"value": s.Value})
+		case "PUT":
+			if _, ok := f.deleted[name]; ok {
+				writeError(w, http.StatusConflict, "Conflict", "ObjectIsDeletedButRecoverable", "Secret is currently in a deleted but recoverable state")
+				return
+			}
+			var body fakeSecret
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			f.secrets[name] = body
+			_, _ = w.Write([]byte(`{}`))
+		case "DELETE":
+			s, ok := f.secrets[name]
+			if !ok {
+				writeError(w, http.StatusNotFound, "SecretNotFound", "", "not found")
+				return
+			}
+			delete(f.secrets, name)
+			f.deleted[name] = s
+			_, _ = w.Write([]byte(`{}`))
+		}
+
+	case len(parts) >= 2 && parts[0] == "deletedsecrets":
+		name := parts[1]
+		s, ok := f.deleted[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "SecretNotFound", "", "not found")
+			return
+		}
+		switch {
+		case len(parts) == 3 && parts[2] == "recover" && r.Method == "POST":
+			delete(f.deleted, name)
+			f.secrets[name] = s
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == "GET":
+			if f.pending > 0 {
+				f.pending--
+				writeError(w, http.StatusNotFound, "SecretNotFound", "", "not found")
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == "DELETE":
+			delete(f.deleted, name)
+			f.purged = append(f.purged, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", "", "no route")
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeKeyVault, config map[string]any) *Provider {
+	t.Helper()
+	t.Setenv("AZURE_AUTHORITY_HOST", f.srv.URL)
+	config["tenant_id"] = "tenant"
+	config["client_id"] = "client"
+	config["client_secret"] = "sp-secret"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return prov.(*Provider)
+}
+
+func TestNew_MissingVaultURL(t *testing.T) {
+	if _, err := New(map[string]any{}); err == nil {
+		t.Error("expected error for missing vault_url")
+	}
+
+	_, err := New(map[string]any{
+		"environments": map[string]map[string]string{
+			"production": {"prefix": "prod-"},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for environment without vault_url")
+	}
+}
+
+func TestSecretName(t *testing.T) {
+	tests := []struct {
+		prefix, name, want string
+		wantErr            bool
+	}{
+		{"", "DATABASE_URL", "DATABASE-URL", false},
+		{"test_", "API_KEY", "test-API-KEY", false},
+		{"", "has.dot", "", true},
+		{"", strings.Repeat("A", 128), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := SecretName(tt.prefix, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SecretName(%q, %q) error = %v, wantErr %v", tt.prefix, tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SecretName(%q, %q) = %q, want %q", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProvider_SetAndList(t *testing.T) {
+	f := newFakeKeyVault(t)
+	p := newTestProvider(t, f, map[string]any{"vault_url": f.srv.URL, "prefix": "test-"})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "DATABASE_URL", "postgres://x", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.secrets["test-DATABASE-URL"].Value != "postgres://x" {
+		t.Errorf("stored secrets = %v", f.secrets)
+	}
+
+	// Secrets outside the prefix and untagged secrets
+	f.secrets["other-KEY"] = fakeSecret{Value: "x"}
+	f.secrets["test-LEGACY-KEY"] = fakeSecret{Value: "legacy"}
+
+	secrets, err := p.List(ctx, "default")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	if len(got) != 2 || got["DATABASE_URL"] != "postgres://x" || got["LEGACY_KEY"] != "legacy" {
+		t.Errorf("List() = %v", got)
+	}
+
+	// The token is fetched once and cached
+	if f.logins != 1 {
+		t.Errorf("logins = %d, want 1", f.logins)
+	}
+}
+
+func TestProvider_PerEnvironmentVault(t *testing.T) {
+	f := newFakeKeyVault(t)
+	other := newFakeKeyVault(t)
+	p := newTestProvider(t, f, map[string]any{
+		"environments": map[string]map[string]string{
+			"staging":    {"vault_url": f.srv.URL, "prefix": "staging-"},
+			"production": {"vault_url": other.srv.URL},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "stg", "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "prd", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if f.secrets["staging-API-KEY"].Value != "stg" {
+		t.Errorf("staging vault = %v", f.secrets)
+	}
+	if other.secrets["API-KEY"].Value != "prd" {
+		t.Errorf("production vault = %v", other.secrets)
+	}
+}
+
+func TestProvider_SetRecoversDeleted(t *testing.T) {
+	f := newFakeKeyVault(t)
+	p := newTestProvider(t, f, map[string]any{"vault_url": f.srv.URL})
+	ctx := context.Background()
+
+	f.deleted["API-KEY"] = fakeSecret{Value: "old"}
+
+	if err := p.Set(ctx, "API_KEY", "new", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.secrets["API-KEY"].Value != "new" {
+		t.Errorf("secret = %+v, want recovered and updated", f.secrets["API-KEY"])
+	}
+	if len(f.deleted) != 0 {
+		t.Errorf("deleted = %v, want empty", f.deleted)
+	}
+}
+
+func TestProvider_DeleteAndPurge(t *testing.T) {
+	f := newFakeKeyVault(t)
+	ctx := context.Background()
+
+	// Soft delete only
+	p := newTestProvider(t, f, map[string]any{"vault_url": f.srv.URL})
+	f.secrets["A-KEY"] = fakeSecret{Value: "a"}
+	if err := p.Delete(ctx, "A_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.deleted["A-KEY"]; !ok {
+		t.Error("A-KEY should be soft-deleted")
+	}
+
+	// Purge waits for the deleted secret to appear
+	p = newTestProvider(t, f, map[string]any{"vault_url": f.srv.URL, "purge_on_delete": true})
+	f.secrets["B-KEY"] = fakeSecret{Value: "b"}
+	f.pending = 2
+	if err := p.Delete(ctx, "B_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(f.purged) != 1 || f.purged[0] != "B-KEY" {
+		t.Errorf("purged = %v, want [B-KEY]", f.purged)
+	}
+
+	// Deleting a missing secret is not an error
+	if err := p.Delete(ctx, "MISSING", "default"); err != nil {
+		t.Errorf("Delete of missing secret failed: %v", err)
+	}
+}
+
+func TestProvider_BadCredentials(t *testing.T) {
+	f := newFakeKeyVault(t)
+	p := newTestProvider(t, f, map[string]any{"vault_url": f.srv.URL})
+	p.tokens.(*clientCredentials).clientSecret = "wrong"
+
+	err := p.Validate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid client secret") {
+		t.Errorf("Validate() error = %v, want login failure", err)
+	}
+}
@@ -0,0 +1,289 @@
+package azurekv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const apiVersion = "7.4"
+
+// Deleted secrets take a few seconds to move in and out of the recycle bin
+var (
+	pollInterval = 2 * time.Second
+	pollAttempts = 15
+)
+
+// Client handles Key Vault data-plane requests for one vault
+type Client struct {
+	vaultURL string
+	tokens   tokenSource
+	http     *http.Client
+}
+
+// NewClient creates a new Key Vault API client
+func NewClient(vaultURL string, tokens tokenSource) *Client {
+	return &Client{
+		vaultURL: strings.TrimRight(vaultURL, "/"),
+		tokens:   tokens,
+		http:     &http.Client{},
+	}
+}
+
+// SecretItem is a secret's metadata as returned by the list endpoint
+type SecretItem struct {
+	ID         string            `json:"id"`
+	Tags       map[string]string `json:"tags"`
+	Attributes struct {
+		Enabled bool `json:"enabled"`
+	} `json:"attributes"`
+}
+
+// Name returns the secret name from its ID (https://vault/secrets/NAME)
+func (s SecretItem) Name() string {
+	return s.ID[strings.LastIndex(s.ID, "/")+1:]
+}
+
+// apiError is a Key Vault error response
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("azure-keyvault API error: %s (%s)", e.Message, e.Code)
+	}
+	return fmt.Sprintf("azure-keyvault API error: status %d, body: %s", e.Status, e.Message)
+}
+
+// ListSecrets returns metadata for every secret in the vault
+func (c *Client) ListSecrets(ctx context.Context) ([]SecretItem, error) {
+	var items []SecretItem
+	next := c.endpoint("secrets") + "&maxresults=25"
+
+	for next != "" {
+		resp, err := c.doRequest(ctx, "GET", next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := c.parseError(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var page struct {
+			Value    []SecretItem `json:"value"`
+			NextLink string       `json:"nextLink"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		items = append(items, page.Value...)
+		next = page.NextLink
+	}
+
+	return items, nil
+}
+
+// GetSecret returns the current value of a secret
+func (c *Client) GetSecret(ctx context.Context, name string) (string, error) {
+	resp, err := c.doRequest(ctx, "GET", c.endpoint("secrets", name), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.parseError(resp)
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Value, nil
+}
+
+// SetSecret writes a new version of a secret. A soft-deleted secret with the
+// same name is recovered first, since Key Vault refuses to overwrite it.
+func (c *Client) SetSecret(ctx context.Context, name, value string, tags map[string]string) error {
+	err := c.putSecret(ctx, name, value, tags)
+	if apiErr, ok := err.(*apiError); ok && apiErr.Code == "ObjectIsDeletedButRecoverable" {
+		if err := c.recoverDeleted(ctx, name); err != nil {
+			return err
+		}
+		return c.putSecret(ctx, name, value, tags)
+	}
+	return err
+}
+
+func (c *Client) putSecret(ctx context.Context, name, value string, tags map[string]string) error {
+	body, err := json.Marshal(map[string]any{
+		"value": value,
+		"tags":  tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", c.endpoint("secrets", name), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// recoverDeleted restores a soft-deleted secret and waits until it is usable
+func (c *Client) recoverDeleted(ctx context.Context, name string) error {
+	resp, err := c.doRequest(ctx, "POST", c.endpoint("deletedsecrets", name, "recover"), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+
+	return c.poll(ctx, c.endpoint("secrets", name))
+}
+
+// DeleteSecret soft-deletes a secret. With purge, the deleted secret is also
+// removed from the recycle bin so the name can be reused immediately.
+func (c *Client) DeleteSecret(ctx context.Context, name string, purge bool) error {
+	resp, err := c.doRequest(ctx, "DELETE", c.endpoint("secrets", name), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if !purge {
+		return nil
+	}
+
+	// The deleted secret appears in the recycle bin asynchronously
+	deletedURL := c.endpoint("deletedsecrets", name)
+	if err := c.poll(ctx, deletedURL); err != nil {
+		return err
+	}
+
+	purgeResp, err := c.doRequest(ctx, "DELETE", deletedURL, nil)
+	if err != nil {
+		return err
+	}
+	defer purgeResp.Body.Close()
+
+	if purgeResp.StatusCode != http.StatusNoContent && purgeResp.StatusCode != http.StatusOK {
+		return c.parseError(purgeResp)
+	}
+	return nil
+}
+
+// poll waits until a GET on u stops returning 404
+func (c *Client) poll(ctx context.Context, u string) error {
+	for attempt := 0; attempt < pollAttempts; attempt++ {
+		resp, err := c.doRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return err
+		}
+		status := resp.StatusCode
+		if status != http.StatusNotFound && status != http.StatusOK {
+			err := c.parseError(resp)
+			resp.Body.Close()
+			return err
+		}
+		resp.Body.Close()
+		if status == http.StatusOK {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	return fmt.Errorf("azure-keyvault: timed out waiting for %s", u)
+}
+
+// endpoint builds a vault URL from path segments, e.g. ("deletedsecrets", name, "recover")
+func (c *Client) endpoint(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf("%s/%s?api-version=%s", c.vaultURL, strings.Join(escaped, "/"), apiVersion)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Error struct {
+			Code       string `json:"code"`
+			Message    string `json:"message"`
+			InnerError struct {
+				Code string `json:"code"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		code := errResp.Error.Code
+		if errResp.Error.InnerError.Code != "" {
+			code = errResp.Error.InnerError.Code
+		}
+		return &apiError{Status: resp.StatusCode, Code: code, Message: errResp.Error.Message}
+	}
+
+	return &apiError{Status: resp.StatusCode, Message: string(body)}
+}
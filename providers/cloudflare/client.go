@@ -0,0 +1,239 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultBaseURL = "https://api.cloudflare.com/client/v4"
+
+// Client handles Cloudflare API requests for one account
+type Client struct {
+	baseURL   string
+	token     string
+	accountID string
+	http      *http.Client
+}
+
+// NewClient creates a new Cloudflare API client
+func NewClient(token, accountID string) *Client {
+	return &Client{
+		baseURL:   defaultBaseURL,
+		token:     token,
+		accountID: accountID,
+		http:      &http.Client{},
+	}
+}
+
+// envelope is the wrapper around every Cloudflare API response
+type envelope struct {
+	Success bool            `json:"success"`
+	Errors  []apiMessage    `json:"errors"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type apiMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Binding is a Worker binding; secrets have type secret_text
+type Binding struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// EnvVar is a Pages environment variable. Values of secret_text variables
+// are not returned by the API.
+type EnvVar struct {
+	Type  string `json:"type"` // plain_text or secret_text
+	Value string `json:"value,omitempty"`
+}
+
+// ListWorkerSecrets returns the names of a Worker's secrets
+func (c *Client) ListWorkerSecrets(ctx context.Context, script string) ([]string, error) {
+	var bindings []Binding
+	if err := c.do(ctx, "GET", c.scriptPath(script)+"/secrets", nil, &bindings); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		names = append(names, b.Name)
+	}
+	return names, nil
+}
+
+// PutWorkerSecret creates or updates one Worker secret
+func (c *Client) PutWorkerSecret(ctx context.Context, script, name, value string) error {
+	return c.do(ctx, "PUT", c.scriptPath(script)+"/secrets", Binding{Name: name, Type: "secret_text", Text: value}, nil)
+}
+
+// DeleteWorkerSecret deletes one Worker secret
+func (c *Client) DeleteWorkerSecret(ctx context.Context, script, name string) error {
+	return c.do(ctx, "DELETE", c.scriptPath(script)+"/secrets/"+url.PathEscape(name), nil, nil)
+}
+
+// PutWorkerSecrets writes several Worker secrets in one settings update,
+// like wrangler secret bulk. Bindings that aren't being written are kept.
+func (c *Client) PutWorkerSecrets(ctx context.Context, script string, values map[string]string) error {
+	var settings struct {
+		Bindings []Binding `json:"bindings"`
+	}
+	if err := c.do(ctx, "GET", c.scriptPath(script)+"/settings", nil, &settings); err != nil {
+		return err
+	}
+
+	var bindings []Binding
+	for _, b := range settings.Bindings {
+		if _, ok := values[b.Name]; !ok {
+			bindings = append(bindings, Binding{Name: b.Name, Type: "inherit"})
+		}
+	}
+	for name, value := range values {
+		bindings = append(bindings, Binding{Name: name, Type: "secret_text", Text: value})
+	}
+
+	payload, err := json.Marshal(map[string]any{"bindings": bindings})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// The settings endpoint only takes multipart form data
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("settings", string(payload)); err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PATCH", c.scriptPath(script)+"/settings", form.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.decode(resp, nil)
+}
+
+// GetPagesEnvVars returns a Pages project's variables for one deployment
+// environment (production or preview)
+func (c *Client) GetPagesEnvVars(ctx context.Context, project, environment string) (map[string]EnvVar, error) {
+	var result struct {
+		DeploymentConfigs map[string]struct {
+			EnvVars map[string]*EnvVar `json:"env_vars"`
+		} `json:"deployment_configs"`
+	}
+	if err := c.do(ctx, "GET", c.pagesPath(project), nil, &result); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]EnvVar)
+	for name, v := range result.DeploymentConfigs[environment].EnvVars {
+		if v != nil {
+			vars[name] = *v
+		}
+	}
+	return vars, nil
+}
+
+// PatchPagesEnvVars sets variables on a Pages deployment environment. A nil
+// entry removes the variable.
+func (c *Client) PatchPagesEnvVars(ctx context.Context, project, environment string, vars map[string]*EnvVar) error {
+	body := map[string]any{
+		"deployment_configs": map[string]any{
+			environment: map[string]any{"env_vars": vars},
+		},
+	}
+	return c.do(ctx, "PATCH", c.pagesPath(project), body, nil)
+}
+
+// VerifyToken checks that the API token is valid and active
+func (c *Client) VerifyToken(ctx context.Context) error {
+	return c.do(ctx, "GET", "/user/tokens/verify", nil, nil)
+}
+
+func (c *Client) scriptPath(script string) string {
+	return "/accounts/" + c.accountID + "/workers/scripts/" + url.PathEscape(script)
+}
+
+func (c *Client) pagesPath(project string) string {
+	return "/accounts/" + c.accountID + "/pages/projects/" + url.PathEscape(project)
+}
+
+// do sends a JSON request and decodes the envelope's result into out
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, "application/json", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.decode(resp, out)
+}
+
+func (c *Client) decode(resp *http.Response, out any) error {
+	data, _ := io.ReadAll(resp.Body)
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("cloudflare API error: status %d, body: %s", resp.StatusCode, string(data))
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !env.Success || resp.StatusCode >= 300 {
+		if len(env.Errors) > 0 {
+			msgs := make([]string, len(env.Errors))
+			for i, e := range env.Errors {
+				msgs[i] = fmt.Sprintf("%s (%d)", e.Message, e.Code)
+			}
+			return fmt.Errorf("cloudflare API error: %s", strings.Join(msgs, "; "))
+		}
+		return fmt.Errorf("cloudflare API error: status %d, body: %s", resp.StatusCode, string(data))
+	}
+
+	if out != nil && len(env.Result) > 0 {
+		if err := json.Unmarshal(env.Result, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint, contentType string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return c.http.Do(req)
+}
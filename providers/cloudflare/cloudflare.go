@@ -0,0 +1,195 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "cloudflare",
+		DisplayName: "Cloudflare",
+		Factory:     New,
+		EnvVar:      "CLOUDFLARE_API_TOKEN",
+		Beta:        true,
+	})
+}
+
+// Provider implements the Cloudflare provider. With script set it manages
+// Worker secrets; with project set it manages Pages environment variables.
+type Provider struct {
+	client  *Client
+	config  map[string]any
+	script  string
+	project string
+}
+
+// New creates a new Cloudflare provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("cloudflare: API token is required")
+	}
+
+	accountID, _ := config["account_id"].(string)
+	if accountID == "" {
+		accountID = os.Getenv("CLOUDFLARE_ACCOUNT_ID")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("cloudflare: account_id is required (or set CLOUDFLARE_ACCOUNT_ID)")
+	}
+
+	script, _ := config["script"].(string)
+	project, _ := config["project"].(string)
+	switch {
+	case script != "" && project != "":
+		return nil, fmt.Errorf("cloudflare: set either script (Workers) or project (Pages), not both")
+	case script == "" && project == "":
+		return nil, fmt.Errorf("cloudflare: script (Workers) or project (Pages) is required")
+	}
+
+	return &Provider{
+		client:  NewClient(token, accountID),
+		config:  config,
+		script:  script,
+		project: project,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "cloudflare" }
+func (p *Provider) DisplayName() string { return "Cloudflare" }
+
+// Environments returns Pages deployment environments, or for Workers
+// "default" plus any configured environments (each with its own script)
+func (p *Provider) Environments() []string {
+	if p.project != "" {
+		return []string{"preview", "production"}
+	}
+	return append([]string{"default"}, provider.ConfiguredEnvironments(p.config)...)
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	if p.project != "" {
+		return map[string]string{
+			"preview":    "test",
+			"production": "live",
+		}
+	}
+	return map[string]string{
+		"default": "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	return p.client.VerifyToken(ctx)
+}
+
+// workerScript returns the Worker script for an environment, e.g. a
+// wrangler environment deployed as my-worker-staging
+func (p *Provider) workerScript(environment string) string {
+	return provider.EnvSetting(p.config, environment, "script")
+}
+
+// pagesEnvironment validates a Pages deployment environment name
+func (p *Provider) pagesEnvironment(environment string) (string, error) {
+	if environment != "production" && environment != "preview" {
+		return "", fmt.Errorf("cloudflare: Pages environment must be production or preview, got %q", environment)
+	}
+	return environment, nil
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	var result []model.SecretValue
+
+	if p.script != "" {
+		names, err := p.client.ListWorkerSecrets(ctx, p.workerScript(environment))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			result = append(result, model.SecretValue{
+				Name:        name,
+				Environment: environment,
+				Unreadable:  true, // Worker secrets can't be read back
+			})
+		}
+		return result, nil
+	}
+
+	env, err := p.pagesEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := p.client.GetPagesEnvVars(ctx, p.project, env)
+	if err != nil {
+		return nil, err
+	}
+	for name, v := range vars {
+		// secret_text values can't be read back. A plain value for a
+		// sensitive key is reported the same way, so a sync stores it as a
+		// secret.
+		if v.Type != "plain_text" || p.sensitive(name) {
+			result = append(result, model.SecretValue{Name: name, Environment: environment, Unreadable: true})
+			continue
+		}
+		result = append(result, model.SecretValue{
+			Name:        name,
+			Value:       v.Value,
+			Environment: environment,
+		})
+	}
+	return result, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	if p.script != "" {
+		return p.client.PutWorkerSecret(ctx, p.workerScript(environment), name, value)
+	}
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes Worker secrets in one bulk update, or Pages variables in
+// one project update. Pages keys marked sensitive: false are stored as
+// plain text.
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	if p.script != "" {
+		return p.client.PutWorkerSecrets(ctx, p.workerScript(environment), values)
+	}
+
+	env, err := p.pagesEnvironment(environment)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]*EnvVar, len(values))
+	for name, value := range values {
+		kind := "secret_text"
+		if !p.sensitive(name) {
+			kind = "plain_text"
+		}
+		vars[name] = &EnvVar{Type: kind, Value: value}
+	}
+	return p.client.PatchPagesEnvVars(ctx, p.project, env, vars)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	if p.script != "" {
+		return p.client.DeleteWorkerSecret(ctx, p.workerScript(environment), name)
+	}
+
+	env, err := p.pagesEnvironment(environment)
+	if err != nil {
+		return err
+	}
+	return p.client.PatchPagesEnvVars(ctx, p.project, env, map[string]*EnvVar{name: nil})
+}
+
+func (p *Provider) sensitive(name string) bool {
+	return provider.SecretSchema(p.config, name).IsSensitive()
+}
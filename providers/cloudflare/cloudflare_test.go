@@ -0,0 +1,366 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// fakeCloudflare is an in-memory stand-in for the Workers and Pages APIs
+type fakeCloudflare struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	bindings map[string][]Binding         // script -> bindings
+	pages    map[string]map[string]EnvVar // deployment environment -> vars
+	requests []string
+}
+
+func newFakeCloudflare(t *testing.T) *fakeCloudflare {
+	f := &fakeCloudflare{
+		bindings: make(map[string][]Binding),
+		pages:    map[string]map[string]EnvVar{"production": {}, "preview": {}},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func reply(w http.ResponseWriter, result any) {
+	_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "errors": []any{}, "result": result})
+}
+
+func fail(w http.ResponseWriter, status, code int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": false,
+		"errors":  []map[string]any{{"code": code, "message": message}},
+	})
+}
+
+func (f *fakeCloudflare) setBinding(script string, b Binding) {
+	for i, existing := range f.bindings[script] {
+		if existing.Name == b.Name {
+			f.bindings[script][i] = b
+			return
+		}
+	}
+	f.bindings[script] = append(f.bindings[script], b)
+}
+
+func (f *fakeCloudflare) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer cf-token" {
+		fail(w, http.StatusForbidden, 10000, "Authentication error")
+		return
+	}
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	path := r.URL.Path
+	if path == "/user/tokens/verify" {
+		reply(w, map[string]string{"status": "active"})
+		return
+	}
+
+	const prefix = "/accounts/acct-1/"
+	if !strings.HasPrefix(path, prefix) {
+		fail(w, http.StatusNotFound, 7003, "Could not route")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+
+	switch {
+	case len(parts) >= 4 && parts[0] == "workers" && parts[1] == "scripts" && parts[3] == "secrets":
+		script := parts[2]
+		switch {
+		case r.Method == "GET":
+			var secrets []Binding
+			for _, b := range f.bindings[script] {
+				if b.Type == "secret_text" {
+					secrets = append(secrets, Binding{Name: b.Name, Type: b.Type})
+				}
+			}
+			reply(w, secrets)
+		case r.Method == "PUT":
+			var b Binding
+			_ = json.NewDecoder(r.Body).Decode(&b)
+			f.setBinding(script, b)
+			reply(w, map[string]string{"name": b.Name, "type": b.Type})
+		case r.Method == "DELETE" && len(parts) == 5:
+			kept := f.bindings[script][:0]
+			found := false
+			for _, b := range f.bindings[script] {
+				if b.Name == parts[4] {
+					found = true
+					continue
+				}
+				kept = append(kept, b)
+			}
+			if !found {
+				fail(w, http.StatusNotFound, 10056, "Secret not found")
+				return
+			}
+			f.bindings[script] = kept
+			reply(w, nil)
+		}
+
+	case len(parts) == 4 && parts[0] == "workers" && parts[3] == "settings":
+		script := parts[2]
+		if r.Method == "GET" {
+			reply(w, map[string]any{"bindings": f.bindings[script]})
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			fail(w, http.StatusBadRequest, 10021, "expected multipart form")
+			return
+		}
+		var settings struct {
+			Bindings []Binding `json:"bindings"`
+		}
+		_ = json.Unmarshal([]byte(r.FormValue("settings")), &settings)
+
+		// Bindings not listed are removed; inherit keeps the existing one
+		old := f.bindings[script]
+		var next []Binding
+		for _, b := range settings.Bindings {
+			if b.Type == "inherit" {
+				for _, o := range old {
+					if o.Name == b.Name {
+						next = append(next, o)
+					}
+				}
+				continue
+			}
+			next = append(next, b)
+		}
+		f.bindings[script] = next
+		reply(w, map[string]any{"bindings": next})
+
+	case len(parts) == 3 && parts[0] == "pages" && parts[1] == "projects" && parts[2] == "site":
+		switch r.Method {
+		case "GET":
+			configs := make(map[string]any)
+			for env, vars := range f.pages {
+				out := make(map[string]EnvVar)
+				for name, v := range vars {
+					if v.Type == "secret_text" {
+						v.Value = ""
+					}
+					out[name] = v
+				}
+				configs[env] = map[string]any{"env_vars": out}
+			}
+			reply(w, map[string]any{"name": "site", "deployment_configs": configs})
+		case "PATCH":
+			var body struct {
+				DeploymentConfigs map[string]struct {
+					EnvVars map[string]*EnvVar `json:"env_vars"`
+				} `json:"deployment_configs"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for env, cfg := range body.DeploymentConfigs {
+				for name, v := range cfg.EnvVars {
+					if v == nil {
+						delete(f.pages[env], name)
+					} else {
+						f.pages[env][name] = *v
+					}
+				}
+			}
+			reply(w, map[string]any{"name": "site"})
+		}
+
+	default:
+		fail(w, http.StatusNotFound, 7003, "Could not route")
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeCloudflare, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "cf-token"
+	config["account_id"] = "acct-1"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func TestNew_Validation(t *testing.T) {
+	t.Setenv("CLOUDFLARE_ACCOUNT_ID", "")
+	if _, err := New(map[string]any{"token": "x", "script": "w"}); err == nil {
+		t.Error("expected error for missing account_id")
+	}
+	if _, err := New(map[string]any{"token": "x", "account_id": "a"}); err == nil {
+		t.Error("expected error for missing script and project")
+	}
+	if _, err := New(map[string]any{"token": "x", "account_id": "a", "script": "w", "project": "p"}); err == nil {
+		t.Error("expected error for both script and project")
+	}
+
+	t.Setenv("CLOUDFLARE_ACCOUNT_ID", "from-env")
+	prov, err := New(map[string]any{"token": "x", "script": "w"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if prov.(*Provider).client.accountID != "from-env" {
+		t.Error("account ID should fall back to CLOUDFLARE_ACCOUNT_ID")
+	}
+}
+
+func TestProvider_WorkerSecrets(t *testing.T) {
+	f := newFakeCloudflare(t)
+	p := newTestProvider(t, f, map[string]any{
+		"script": "api",
+		"environments": map[string]map[string]string{
+			"staging": {"script": "api-staging"},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_123", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_stg", "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.bindings["api"][0].Text != "sk_123" || f.bindings["api-staging"][0].Text != "sk_stg" {
+		t.Errorf("bindings = %+v", f.bindings)
+	}
+
+	list, err := p.List(ctx, "default")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "API_KEY" || list[0].Value != "" || !list[0].Unreadable {
+		t.Errorf("List() = %+v", list)
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(f.bindings["api"]) != 0 {
+		t.Errorf("bindings after delete = %+v", f.bindings["api"])
+	}
+}
+
+func TestProvider_WorkerBulk(t *testing.T) {
+	f := newFakeCloudflare(t)
+	f.bindings["api"] = []Binding{
+		{Name: "KV", Type: "kv_namespace"},
+		{Name: "OLD_SECRET", Type: "secret_text", Text: "keep"},
+		{Name: "API_KEY", Type: "secret_text", Text: "old"},
+	}
+	p := newTestProvider(t, f, map[string]any{"script": "api"})
+
+	err := p.SetMany(context.Background(), map[string]string{"API_KEY": "new", "DB_URL": "postgres://x"}, "default")
+	if err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+
+	got := make(map[string]Binding)
+	for _, b := range f.bindings["api"] {
+		got[b.Name] = b
+	}
+	if len(got) != 4 || got["KV"].Type != "kv_namespace" || got["OLD_SECRET"].Text != "keep" {
+		t.Errorf("existing bindings not kept: %+v", f.bindings["api"])
+	}
+	if got["API_KEY"].Text != "new" || got["DB_URL"].Text != "postgres://x" {
+		t.Errorf("bulk secrets = %+v", got)
+	}
+
+	patches := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, "PATCH") {
+			patches++
+		}
+	}
+	if patches != 1 {
+		t.Errorf("requests = %v, want one PATCH", f.requests)
+	}
+}
+
+func TestProvider_PagesEnvVars(t *testing.T) {
+	f := newFakeCloudflare(t)
+	plain := false
+	p := newTestProvider(t, f, map[string]any{
+		"project": "site",
+		"_schema": map[string]model.Secret{
+			"PUBLIC_URL": {Sensitive: &plain},
+		},
+	})
+	ctx := context.Background()
+
+	err := p.SetMany(ctx, map[string]string{"API_KEY": "sk_live", "PUBLIC_URL": "https://example.com"}, "production")
+	if err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if v := f.pages["production"]["API_KEY"]; v.Type != "secret_text" || v.Value != "sk_live" {
+		t.Errorf("API_KEY = %+v", v)
+	}
+	if v := f.pages["production"]["PUBLIC_URL"]; v.Type != "plain_text" {
+		t.Errorf("PUBLIC_URL = %+v, want plain_text", v)
+	}
+	if len(f.pages["preview"]) != 0 {
+		t.Errorf("preview = %+v, want untouched", f.pages["preview"])
+	}
+
+	list, err := p.List(ctx, "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	// Only plain text values are read back
+	got := make(map[string]string)
+	for _, s := range list {
+		if s.Unreadable != (s.Name == "API_KEY") {
+			t.Errorf("%s: Unreadable = %v", s.Name, s.Unreadable)
+		}
+		got[s.Name] = s.Value
+	}
+	if len(got) != 2 || got["API_KEY"] != "" || got["PUBLIC_URL"] != "https://example.com" {
+		t.Errorf("List() = %v", got)
+	}
+
+	// A plain value for a key that is now sensitive must be rewritten
+	plain = true
+	list, _ = p.List(ctx, "production")
+	for _, s := range list {
+		if !s.Unreadable {
+			t.Errorf("%s: Unreadable = false after PUBLIC_URL became sensitive", s.Name)
+		}
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.pages["production"]["API_KEY"]; ok {
+		t.Error("API_KEY should be deleted")
+	}
+
+	if err := p.Set(ctx, "X", "y", "staging"); err == nil {
+		t.Error("expected error for unknown Pages environment")
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	f := newFakeCloudflare(t)
+	p := newTestProvider(t, f, map[string]any{"script": "api"})
+	p.client.token = "wrong"
+
+	err := p.Validate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Authentication error (10000)") {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
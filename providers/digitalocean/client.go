@@ -0,0 +1,128 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const defaultBaseURL = "https://api.digitalocean.com/v2"
+
+// errConflict is returned when an app spec changed between read and write
+var errConflict = errors.New("digitalocean: app spec was modified concurrently")
+
+// Client handles DigitalOcean API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new DigitalOcean API client
+func NewClient(token string) *Client {
+	return &Client{
+		baseURL: defaultBaseURL,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// App is an App Platform app. The spec is kept as decoded JSON so that
+// fields dotenvy doesn't know about survive a round trip.
+type App struct {
+	ID        string         `json:"id"`
+	UpdatedAt string         `json:"updated_at"`
+	Spec      map[string]any `json:"spec"`
+}
+
+// GetApp returns an app with its current spec
+func (c *Client) GetApp(ctx context.Context, appID string) (*App, error) {
+	var result struct {
+		App App `json:"app"`
+	}
+	if err := c.do(ctx, "GET", "/apps/"+url.PathEscape(appID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.App, nil
+}
+
+// UpdateApp replaces an app's spec, which starts a new deployment. It
+// returns errConflict if the app was updated since it was read. The API has
+// no precondition for this, so the check is a separate request: it narrows
+// the window for overwriting a concurrent update but can't close it.
+func (c *Client) UpdateApp(ctx context.Context, app *App) error {
+	current, err := c.GetApp(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	if current.UpdatedAt != app.UpdatedAt {
+		return errConflict
+	}
+	return c.do(ctx, "PUT", "/apps/"+url.PathEscape(app.ID), map[string]any{"spec": app.Spec}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Message != "" {
+			return fmt.Errorf("digitalocean API error: %s", errResp.Message)
+		}
+	}
+
+	return fmt.Errorf("digitalocean API error: status %d, body: %s", resp.StatusCode, string(body))
+}
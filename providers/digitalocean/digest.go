@@ -0,0 +1,69 @@
+package digitalocean
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// digestsKey is the var that holds a digest of each SECRET value dotenvy
+// wrote, since App Platform only returns them encrypted. It is only set at
+// build time, and only when a digest key is configured.
+const digestsKey = "DOTENVY_DIGESTS"
+
+// digestKeyEnv is the env var that holds the digest key if the config
+// doesn't set digest_key
+const digestKeyEnv = "DOTENVY_DIGEST_KEY"
+
+const digestsScope = "BUILD_TIME"
+
+// newDigest returns a salted HMAC-SHA256 of a value as salt:mac in hex. The
+// key never leaves the machine, so the digests var can't be used to guess
+// low-entropy values; the salt keeps equal values from having equal digests.
+func newDigest(key []byte, value string) string {
+	salt := make([]byte, 8)
+	_, _ = rand.Read(salt)
+	return hex.EncodeToString(salt) + ":" + hashValue(key, salt, value)
+}
+
+// matchesDigest reports whether value has the digest under key
+func matchesDigest(key []byte, value, digest string) bool {
+	saltHex, hash, ok := strings.Cut(digest, ":")
+	salt, err := hex.DecodeString(saltHex)
+	if !ok || err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashValue(key, salt, value)), []byte(hash)) == 1
+}
+
+func hashValue(key, salt []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseDigests decodes the digests var: KEY=digest pairs separated by commas
+func parseDigests(s string) map[string]string {
+	digests := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if key, digest, ok := strings.Cut(pair, "="); ok {
+			digests[key] = digest
+		}
+	}
+	return digests
+}
+
+// formatDigests encodes digests for the digests var, sorted by key
+func formatDigests(digests map[string]string) string {
+	pairs := make([]string, 0, len(digests))
+	for key, digest := range digests {
+		pairs = append(pairs, key+"="+digest)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,259 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "digitalocean",
+		DisplayName: "DigitalOcean App Platform",
+		Factory:     New,
+		EnvVar:      "DIGITALOCEAN_TOKEN",
+		Beta:        true,
+	})
+}
+
+// Provider implements the DigitalOcean App Platform provider. Each remote
+// environment is an app, and optionally one component within it; vars are
+// edited in the app spec. SECRET values are encrypted, so with a digest key
+// they are compared by a keyed digest dotenvy stores next to them; without
+// one they show as unknown.
+type Provider struct {
+	client    *Client
+	config    map[string]any
+	digestKey []byte
+}
+
+// New creates a new DigitalOcean provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("digitalocean: API token is required")
+	}
+
+	p := &Provider{
+		client:    NewClient(token),
+		config:    config,
+		digestKey: []byte(provider.ConfigOrEnv(config, "digest_key", digestKeyEnv)),
+	}
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "app_id") == "" {
+			return nil, fmt.Errorf("digitalocean: app_id is required for environment %s", env)
+		}
+	}
+	return p, nil
+}
+
+func (p *Provider) Name() string        { return "digitalocean" }
+func (p *Provider) DisplayName() string { return "DigitalOcean App Platform" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"production"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"staging":    "test",
+		"production": "live",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	for _, env := range p.Environments() {
+		if _, _, err := p.envs(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envs returns the app and the vars of an environment's app or component
+func (p *Provider) envs(ctx context.Context, environment string) (*App, []EnvVar, error) {
+	app, err := p.client.GetApp(ctx, provider.EnvSetting(p.config, environment, "app_id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	holder, err := envHolder(app.Spec, provider.EnvSetting(p.config, environment, "component"))
+	if err != nil {
+		return nil, nil, err
+	}
+	envs, err := getEnvs(holder)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, envs, nil
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	_, envs, err := p.envs(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := digestsOf(envs)
+	var secrets []model.SecretValue
+	for _, e := range envs {
+		if e.Key == digestsKey {
+			continue
+		}
+		s := model.SecretValue{Name: e.Key, Value: e.Value, Environment: environment}
+		if e.IsSecret() {
+			// Encrypted; the plaintext can't be read back
+			s.Value, s.Unreadable = "", true
+			if len(p.digestKey) > 0 {
+				s.Digest = digests[e.Key]
+			}
+		}
+		secrets = append(secrets, s)
+	}
+
+	return secrets, nil
+}
+
+// MatchesDigest reports whether value is the one a SECRET var was written
+// with
+func (p *Provider) MatchesDigest(value, digest string) bool {
+	return len(p.digestKey) > 0 && matchesDigest(p.digestKey, value, digest)
+}
+
+// digestsOf returns the digests stored among envs
+func digestsOf(envs []EnvVar) map[string]string {
+	for _, e := range envs {
+		if e.Key == digestsKey {
+			return parseDigests(e.Value)
+		}
+	}
+	return make(map[string]string)
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one spec update, so the app redeploys once
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// update applies changes to the environment's vars with a spec
+// read-modify-write. A nil value deletes the var. Nothing is written if the
+// spec would be unchanged.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	appID := provider.EnvSetting(p.config, environment, "app_id")
+	component := provider.EnvSetting(p.config, environment, "component")
+
+	return provider.RetryOnConflict(errConflict, "digitalocean: app "+appID, func() error {
+		app, envs, err := p.envs(ctx, environment)
+		if err != nil {
+			return err
+		}
+		envs, changed := p.merge(envs, changes)
+		if !changed {
+			return nil
+		}
+		holder, _ := envHolder(app.Spec, component)
+		setEnvs(holder, envs)
+		return p.client.UpdateApp(ctx, app)
+	})
+}
+
+// merge applies changes to a list of vars, keeping the order and scope of
+// existing ones. Types follow the schema: sensitive secrets become SECRET.
+// A SECRET var whose digest matches the new value is left alone, and the
+// digests var is updated for every SECRET value written. Without a digest
+// key, the digests of written vars are dropped instead.
+func (p *Provider) merge(envs []EnvVar, changes map[string]*string) ([]EnvVar, bool) {
+	changed := false
+	digests := digestsOf(envs)
+	seen := make(map[string]bool, len(envs))
+	var out []EnvVar
+	for _, e := range envs {
+		seen[e.Key] = true
+		value, ok := changes[e.Key]
+		switch {
+		case e.Key == digestsKey:
+			continue
+		case !ok:
+			out = append(out, e)
+		case value == nil:
+			delete(digests, e.Key)
+			changed = true
+		default:
+			typ := p.envType(e.Key)
+			unchanged := e.Value == *value
+			if e.IsSecret() {
+				unchanged = p.MatchesDigest(*value, digests[e.Key])
+			}
+			sameType := typ == e.Type || (typ == TypeGeneral && e.Type == "")
+			if sameType && unchanged {
+				out = append(out, e)
+				continue
+			}
+			e.Value, e.Type = *value, typ
+			out = append(out, e)
+			p.recordDigest(digests, e)
+			changed = true
+		}
+	}
+
+	var added []string
+	for name, value := range changes {
+		if value != nil && !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		e := EnvVar{
+			Key:   name,
+			Value: *changes[name],
+			Scope: defaultScope,
+			Type:  p.envType(name),
+		}
+		out = append(out, e)
+		p.recordDigest(digests, e)
+		changed = true
+	}
+
+	if len(digests) > 0 {
+		out = append(out, EnvVar{Key: digestsKey, Value: formatDigests(digests), Scope: digestsScope, Type: TypeGeneral})
+	}
+	return out, changed
+}
+
+// recordDigest stores the digest of a written var's value, or drops it for
+// a var that is no longer SECRET or when there is no key to digest it with
+func (p *Provider) recordDigest(digests map[string]string, e EnvVar) {
+	if e.IsSecret() && len(p.digestKey) > 0 {
+		digests[e.Key] = newDigest(p.digestKey, e.Value)
+	} else {
+		delete(digests, e.Key)
+	}
+}
+
+func (p *Provider) envType(name string) string {
+	if provider.SecretSchema(p.config, name).IsSensitive() {
+		return TypeSecret
+	}
+	return TypeGeneral
+}
@@ -0,0 +1,339 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// fakeDO is an in-memory stand-in for the App Platform API. Specs are stored
+// as JSON so the provider sees a fresh decode on every read.
+type fakeDO struct {
+	mu      sync.Mutex
+	srv     *httptest.Server
+	specs   map[string]string // app ID -> spec JSON
+	version map[string]int
+	puts    int
+
+	// raceReads makes that many reads bump the app version while no write
+	// has succeeded, to simulate another client updating the app
+	raceReads int
+}
+
+func newFakeDO(t *testing.T) *fakeDO {
+	f := &fakeDO{specs: make(map[string]string), version: make(map[string]int)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeDO) spec(t *testing.T, appID string) map[string]any {
+	t.Helper()
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(f.specs[appID]), &spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func (f *fakeDO) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer dop_v1_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"id":"unauthorized","message":"Unable to authenticate you"}`))
+		return
+	}
+
+	appID := strings.TrimPrefix(r.URL.Path, "/apps/")
+	spec, ok := f.specs[appID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"id":"not_found","message":"app not found"}`))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		fmt.Fprintf(w, `{"app":{"id":%q,"updated_at":"v%d","spec":%s}}`, appID, f.version[appID], spec)
+		if f.raceReads > 0 && f.puts == 0 {
+			// Another client updates the app right after this read
+			f.raceReads--
+			f.version[appID]++
+		}
+	case "PUT":
+		var body struct {
+			Spec json.RawMessage `json:"spec"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.specs[appID] = string(body.Spec)
+		f.version[appID]++
+		f.puts++
+		_, _ = w.Write([]byte(`{"app":{}}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeDO, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "dop_v1_test"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+const testSpec = `{
+	"name": "shop",
+	"region": "ams",
+	"envs": [
+		{"key": "LOG_LEVEL", "value": "info", "scope": "RUN_TIME", "type": "GENERAL"},
+		{"key": "API_KEY", "value": "EV[1:abc]", "scope": "RUN_AND_BUILD_TIME", "type": "SECRET"}
+	],
+	"services": [
+		{"name": "web", "instance_count": 2, "envs": [{"key": "PORT", "value": "8080"}]}
+	]
+}`
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"app_id": "app-1"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "t"}); err == nil {
+		t.Error("expected error for missing app_id")
+	}
+}
+
+func TestProvider_ListHidesSecretValues(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	p := newTestProvider(t, f, map[string]any{"app_id": "app-1"})
+
+	secrets, err := p.List(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	if len(got) != 2 || got["LOG_LEVEL"] != "info" || got["API_KEY"] != "" || !secrets[1].Unreadable {
+		t.Errorf("List() = %v", got)
+	}
+}
+
+func TestProvider_SetManyAppLevel(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	notSensitive := false
+	p := newTestProvider(t, f, map[string]any{
+		"app_id":     "app-1",
+		"digest_key": "k3y",
+		"_schema":    map[string]model.Secret{"LOG_LEVEL": {Sensitive: &notSensitive}, "PUBLIC_URL": {Sensitive: &notSensitive}},
+	})
+	ctx := context.Background()
+
+	err := p.SetMany(ctx, map[string]string{"LOG_LEVEL": "debug", "PUBLIC_URL": "https://shop.example", "DB_URL": "postgres://"}, "production")
+	if err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("puts = %d, want 1", f.puts)
+	}
+
+	spec := f.spec(t, "app-1")
+	if spec["region"] != "ams" || spec["services"] == nil {
+		t.Errorf("unrelated spec fields lost: %v", spec)
+	}
+	envs, _ := getEnvs(spec)
+	want := []EnvVar{
+		{Key: "LOG_LEVEL", Value: "debug", Scope: "RUN_TIME", Type: TypeGeneral},
+		{Key: "API_KEY", Value: "EV[1:abc]", Scope: "RUN_AND_BUILD_TIME", Type: TypeSecret},
+		{Key: "DB_URL", Value: "postgres://", Scope: defaultScope, Type: TypeSecret},
+		{Key: "PUBLIC_URL", Value: "https://shop.example", Scope: defaultScope, Type: TypeGeneral},
+	}
+	if len(envs) != 5 || fmt.Sprint(envs[:4]) != fmt.Sprint(want) {
+		t.Errorf("envs = %v, want %v and digests", envs, want)
+	}
+	// Only the SECRET value written gets a digest
+	digests := envs[len(envs)-1]
+	if digests.Key != digestsKey || digests.Scope != digestsScope || !strings.HasPrefix(digests.Value, "DB_URL=") || strings.Contains(digests.Value, ",") {
+		t.Errorf("digests = %+v", digests)
+	}
+}
+
+func TestProvider_UnchangedSkipsDeploy(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	notSensitive := false
+	p := newTestProvider(t, f, map[string]any{
+		"app_id":  "app-1",
+		"_schema": map[string]model.Secret{"LOG_LEVEL": {Sensitive: &notSensitive}},
+	})
+
+	if err := p.Set(context.Background(), "LOG_LEVEL", "info", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 0 {
+		t.Errorf("puts = %d, want 0", f.puts)
+	}
+}
+
+func TestProvider_UnchangedSecretSkipsDeploy(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	p := newTestProvider(t, f, map[string]any{"app_id": "app-1", "digest_key": "k3y"})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Fatalf("puts = %d, want 1", f.puts)
+	}
+
+	// The secret lists without its value but with a digest that matches it
+	secrets, err := p.List(ctx, "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var apiKey model.SecretValue
+	for _, s := range secrets {
+		if s.Name == digestsKey {
+			t.Error("digests var should not be listed")
+		}
+		if s.Name == "API_KEY" {
+			apiKey = s
+		}
+	}
+	if !apiKey.Unreadable || apiKey.Value != "" || !p.MatchesDigest("sk_live", apiKey.Digest) || p.MatchesDigest("sk_test", apiKey.Digest) {
+		t.Errorf("API_KEY = %+v", apiKey)
+	}
+	// The digest is useless without the key
+	other := newTestProvider(t, f, map[string]any{"app_id": "app-1", "digest_key": "other"})
+	if other.MatchesDigest("sk_live", apiKey.Digest) {
+		t.Error("digest matched under a different key")
+	}
+
+	// Writing the same value again doesn't redeploy; a new one does
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("puts = %d after unchanged write, want 1", f.puts)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_new", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 2 {
+		t.Errorf("puts = %d after changed write, want 2", f.puts)
+	}
+
+	// Deleting the last secret drops the digests var
+	if err := p.Delete(ctx, "API_KEY", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if envs, _ := getEnvs(f.spec(t, "app-1")); len(envs) != 1 || envs[0].Key != "LOG_LEVEL" {
+		t.Errorf("envs = %v", envs)
+	}
+}
+
+func TestProvider_NoDigestKey(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	keyed := newTestProvider(t, f, map[string]any{"app_id": "app-1", "digest_key": "k3y"})
+	p := newTestProvider(t, f, map[string]any{"app_id": "app-1"})
+	ctx := context.Background()
+
+	if err := keyed.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Without a key, secrets are unknown and every write goes through
+	secrets, err := p.List(ctx, "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, s := range secrets {
+		if s.Name == "API_KEY" && (!s.Unreadable || s.Digest != "") {
+			t.Errorf("API_KEY = %+v, want unreadable without a digest", s)
+		}
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 2 {
+		t.Errorf("puts = %d, want 2", f.puts)
+	}
+
+	// and the digest it can no longer keep current is dropped
+	envs, _ := getEnvs(f.spec(t, "app-1"))
+	for _, e := range envs {
+		if e.Key == digestsKey {
+			t.Errorf("digests var kept: %+v", e)
+		}
+	}
+}
+
+func TestProvider_ComponentLevel(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	p := newTestProvider(t, f, map[string]any{
+		"environments": map[string]map[string]string{
+			"production": {"app_id": "app-1", "component": "web"},
+			"staging":    {"app_id": "app-1", "component": "missing"},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "TOKEN", "t0k", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "PORT", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	spec := f.spec(t, "app-1")
+	web := spec["services"].([]any)[0].(map[string]any)
+	envs, _ := getEnvs(web)
+	if len(envs) != 1 || envs[0].Key != "TOKEN" || envs[0].Type != TypeSecret {
+		t.Errorf("component envs = %v", envs)
+	}
+	if web["instance_count"] != float64(2) {
+		t.Errorf("component fields lost: %v", web)
+	}
+
+	if err := p.Validate(ctx); err == nil || !strings.Contains(err.Error(), `no component named "missing"`) {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestProvider_ConflictRetries(t *testing.T) {
+	f := newFakeDO(t)
+	f.specs["app-1"] = testSpec
+	f.raceReads = 2
+	p := newTestProvider(t, f, map[string]any{"app_id": "app-1"})
+
+	if err := p.Set(context.Background(), "DB_URL", "postgres://", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("puts = %d, want 1", f.puts)
+	}
+
+	f.puts = 0
+	f.raceReads = 100
+	err := p.Set(context.Background(), "DB_URL", "mysql://", "production")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package digitalocean
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Env var types. Values of SECRET vars are returned encrypted.
+const (
+	TypeGeneral = "GENERAL"
+	TypeSecret  = "SECRET"
+)
+
+// defaultScope makes new vars available at build and run time
+const defaultScope = "RUN_AND_BUILD_TIME"
+
+// componentKinds are the spec lists whose entries can carry envs
+var componentKinds = []string{"services", "workers", "jobs", "static_sites", "functions"}
+
+// EnvVar is an app-level or component-level environment variable
+type EnvVar struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Scope string `json:"scope,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// IsSecret reports whether the var's value is stored encrypted
+func (e EnvVar) IsSecret() bool {
+	return e.Type == TypeSecret
+}
+
+// envHolder returns the part of a spec that holds the envs: the spec itself
+// for app-level vars, or the named component
+func envHolder(spec map[string]any, component string) (map[string]any, error) {
+	if component == "" {
+		return spec, nil
+	}
+	for _, kind := range componentKinds {
+		list, _ := spec[kind].([]any)
+		for _, item := range list {
+			if c, ok := item.(map[string]any); ok && c["name"] == component {
+				return c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("digitalocean: app has no component named %q", component)
+}
+
+// getEnvs decodes the envs of a spec or component
+func getEnvs(holder map[string]any) ([]EnvVar, error) {
+	raw, ok := holder["envs"]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var envs []EnvVar
+	if err := json.Unmarshal(data, &envs); err != nil {
+		return nil, fmt.Errorf("digitalocean: unexpected envs in app spec: %w", err)
+	}
+	return envs, nil
+}
+
+// setEnvs replaces the envs of a spec or component
+func setEnvs(holder map[string]any, envs []EnvVar) {
+	if len(envs) == 0 {
+		delete(holder, "envs")
+		return
+	}
+	holder["envs"] = envs
+}
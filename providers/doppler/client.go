@@ -0,0 +1,138 @@
+package doppler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultBaseURL = "https://api.doppler.com/v3"
+
+// Client handles Doppler API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new Doppler API client
+func NewClient(token string) *Client {
+	return &Client{
+		baseURL: defaultBaseURL,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// DownloadSecrets returns the computed secrets of a config. Doppler's own
+// DOPPLER_* metadata keys are left out.
+func (c *Client) DownloadSecrets(ctx context.Context, project, config string) (map[string]string, error) {
+	query := url.Values{"format": {"json"}}
+	setScope(query, project, config)
+
+	var secrets map[string]string
+	if err := c.do(ctx, "GET", "/configs/config/secrets/download?"+query.Encode(), nil, &secrets); err != nil {
+		return nil, err
+	}
+	for name := range secrets {
+		if strings.HasPrefix(name, "DOPPLER_") {
+			delete(secrets, name)
+		}
+	}
+	return secrets, nil
+}
+
+// UpdateSecrets sets secrets in a config in one request. A nil value
+// deletes the secret.
+func (c *Client) UpdateSecrets(ctx context.Context, project, config string, secrets map[string]*string) error {
+	body := map[string]any{"secrets": secrets}
+	if project != "" {
+		body["project"] = project
+	}
+	if config != "" {
+		body["config"] = config
+	}
+	return c.do(ctx, "POST", "/configs/config/secrets", body, nil)
+}
+
+// Me checks that the token is valid
+func (c *Client) Me(ctx context.Context) error {
+	return c.do(ctx, "GET", "/me", nil, nil)
+}
+
+// setScope adds project and config to a query. Service tokens are bound to
+// one config, so both may be empty.
+func setScope(query url.Values, project, config string) {
+	if project != "" {
+		query.Set("project", project)
+	}
+	if config != "" {
+		query.Set("config", config)
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Messages []string `json:"messages"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if len(errResp.Messages) > 0 {
+			return fmt.Errorf("doppler API error: %s", strings.Join(errResp.Messages, "; "))
+		}
+	}
+
+	return fmt.Errorf("doppler API error: status %d, body: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,114 @@
+package doppler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "doppler",
+		DisplayName: "Doppler",
+		Factory:     New,
+		EnvVar:      "DOPPLER_TOKEN",
+		Beta:        true,
+	})
+}
+
+// Provider implements the Doppler provider. Remote environments are config
+// names (dev, stg, prd, or branch configs like dev_preview) in a project.
+type Provider struct {
+	client *Client
+	config map[string]any
+}
+
+// New creates a new Doppler provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("doppler: API token is required")
+	}
+
+	return &Provider{
+		client: NewClient(token),
+		config: config,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "doppler" }
+func (p *Provider) DisplayName() string { return "Doppler" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"dev", "stg", "prd"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"dev": "test",
+		"stg": "test",
+		"prd": "live",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	return p.client.Me(ctx)
+}
+
+// scope returns the project and config for an environment. The config
+// defaults to the environment name; either may be overridden per
+// environment.
+func (p *Provider) scope(environment string) (string, string) {
+	project := provider.EnvSetting(p.config, environment, "project")
+	config := provider.EnvSetting(p.config, environment, "config")
+	if config == "" {
+		config = environment
+	}
+	return project, config
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	project, config := p.scope(environment)
+	values, err := p.client.DownloadSecrets(ctx, project, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for name, value := range values {
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one update
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	project, config := p.scope(environment)
+	secrets := make(map[string]*string, len(values))
+	for name, value := range values {
+		secrets[name] = &value
+	}
+	return p.client.UpdateSecrets(ctx, project, config, secrets)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	project, config := p.scope(environment)
+	return p.client.UpdateSecrets(ctx, project, config, map[string]*string{name: nil})
+}
@@ -0,0 +1,184 @@
+package doppler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDoppler is an in-memory stand-in for the Doppler v3 API
+type fakeDoppler struct {
+	mu      sync.Mutex
+	srv     *httptest.Server
+	configs map[string]map[string]string // "project/config" -> secrets
+	updates int
+}
+
+func newFakeDoppler(t *testing.T) *fakeDoppler {
+	f := &fakeDoppler{configs: make(map[string]map[string]string)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeDoppler) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer dp.pt.test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"messages":["Invalid Auth token"],"success":false}`))
+		return
+	}
+
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"messages":["Could not find requested config"],"success":false}`))
+	}
+
+	switch r.URL.Path {
+	case "/me":
+		_, _ = w.Write([]byte(`{"name":"test"}`))
+
+	case "/configs/config/secrets/download":
+		q := r.URL.Query()
+		key := q.Get("project") + "/" + q.Get("config")
+		secrets, ok := f.configs[key]
+		if !ok || q.Get("format") != "json" {
+			notFound()
+			return
+		}
+		out := map[string]string{
+			"DOPPLER_PROJECT":     q.Get("project"),
+			"DOPPLER_CONFIG":      q.Get("config"),
+			"DOPPLER_ENVIRONMENT": q.Get("config"),
+		}
+		for k, v := range secrets {
+			out[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(out)
+
+	case "/configs/config/secrets":
+		var body struct {
+			Project string             `json:"project"`
+			Config  string             `json:"config"`
+			Secrets map[string]*string `json:"secrets"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		secrets, ok := f.configs[body.Project+"/"+body.Config]
+		if !ok {
+			notFound()
+			return
+		}
+		f.updates++
+		for k, v := range body.Secrets {
+			if v == nil {
+				delete(secrets, k)
+			} else {
+				secrets[k] = *v
+			}
+		}
+		_, _ = w.Write([]byte(`{"secrets":{}}`))
+
+	default:
+		notFound()
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeDoppler, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "dp.pt.test"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func TestNew_MissingToken(t *testing.T) {
+	if _, err := New(map[string]any{"project": "web"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+}
+
+func TestProvider_ListSkipsMetadata(t *testing.T) {
+	f := newFakeDoppler(t)
+	f.configs["web/prd"] = map[string]string{"API_KEY": "sk_live"}
+	p := newTestProvider(t, f, map[string]any{"project": "web"})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	secrets, err := p.List(ctx, "prd")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "API_KEY" || secrets[0].Value != "sk_live" {
+		t.Errorf("List() = %+v", secrets)
+	}
+}
+
+func TestProvider_SetManyAndDelete(t *testing.T) {
+	f := newFakeDoppler(t)
+	f.configs["web/dev"] = map[string]string{"OLD": "x"}
+	p := newTestProvider(t, f, map[string]any{"project": "web"})
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"A": "1", "B": "2"}, "dev"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.updates != 1 || f.configs["web/dev"]["A"] != "1" || f.configs["web/dev"]["B"] != "2" {
+		t.Errorf("updates = %d, secrets = %v", f.updates, f.configs["web/dev"])
+	}
+
+	if err := p.Delete(ctx, "OLD", "dev"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.configs["web/dev"]["OLD"]; ok {
+		t.Error("OLD should be deleted")
+	}
+}
+
+func TestProvider_ConfigOverride(t *testing.T) {
+	f := newFakeDoppler(t)
+	f.configs["web/dev_preview"] = map[string]string{}
+	f.configs["api/prd"] = map[string]string{}
+	p := newTestProvider(t, f, map[string]any{
+		"project": "web",
+		"environments": map[string]map[string]string{
+			"preview":    {"config": "dev_preview"},
+			"production": {"project": "api", "config": "prd"},
+		},
+	})
+	ctx := context.Background()
+
+	if got := strings.Join(p.Environments(), ","); got != "preview,production" {
+		t.Errorf("Environments() = %s", got)
+	}
+	if err := p.Set(ctx, "KEY", "pv", "preview"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "KEY", "pd", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.configs["web/dev_preview"]["KEY"] != "pv" || f.configs["api/prd"]["KEY"] != "pd" {
+		t.Errorf("configs = %v", f.configs)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	f := newFakeDoppler(t)
+	p := newTestProvider(t, f, map[string]any{"project": "web"})
+
+	_, err := p.List(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "Could not find requested config") {
+		t.Errorf("List() error = %v", err)
+	}
+}
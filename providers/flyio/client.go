@@ -7,12 +7,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
-const baseURL = "https://api.machines.dev/v1"
+const defaultBaseURL = "https://api.machines.dev/v1"
+
+const (
+	// leaseTTL is how long a machine lease lasts, in seconds, if it isn't
+	// released
+	leaseTTL = 300
+
+	// waitTimeout is how long to wait for a machine to start, in seconds.
+	// It is the most the API allows.
+	waitTimeout = 60
+)
 
 // Client handles Fly.io Machines API requests
 type Client struct {
+	baseURL string
 	token   string
 	appName string
 	http    *http.Client
@@ -21,6 +33,7 @@ type Client struct {
 // NewClient creates a new Fly.io API client
 func NewClient(token, appName string) *Client {
 	return &Client{
+		baseURL: defaultBaseURL,
 		token:   token,
 		appName: appName,
 		http:    &http.Client{},
@@ -58,7 +71,8 @@ func (c *Client) ListSecrets(ctx context.Context) ([]Secret, error) {
 	return result.Secrets, nil
 }
 
-// SetSecrets creates or updates secrets via the Machines API.
+// SetSecrets creates or updates secrets via the Machines API. Machines only
+// pick them up when they are next updated.
 // The API expects {"values": {"KEY": "VALUE", ...}} with plaintext values.
 func (c *Client) SetSecrets(ctx context.Context, values map[string]string) error {
 	endpoint := fmt.Sprintf("/apps/%s/secrets", c.appName)
@@ -83,7 +97,7 @@ func (c *Client) SetSecrets(ctx context.Context, values map[string]string) error
 
 // DeleteSecret deletes a secret by name
 func (c *Client) DeleteSecret(ctx context.Context, name string) error {
-	endpoint := fmt.Sprintf("/apps/%s/secrets/%s", c.appName, name)
+	endpoint := fmt.Sprintf("/apps/%s/secrets/%s", c.appName, url.PathEscape(name))
 
 	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
@@ -98,23 +112,165 @@ func (c *Client) DeleteSecret(ctx context.Context, name string) error {
 	return nil
 }
 
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+// Machine is a Fly Machine. Its config is kept as returned, so an update
+// changes nothing else.
+type Machine struct {
+	ID         string          `json:"id"`
+	State      string          `json:"state"`
+	InstanceID string          `json:"instance_id,omitempty"`
+	Config     json.RawMessage `json:"config"`
+}
+
+// ListMachines retrieves the app's machines
+func (c *Client) ListMachines(ctx context.Context) ([]Machine, error) {
+	endpoint := fmt.Sprintf("/apps/%s/machines", c.appName)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var machines []Machine
+	if err := json.NewDecoder(resp.Body).Decode(&machines); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return machines, nil
+}
+
+// AcquireLease leases a machine so nothing else updates it meanwhile, and
+// returns the lease's nonce
+func (c *Client) AcquireLease(ctx context.Context, machineID string) (string, error) {
+	endpoint := fmt.Sprintf("/apps/%s/machines/%s/lease", c.appName, url.PathEscape(machineID))
+
+	body, err := json.Marshal(map[string]any{"ttl": leaseTTL})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.parseError(resp)
+	}
+
+	var result struct {
+		Data struct {
+			Nonce string `json:"nonce"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Data.Nonce, nil
+}
+
+// ReleaseLease releases a lease taken with AcquireLease
+func (c *Client) ReleaseLease(ctx context.Context, machineID, nonce string) error {
+	endpoint := fmt.Sprintf("/apps/%s/machines/%s/lease", c.appName, url.PathEscape(machineID))
+
+	resp, err := c.doLeasedRequest(ctx, "DELETE", endpoint, nil, nonce)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// UpdateMachine updates a leased machine with its current config, which
+// restarts it with the app's current secrets. A stopped machine stays
+// stopped. It returns the ID of the machine's new instance.
+func (c *Client) UpdateMachine(ctx context.Context, m Machine, nonce string) (string, error) {
+	endpoint := fmt.Sprintf("/apps/%s/machines/%s", c.appName, url.PathEscape(m.ID))
+
+	body, err := json.Marshal(map[string]any{
+		"config":      m.Config,
+		"skip_launch": m.State != "started",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doLeasedRequest(ctx, "POST", endpoint, body, nonce)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.parseError(resp)
+	}
+
+	var updated Machine
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return updated.InstanceID, nil
+}
+
+// WaitForState waits until a machine's instance reaches state, or fails
+// after waitTimeout
+func (c *Client) WaitForState(ctx context.Context, machineID, instanceID, state string) error {
+	query := url.Values{"state": {state}, "timeout": {fmt.Sprint(waitTimeout)}}
+	if instanceID != "" {
+		query.Set("instance_id", instanceID)
+	}
+	endpoint := fmt.Sprintf("/apps/%s/machines/%s/wait?%s", c.appName, url.PathEscape(machineID), query.Encode())
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// doLeasedRequest sends a request holding a machine lease, given its nonce
+func (c *Client) doLeasedRequest(ctx context.Context, method, endpoint string, body []byte, nonce string) (*http.Response, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		bodyReader = bytes.NewReader(body)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+endpoint, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
+	if nonce != "" {
+		req.Header.Set("fly-machine-lease-nonce", nonce)
+	}
 
 	return c.http.Do(req)
 }
 
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	return c.doLeasedRequest(ctx, method, endpoint, body, "")
+}
+
 func (c *Client) parseError(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
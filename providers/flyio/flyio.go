@@ -2,7 +2,10 @@ package flyio
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
@@ -74,16 +77,68 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 			Name:        s.Name,
 			Value:       "", // Fly.io API returns names only, not values
 			Environment: environment,
+			Digest:      s.Digest,
 		})
 	}
 
 	return result, nil
 }
 
+// MatchesDigest reports whether value has the listed digest: the start of
+// its hex SHA-256 hash
+func (p *Provider) MatchesDigest(value, digest string) bool {
+	sum := sha256.Sum256([]byte(value))
+	return digest != "" && strings.HasPrefix(hex.EncodeToString(sum[:]), strings.ToLower(digest))
+}
+
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	return p.client.SetSecrets(ctx, map[string]string{name: value})
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany stages the values in one request. Machines pick them up on Deploy.
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	return p.client.SetSecrets(ctx, values)
 }
 
+// Delete stages the removal of a secret until Deploy
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
 	return p.client.DeleteSecret(ctx, name)
 }
+
+// Deploy updates the machines one at a time, so they restart with the
+// staged secrets. Each machine is leased while it is updated, and a running
+// one must be started again before the next is touched, so a bad secret
+// stops the rollout after one machine. Destroyed machines are skipped.
+func (p *Provider) Deploy(ctx context.Context, environment string) error {
+	machines, err := p.client.ListMachines(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if m.State == "destroyed" || m.State == "destroying" {
+			continue
+		}
+		if err := p.updateMachine(ctx, m); err != nil {
+			return fmt.Errorf("flyio: failed to update machine %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
+
+// updateMachine updates one machine under a lease and waits for it to start
+// if it was running
+func (p *Provider) updateMachine(ctx context.Context, m Machine) error {
+	nonce, err := p.client.AcquireLease(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	instanceID, err := p.client.UpdateMachine(ctx, m, nonce)
+	if err == nil && m.State == "started" {
+		err = p.client.WaitForState(ctx, m.ID, instanceID, "started")
+	}
+	if releaseErr := p.client.ReleaseLease(ctx, m.ID, nonce); err == nil {
+		err = releaseErr
+	}
+	return err
+}
@@ -1,6 +1,17 @@
 package flyio
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -90,3 +101,230 @@ func TestProvider_DefaultMapping(t *testing.T) {
 		t.Errorf("DefaultMapping()[default] = %q, want 'test'", mapping["default"])
 	}
 }
+
+// fakeFly is an in-memory stand-in for the Machines API. Secrets are staged
+// until a machine is updated. An updated machine is "replacing" until
+// someone waits for it, and no other machine can be updated meanwhile.
+type fakeFly struct {
+	mu        sync.Mutex
+	srv       *httptest.Server
+	secrets   map[string]string
+	machines  map[string]string // ID -> state
+	instances map[string]string // ID -> current instance
+	leases    map[string]string // ID -> nonce
+	failStart string            // Machine that never starts again
+	requests  []string
+}
+
+func newFakeFly(t *testing.T) *fakeFly {
+	f := &fakeFly{
+		secrets:   map[string]string{},
+		machines:  map[string]string{"m1": "started", "m2": "stopped", "m3": "destroyed", "m4": "started"},
+		instances: map[string]string{},
+		leases:    map[string]string{},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeFly) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer fly_token_123" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/apps/my-app")
+	var body map[string]json.RawMessage
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	if r.Method != "GET" || strings.HasSuffix(path, "/wait") {
+		f.requests = append(f.requests, strings.TrimSpace(r.Method+" "+path+" "+string(body["skip_launch"])))
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(path, "/machines/"), "/")
+	nonce := r.Header.Get("fly-machine-lease-nonce")
+
+	encode := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+	switch {
+	case r.Method == "GET" && path == "/secrets":
+		var secrets []Secret
+		for _, name := range slices.Sorted(maps.Keys(f.secrets)) {
+			sum := sha256.Sum256([]byte(f.secrets[name]))
+			secrets = append(secrets, Secret{Name: name, Digest: hex.EncodeToString(sum[:8])})
+		}
+		encode(map[string]any{"secrets": secrets})
+
+	case r.Method == "POST" && path == "/secrets":
+		var values map[string]string
+		_ = json.Unmarshal(body["values"], &values)
+		maps.Copy(f.secrets, values)
+		encode(map[string]any{})
+
+	case r.Method == "DELETE" && strings.HasPrefix(path, "/secrets/"):
+		delete(f.secrets, strings.TrimPrefix(path, "/secrets/"))
+		encode(map[string]any{})
+
+	case r.Method == "GET" && path == "/machines":
+		var machines []Machine
+		for _, id := range slices.Sorted(maps.Keys(f.machines)) {
+			machines = append(machines, Machine{ID: id, State: f.machines[id], Config: json.RawMessage(`{"image":"app"}`)})
+		}
+		encode(machines)
+
+	case r.Method == "POST" && path == "/machines/"+id+"/lease":
+		if f.leases[id] != "" {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"lease currently held"}`))
+			return
+		}
+		f.leases[id] = "nonce-" + id
+		encode(map[string]any{"status": "success", "data": map[string]string{"nonce": f.leases[id]}})
+
+	case r.Method == "DELETE" && path == "/machines/"+id+"/lease":
+		if nonce != f.leases[id] {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"wrong lease nonce"}`))
+			return
+		}
+		delete(f.leases, id)
+		encode(map[string]any{})
+
+	case r.Method == "GET" && path == "/machines/"+id+"/wait":
+		query := r.URL.Query()
+		if id == f.failStart || query.Get("state") != "started" || query.Get("instance_id") != f.instances[id] {
+			w.WriteHeader(http.StatusRequestTimeout)
+			_, _ = w.Write([]byte(`{"error":"deadline_exceeded"}`))
+			return
+		}
+		f.machines[id] = "started"
+		encode(map[string]any{"ok": true})
+
+	case r.Method == "POST" && path == "/machines/"+id:
+		if string(body["config"]) != `{"image":"app"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"config changed"}`))
+			return
+		}
+		if nonce == "" || nonce != f.leases[id] {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"machine is leased"}`))
+			return
+		}
+		for other, state := range f.machines {
+			if state == "replacing" {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error":"` + other + ` is still replacing"}`))
+				return
+			}
+		}
+		f.instances[id] = "inst-" + strconv.Itoa(len(f.requests))
+		if string(body["skip_launch"]) != "true" {
+			f.machines[id] = "replacing"
+		}
+		encode(Machine{ID: id, State: f.machines[id], InstanceID: f.instances[id]})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeFly) *Provider {
+	prov, err := New(map[string]any{"_resolved_token": "fly_token_123", "app_name": "my-app"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func TestProvider_Digests(t *testing.T) {
+	f := newFakeFly(t)
+	f.secrets["API_KEY"] = "secret"
+	p := newTestProvider(t, f)
+
+	secrets, err := p.List(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Value != "" || secrets[0].Digest == "" {
+		t.Fatalf("List = %+v, want API_KEY with a digest and no value", secrets)
+	}
+
+	digest := secrets[0].Digest
+	if !p.MatchesDigest("secret", digest) || !p.MatchesDigest("secret", strings.ToUpper(digest)) {
+		t.Errorf("MatchesDigest(secret, %s) = false, want true", digest)
+	}
+	if p.MatchesDigest("other", digest) || p.MatchesDigest("secret", "") {
+		t.Error("MatchesDigest matched a different value or an empty digest")
+	}
+}
+
+func TestProvider_StageAndDeploy(t *testing.T) {
+	f := newFakeFly(t)
+	f.secrets["OLD"] = "x"
+	p := newTestProvider(t, f)
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"A": "1", "B": "2"}, "default"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if err := p.Delete(ctx, "OLD", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := p.Deploy(ctx, "default"); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	if len(f.secrets) != 2 || f.secrets["A"] != "1" || f.secrets["B"] != "2" {
+		t.Errorf("secrets = %v", f.secrets)
+	}
+	// One write for the batch, then each live machine updated once; the
+	// stopped one isn't started
+	want := []string{
+		"POST /secrets", "DELETE /secrets/OLD",
+		"POST /machines/m1/lease", "POST /machines/m1 false", "GET /machines/m1/wait", "DELETE /machines/m1/lease",
+		"POST /machines/m2/lease", "POST /machines/m2 true", "DELETE /machines/m2/lease",
+		"POST /machines/m4/lease", "POST /machines/m4 false", "GET /machines/m4/wait", "DELETE /machines/m4/lease",
+	}
+	if !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %q, want %q", f.requests, want)
+	}
+	if len(f.leases) != 0 || f.machines["m1"] != "started" || f.machines["m4"] != "started" {
+		t.Errorf("leases = %v, machines = %v", f.leases, f.machines)
+	}
+}
+
+func TestProvider_DeployStopsAtFailedMachine(t *testing.T) {
+	f := newFakeFly(t)
+	p := newTestProvider(t, f)
+	ctx := context.Background()
+
+	// A machine that doesn't come back stops the rollout, and its lease is
+	// still released
+	f.failStart = "m1"
+	err := p.Deploy(ctx, "default")
+	if err == nil || !strings.Contains(err.Error(), "machine m1") {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	want := []string{"POST /machines/m1/lease", "POST /machines/m1 false", "GET /machines/m1/wait", "DELETE /machines/m1/lease"}
+	if !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %q, want %q", f.requests, want)
+	}
+
+	// A machine someone else holds isn't touched
+	f.failStart, f.requests = "", nil
+	f.machines["m1"] = "started"
+	f.leases["m1"] = "theirs"
+	err = p.Deploy(ctx, "default")
+	if err == nil || !strings.Contains(err.Error(), "lease currently held") {
+		t.Errorf("Deploy() error = %v", err)
+	}
+	if !slices.Equal(f.requests, []string{"POST /machines/m1/lease"}) {
+		t.Errorf("requests = %q", f.requests)
+	}
+}
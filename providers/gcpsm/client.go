@@ -3,13 +3,31 @@ package gcpsm
 import (
 	"context"
 	"fmt"
+	"maps"
+	"sort"
+	"strconv"
 	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const (
+	// listPageSize is how many secrets are listed per page
+	listPageSize = 250
+
+	// fetchConcurrency bounds how many values are accessed at once
+	fetchConcurrency = 10
+
+	// accessRate is the most value accesses per second, well within the
+	// default quota of 90,000 per minute per project
+	accessRate rate.Limit = 100
 )
 
 // smClient defines the GCP Secret Manager operations used by the client
@@ -19,6 +37,11 @@ type smClient interface {
 	DeleteSecret(ctx context.Context, req *secretmanagerpb.DeleteSecretRequest) error
 	AddSecretVersion(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	GetSecret(ctx context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error)
+	UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error)
+	ListSecretVersions(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) versionIterator
+	DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
+	DestroySecretVersion(ctx context.Context, req *secretmanagerpb.DestroySecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	Close() error
 }
 
@@ -27,6 +50,11 @@ type secretIterator interface {
 	Next() (*secretmanagerpb.Secret, error)
 }
 
+// versionIterator abstracts the GCP secret version iterator for testing
+type versionIterator interface {
+	Next() (*secretmanagerpb.SecretVersion, error)
+}
+
 // gcpClientWrapper wraps the real GCP Secret Manager client
 type gcpClientWrapper struct {
 	inner *secretmanager.Client
@@ -52,20 +80,55 @@ func (w *gcpClientWrapper) AccessSecretVersion(ctx context.Context, req *secretm
 	return w.inner.AccessSecretVersion(ctx, req)
 }
 
+func (w *gcpClientWrapper) GetSecret(ctx context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error) {
+	return w.inner.GetSecret(ctx, req)
+}
+
+func (w *gcpClientWrapper) UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error) {
+	return w.inner.UpdateSecret(ctx, req)
+}
+
+func (w *gcpClientWrapper) ListSecretVersions(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) versionIterator {
+	return w.inner.ListSecretVersions(ctx, req)
+}
+
+func (w *gcpClientWrapper) DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	return w.inner.DisableSecretVersion(ctx, req)
+}
+
+func (w *gcpClientWrapper) DestroySecretVersion(ctx context.Context, req *secretmanagerpb.DestroySecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	return w.inner.DestroySecretVersion(ctx, req)
+}
+
 func (w *gcpClientWrapper) Close() error {
 	return w.inner.Close()
 }
 
 // client wraps the GCP Secret Manager API
 type client struct {
-	api     smClient
-	project string
-	prefix  string
+	api         smClient
+	project     string
+	concurrency int           // Values fetched at once
+	limiter     *rate.Limiter // Shared by all value fetches
 }
 
 // newClient creates a new GCP Secret Manager client wrapper
-func newClient(api smClient, project, prefix string) *client {
-	return &client{api: api, project: project, prefix: prefix}
+func newClient(api smClient, project string) *client {
+	return &client{
+		api:         api,
+		project:     project,
+		concurrency: fetchConcurrency,
+		limiter:     rate.NewLimiter(accessRate, fetchConcurrency),
+	}
+}
+
+// secretOptions controls how a secret is created and what happens to its
+// older versions
+type secretOptions struct {
+	Locations    []string          // User-managed replication; automatic if empty
+	Labels       map[string]string // Merged into the secret's labels
+	KeepVersions int               // Versions left untouched after a write; 0 keeps all
+	Destroy      bool              // Destroy older versions rather than disable them
 }
 
 // parent returns the GCP resource parent path
@@ -74,8 +137,8 @@ func (c *client) parent() string {
 }
 
 // secretPath returns the full resource path for a secret
-func (c *client) secretPath(name string) string {
-	return fmt.Sprintf("projects/%s/secrets/%s%s", c.project, c.prefix, name)
+func (c *client) secretPath(secretID string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", c.project, secretID)
 }
 
 // Validate checks that credentials and project access work
@@ -92,12 +155,14 @@ func (c *client) Validate(ctx context.Context) error {
 	return nil
 }
 
-// ListSecrets returns all secrets matching the prefix
-func (c *client) ListSecrets(ctx context.Context) (map[string]string, error) {
-	result := make(map[string]string)
+// ListSecrets returns all secrets matching the prefix, keyed by name
+// without the prefix. Values are fetched concurrently.
+func (c *client) ListSecrets(ctx context.Context, prefix string) (map[string]string, error) {
+	var names, bareNames []string
 
 	it := c.api.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
-		Parent: c.parent(),
+		Parent:   c.parent(),
+		PageSize: listPageSize,
 	})
 
 	for {
@@ -113,93 +178,218 @@ func (c *client) ListSecrets(ctx context.Context) (map[string]string, error) {
 		parts := strings.Split(secret.Name, "/")
 		secretID := parts[len(parts)-1]
 
-		// Filter by prefix
-		if c.prefix != "" && !strings.HasPrefix(secretID, c.prefix) {
+		// Filter by prefix and strip it to get the bare name
+		bareName, ok := strings.CutPrefix(secretID, prefix)
+		if !ok {
 			continue
 		}
+		names = append(names, secret.Name)
+		bareNames = append(bareNames, bareName)
+	}
 
-		// Strip prefix to get the bare name
-		bareName := secretID
-		if c.prefix != "" {
-			bareName = strings.TrimPrefix(secretID, c.prefix)
-		}
-
-		// Access latest version
-		resp, err := c.api.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
-			Name: secret.Name + "/versions/latest",
-		})
-		if err != nil {
-			// Skip secrets with no versions
-			if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-				continue
+	values := make([]*string, len(names))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, name := range names {
+		g.Go(func() error {
+			if err := c.limiter.Wait(gctx); err != nil {
+				return err
 			}
-			return nil, fmt.Errorf("gcp-secret-manager: failed to access secret %s: %w", bareName, err)
-		}
 
-		result[bareName] = string(resp.Payload.Data)
+			// Access latest version
+			resp, err := c.api.AccessSecretVersion(gctx, &secretmanagerpb.AccessSecretVersionRequest{
+				Name: name + "/versions/latest",
+			})
+			if err != nil {
+				// Skip secrets with no versions
+				if isNotFound(err) {
+					return nil
+				}
+				return fmt.Errorf("gcp-secret-manager: failed to access secret %s: %w", bareNames[i], err)
+			}
+			value := string(resp.Payload.Data)
+			values[i] = &value
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
+	result := make(map[string]string, len(names))
+	for i, value := range values {
+		if value != nil {
+			result[bareNames[i]] = *value
+		}
+	}
 	return result, nil
 }
 
-// SetSecret creates or updates a secret
-func (c *client) SetSecret(ctx context.Context, name, value string) error {
-	fullName := c.secretPath(name)
+// SetSecret creates or updates a secret. No version is added if the latest
+// one already holds value. After a write, versions beyond the newest
+// KeepVersions are disabled or destroyed.
+func (c *client) SetSecret(ctx context.Context, secretID, value string, opts secretOptions) error {
+	fullName := c.secretPath(secretID)
+
+	secret, err := c.api.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: fullName})
+	switch {
+	case isNotFound(err):
+		if err := c.createSecret(ctx, secretID, opts); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("gcp-secret-manager: failed to get secret %s: %w", secretID, err)
+	default:
+		if err := c.updateLabels(ctx, secret, opts.Labels); err != nil {
+			return err
+		}
+		resp, err := c.api.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+			Name: fullName + "/versions/latest",
+		})
+		if err == nil && string(resp.Payload.Data) == value {
+			return nil
+		}
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("gcp-secret-manager: failed to access secret %s: %w", secretID, err)
+		}
+	}
 
-	// Try to add a new version (assumes secret exists)
-	_, err := c.api.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+	_, err = c.api.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
 		Parent: fullName,
 		Payload: &secretmanagerpb.SecretPayload{
 			Data: []byte(value),
 		},
 	})
 	if err != nil {
-		// If secret doesn't exist, create it first
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			_, createErr := c.api.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
-				Parent:   c.parent(),
-				SecretId: c.prefix + name,
-				Secret: &secretmanagerpb.Secret{
-					Replication: &secretmanagerpb.Replication{
-						Replication: &secretmanagerpb.Replication_Automatic_{
-							Automatic: &secretmanagerpb.Replication_Automatic{},
-						},
-					},
-				},
-			})
-			if createErr != nil {
-				return fmt.Errorf("gcp-secret-manager: failed to create secret %s: %w", name, createErr)
-			}
+		return fmt.Errorf("gcp-secret-manager: failed to add version for %s: %w", secretID, err)
+	}
 
-			// Now add the version
-			_, err = c.api.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
-				Parent: fullName,
-				Payload: &secretmanagerpb.SecretPayload{
-					Data: []byte(value),
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("gcp-secret-manager: failed to add version for %s: %w", name, err)
-			}
-			return nil
+	if opts.KeepVersions > 0 {
+		return c.pruneVersions(ctx, secretID, opts.KeepVersions, opts.Destroy)
+	}
+	return nil
+}
+
+// createSecret creates a secret without versions
+func (c *client) createSecret(ctx context.Context, secretID string, opts secretOptions) error {
+	replication := &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_Automatic_{
+			Automatic: &secretmanagerpb.Replication_Automatic{},
+		},
+	}
+	if len(opts.Locations) > 0 {
+		replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(opts.Locations))
+		for _, location := range opts.Locations {
+			replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+		}
+		replication.Replication = &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+		}
+	}
+
+	_, err := c.api.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+		Parent:   c.parent(),
+		SecretId: secretID,
+		Secret: &secretmanagerpb.Secret{
+			Replication: replication,
+			Labels:      opts.Labels,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("gcp-secret-manager: failed to create secret %s: %w", secretID, err)
+	}
+	return nil
+}
+
+// updateLabels adds labels to a secret that lacks them, keeping its other
+// labels
+func (c *client) updateLabels(ctx context.Context, secret *secretmanagerpb.Secret, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(secret.Labels)+len(labels))
+	maps.Copy(merged, secret.Labels)
+	maps.Copy(merged, labels)
+	if maps.Equal(merged, secret.Labels) {
+		return nil
+	}
+
+	_, err := c.api.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:   secret.Name,
+			Labels: merged,
+			Etag:   secret.Etag,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}},
+	})
+	if err != nil {
+		return fmt.Errorf("gcp-secret-manager: failed to label secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
+
+// pruneVersions disables (or destroys) all but the newest keep versions of
+// a secret. Disabled versions can be re-enabled; destroyed ones are gone.
+func (c *client) pruneVersions(ctx context.Context, secretID string, keep int, destroy bool) error {
+	var versions []*secretmanagerpb.SecretVersion
+	it := c.api.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: c.secretPath(secretID),
+	})
+	for {
+		v, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("gcp-secret-manager: failed to list versions of %s: %w", secretID, err)
+		}
+		if v.State != secretmanagerpb.SecretVersion_DESTROYED {
+			versions = append(versions, v)
 		}
-		return fmt.Errorf("gcp-secret-manager: failed to set secret %s: %w", name, err)
 	}
 
+	// Newest first
+	sort.Slice(versions, func(i, j int) bool {
+		return versionNumber(versions[i].Name) > versionNumber(versions[j].Name)
+	})
+
+	for _, v := range versions[min(keep, len(versions)):] {
+		var err error
+		switch {
+		case destroy:
+			_, err = c.api.DestroySecretVersion(ctx, &secretmanagerpb.DestroySecretVersionRequest{Name: v.Name, Etag: v.Etag})
+		case v.State == secretmanagerpb.SecretVersion_ENABLED:
+			_, err = c.api.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{Name: v.Name, Etag: v.Etag})
+		}
+		if err != nil {
+			return fmt.Errorf("gcp-secret-manager: failed to prune version %s: %w", v.Name, err)
+		}
+	}
 	return nil
 }
 
 // DeleteSecret removes a secret
-func (c *client) DeleteSecret(ctx context.Context, name string) error {
+func (c *client) DeleteSecret(ctx context.Context, secretID string) error {
 	err := c.api.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
-		Name: c.secretPath(name),
+		Name: c.secretPath(secretID),
 	})
 	if err != nil {
 		// Ignore not-found errors on delete
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		if isNotFound(err) {
 			return nil
 		}
-		return fmt.Errorf("gcp-secret-manager: failed to delete secret %s: %w", name, err)
+		return fmt.Errorf("gcp-secret-manager: failed to delete secret %s: %w", secretID, err)
 	}
 	return nil
 }
+
+// versionNumber returns the number at the end of a version resource name
+func versionNumber(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
+
+func isNotFound(err error) bool {
+	st, ok := status.FromError(err)
+	return err != nil && ok && st.Code() == codes.NotFound
+}
@@ -3,6 +3,7 @@ package gcpsm
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 
@@ -20,11 +21,14 @@ func init() {
 	})
 }
 
-// Provider implements the GCP Secret Manager provider
+// Provider implements the GCP Secret Manager provider. Each key is a secret
+// named prefix+key; the prefix may contain {env}, so each remote environment
+// gets its own set of secrets.
 type Provider struct {
 	client  *client
 	project string
 	prefix  string
+	config  map[string]any
 }
 
 // New creates a new GCP Secret Manager provider
@@ -36,6 +40,12 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 
 	prefix, _ := config["prefix"].(string)
 
+	switch oldVersions, _ := config["old_versions"].(string); oldVersions {
+	case "", "disable", "destroy":
+	default:
+		return nil, fmt.Errorf("gcp-secret-manager: old_versions must be disable or destroy, got %q", oldVersions)
+	}
+
 	ctx := context.Background()
 	smClient, err := secretmanager.NewClient(ctx)
 	if err != nil {
@@ -43,9 +53,10 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 	}
 
 	return &Provider{
-		client:  newClient(&gcpClientWrapper{inner: smClient}, project, prefix),
+		client:  newClient(&gcpClientWrapper{inner: smClient}, project),
 		project: project,
 		prefix:  prefix,
+		config:  config,
 	}, nil
 }
 
@@ -53,6 +64,9 @@ func (p *Provider) Name() string        { return "gcp-secret-manager" }
 func (p *Provider) DisplayName() string { return "GCP Secret Manager" }
 
 func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
 	return []string{"default"}
 }
 
@@ -66,8 +80,64 @@ func (p *Provider) Validate(ctx context.Context) error {
 	return p.client.Validate(ctx)
 }
 
+// secretPrefix returns the secret ID prefix of an environment, which may be
+// overridden per environment, with {env} expanded
+func (p *Provider) secretPrefix(environment string) string {
+	prefix := p.prefix
+	if override := provider.EnvSetting(p.config, environment, "prefix"); override != "" {
+		prefix = override
+	}
+	return provider.ExpandEnv(prefix, environment)
+}
+
+// options returns how an environment's secrets are written. Every secret is
+// labelled as managed by dotenvy, with its environment.
+func (p *Provider) options(environment string) secretOptions {
+	labels := map[string]string{
+		"managed-by":  "dotenvy",
+		"dotenvy-env": labelValue(environment),
+	}
+	for k, v := range provider.MapSetting(p.config, "tags", environment) {
+		labels[k] = labelValue(v)
+	}
+
+	opts := secretOptions{Labels: labels}
+	opts.KeepVersions, _ = p.config["keep_versions"].(int)
+	opts.Destroy = p.config["old_versions"] == "destroy"
+	switch locations := p.config["locations"].(type) {
+	case []string:
+		opts.Locations = locations
+	case []any:
+		for _, l := range locations {
+			if s, ok := l.(string); ok {
+				opts.Locations = append(opts.Locations, s)
+			}
+		}
+	}
+	return opts
+}
+
+// labelValue makes s a valid label value: lowercase letters, digits, _ and
+// -, at most 63 characters
+func labelValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, s)
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return s
+}
+
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	data, err := p.client.ListSecrets(ctx)
+	data, err := p.client.ListSecrets(ctx, p.secretPrefix(environment))
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +155,9 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 }
 
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	return p.client.SetSecret(ctx, name, value)
+	return p.client.SetSecret(ctx, p.secretPrefix(environment)+name, value, p.options(environment))
 }
 
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	return p.client.DeleteSecret(ctx, name)
+	return p.client.DeleteSecret(ctx, p.secretPrefix(environment)+name)
 }
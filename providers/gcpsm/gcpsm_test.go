@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -42,6 +44,10 @@ type mockSMClient struct {
 	deleteErr       error
 	deletedSecrets  []string
 	createdSecrets  []string
+	created         map[string]*secretmanagerpb.Secret
+	versions        map[string][]*secretmanagerpb.SecretVersion
+	labelUpdates    int
+	latency         time.Duration // Simulated round trip of value accesses
 }
 
 func newMockSMClient(project string, secrets map[string]string) *mockSMClient {
@@ -49,8 +55,10 @@ func newMockSMClient(project string, secrets map[string]string) *mockSMClient {
 		secrets = make(map[string]string)
 	}
 	return &mockSMClient{
-		secrets: secrets,
-		project: project,
+		secrets:  secrets,
+		project:  project,
+		created:  make(map[string]*secretmanagerpb.Secret),
+		versions: make(map[string][]*secretmanagerpb.SecretVersion),
 	}
 }
 
@@ -74,6 +82,7 @@ func (m *mockSMClient) CreateSecret(ctx context.Context, req *secretmanagerpb.Cr
 	}
 	secretID := req.SecretId
 	m.createdSecrets = append(m.createdSecrets, secretID)
+	m.created[secretID] = req.Secret
 	// Don't set a value yet — that happens in AddSecretVersion
 	return &secretmanagerpb.Secret{
 		Name: fmt.Sprintf("%s/secrets/%s", req.Parent, secretID),
@@ -115,15 +124,19 @@ func (m *mockSMClient) AddSecretVersion(ctx context.Context, req *secretmanagerp
 	}
 
 	m.secrets[secretID] = string(req.Payload.Data)
-	return &secretmanagerpb.SecretVersion{
-		Name: req.Parent + "/versions/1",
-	}, nil
+	v := &secretmanagerpb.SecretVersion{
+		Name:  fmt.Sprintf("%s/versions/%d", req.Parent, len(m.versions[secretID])+1),
+		State: secretmanagerpb.SecretVersion_ENABLED,
+	}
+	m.versions[secretID] = append(m.versions[secretID], v)
+	return v, nil
 }
 
 func (m *mockSMClient) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
 	if m.accessErr != nil {
 		return nil, m.accessErr
 	}
+	time.Sleep(m.latency)
 	// Extract secret name from versions path (projects/*/secrets/NAME/versions/latest)
 	parts := strings.Split(req.Name, "/")
 	// Find "secrets" index and get the next part
@@ -143,6 +156,71 @@ func (m *mockSMClient) AccessSecretVersion(ctx context.Context, req *secretmanag
 	return nil, status.Error(codes.NotFound, "secret not found")
 }
 
+func (m *mockSMClient) GetSecret(ctx context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error) {
+	parts := strings.Split(req.Name, "/")
+	secretID := parts[len(parts)-1]
+	if s, ok := m.created[secretID]; ok {
+		return &secretmanagerpb.Secret{Name: req.Name, Labels: s.Labels}, nil
+	}
+	if _, ok := m.secrets[secretID]; ok {
+		return &secretmanagerpb.Secret{Name: req.Name}, nil
+	}
+	return nil, status.Error(codes.NotFound, "secret not found")
+}
+
+func (m *mockSMClient) UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error) {
+	parts := strings.Split(req.Secret.Name, "/")
+	secretID := parts[len(parts)-1]
+	if m.created[secretID] == nil {
+		m.created[secretID] = &secretmanagerpb.Secret{}
+	}
+	m.created[secretID].Labels = req.Secret.Labels
+	m.labelUpdates++
+	return req.Secret, nil
+}
+
+// mockVersionIterator implements versionIterator for testing
+type mockVersionIterator struct {
+	versions []*secretmanagerpb.SecretVersion
+}
+
+func (m *mockVersionIterator) Next() (*secretmanagerpb.SecretVersion, error) {
+	if len(m.versions) == 0 {
+		return nil, iterator.Done
+	}
+	v := m.versions[0]
+	m.versions = m.versions[1:]
+	return v, nil
+}
+
+func (m *mockSMClient) ListSecretVersions(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) versionIterator {
+	parts := strings.Split(req.Parent, "/")
+	return &mockVersionIterator{versions: append([]*secretmanagerpb.SecretVersion(nil), m.versions[parts[len(parts)-1]]...)}
+}
+
+func (m *mockSMClient) version(name string) *secretmanagerpb.SecretVersion {
+	for _, versions := range m.versions {
+		for _, v := range versions {
+			if v.Name == name {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockSMClient) DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	v := m.version(req.Name)
+	v.State = secretmanagerpb.SecretVersion_DISABLED
+	return v, nil
+}
+
+func (m *mockSMClient) DestroySecretVersion(ctx context.Context, req *secretmanagerpb.DestroySecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	v := m.version(req.Name)
+	v.State = secretmanagerpb.SecretVersion_DESTROYED
+	return v, nil
+}
+
 func (m *mockSMClient) Close() error {
 	return nil
 }
@@ -157,7 +235,7 @@ func TestNew_MissingProject(t *testing.T) {
 func TestProvider_Name(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -171,7 +249,7 @@ func TestProvider_Name(t *testing.T) {
 
 func TestProvider_Environments(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	envs := p.Environments()
 	if len(envs) != 1 || envs[0] != "default" {
@@ -181,7 +259,7 @@ func TestProvider_Environments(t *testing.T) {
 
 func TestProvider_DefaultMapping(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	mapping := p.DefaultMapping()
 	if mapping["default"] != "test" {
@@ -191,7 +269,7 @@ func TestProvider_DefaultMapping(t *testing.T) {
 
 func TestProvider_Validate(t *testing.T) {
 	mock := newMockSMClient("my-project", map[string]string{"SECRET": "val"})
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	if err := p.Validate(context.Background()); err != nil {
 		t.Errorf("Validate() error = %v", err)
@@ -200,7 +278,7 @@ func TestProvider_Validate(t *testing.T) {
 
 func TestProvider_Validate_Empty(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	if err := p.Validate(context.Background()); err != nil {
 		t.Errorf("Validate() error = %v", err)
@@ -210,7 +288,7 @@ func TestProvider_Validate_Empty(t *testing.T) {
 func TestProvider_Validate_Error(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
 	mock.listErr = fmt.Errorf("permission denied")
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	if err := p.Validate(context.Background()); err == nil {
 		t.Error("expected error from Validate()")
@@ -223,7 +301,7 @@ func TestProvider_List(t *testing.T) {
 		"API_KEY": "sk_test_123",
 	})
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -256,7 +334,7 @@ func TestProvider_List_WithPrefix(t *testing.T) {
 		"other_SECRET": "should_be_filtered",
 	})
 	p := &Provider{
-		client:  newClient(mock, "my-project", "myapp_"),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 		prefix:  "myapp_",
 	}
@@ -286,7 +364,7 @@ func TestProvider_List_WithPrefix(t *testing.T) {
 func TestProvider_Set_NewSecret(t *testing.T) {
 	mock := newMockSMClient("my-project", map[string]string{})
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -305,7 +383,7 @@ func TestProvider_Set_ExistingSecret(t *testing.T) {
 		"KEY": "old_value",
 	})
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -322,7 +400,7 @@ func TestProvider_Set_ExistingSecret(t *testing.T) {
 func TestProvider_Set_WithPrefix(t *testing.T) {
 	mock := newMockSMClient("my-project", map[string]string{})
 	p := &Provider{
-		client:  newClient(mock, "my-project", "myapp_"),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 		prefix:  "myapp_",
 	}
@@ -342,7 +420,7 @@ func TestProvider_Delete(t *testing.T) {
 		"KEY": "value",
 	})
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -360,7 +438,7 @@ func TestProvider_Delete_NotFound(t *testing.T) {
 	mock := newMockSMClient("my-project", map[string]string{})
 	mock.deleteErr = status.Error(codes.NotFound, "not found")
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -374,7 +452,7 @@ func TestProvider_Delete_NotFound(t *testing.T) {
 func TestProvider_List_Error(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
 	mock.listErr = fmt.Errorf("permission denied")
-	p := &Provider{client: newClient(mock, "my-project", "")}
+	p := &Provider{client: newClient(mock, "my-project")}
 
 	_, err := p.List(context.Background(), "default")
 	if err == nil {
@@ -386,7 +464,7 @@ func TestProvider_Set_CreateError(t *testing.T) {
 	mock := newMockSMClient("my-project", map[string]string{})
 	mock.createErr = fmt.Errorf("quota exceeded")
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -400,7 +478,7 @@ func TestProvider_Delete_Error(t *testing.T) {
 	mock := newMockSMClient("my-project", nil)
 	mock.deleteErr = fmt.Errorf("permission denied")
 	p := &Provider{
-		client:  newClient(mock, "my-project", ""),
+		client:  newClient(mock, "my-project"),
 		project: "my-project",
 	}
 
@@ -409,3 +487,175 @@ func TestProvider_Delete_Error(t *testing.T) {
 		t.Error("expected error from Delete()")
 	}
 }
+
+func TestProvider_EnvTemplatedPrefix(t *testing.T) {
+	mock := newMockSMClient("my-project", map[string]string{
+		"myapp_staging_DB_URL":    "postgres://staging",
+		"myapp_production_DB_URL": "postgres://prod",
+		"legacy_DB_URL":           "postgres://legacy",
+	})
+	p := &Provider{
+		client:  newClient(mock, "my-project"),
+		project: "my-project",
+		prefix:  "myapp_{env}_",
+		config: map[string]any{
+			"environments": map[string]map[string]string{"legacy": {"prefix": "legacy_"}},
+		},
+	}
+	ctx := context.Background()
+
+	for env, want := range map[string]string{"staging": "postgres://staging", "production": "postgres://prod", "legacy": "postgres://legacy"} {
+		secrets, err := p.List(ctx, env)
+		if err != nil {
+			t.Fatalf("List(%s) error = %v", env, err)
+		}
+		if len(secrets) != 1 || secrets[0].Name != "DB_URL" || secrets[0].Value != want {
+			t.Errorf("List(%s) = %+v", env, secrets)
+		}
+	}
+
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if mock.secrets["myapp_production_API_KEY"] != "sk_live" {
+		t.Errorf("secrets = %v", mock.secrets)
+	}
+}
+
+func TestProvider_Set_ReplicationAndLabels(t *testing.T) {
+	mock := newMockSMClient("my-project", map[string]string{"EXISTING": "val"})
+	p := &Provider{
+		client:  newClient(mock, "my-project"),
+		project: "my-project",
+		config: map[string]any{
+			"locations": []any{"us-east1", "europe-west1"},
+			"tags":      map[string]string{"team": "Shop"},
+		},
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "NEW_KEY", "value", "Production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	created := mock.created["NEW_KEY"]
+	replicas := created.GetReplication().GetUserManaged().GetReplicas()
+	if len(replicas) != 2 || replicas[0].Location != "us-east1" || replicas[1].Location != "europe-west1" {
+		t.Errorf("replication = %v", created.GetReplication())
+	}
+	want := map[string]string{"managed-by": "dotenvy", "dotenvy-env": "production", "team": "shop"}
+	for k, v := range want {
+		if created.Labels[k] != v {
+			t.Errorf("labels = %v, want %v", created.Labels, want)
+			break
+		}
+	}
+
+	// Existing secrets are labelled once
+	if err := p.Set(ctx, "EXISTING", "new", "Production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := p.Set(ctx, "EXISTING", "newer", "Production"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if mock.labelUpdates != 1 || mock.created["EXISTING"].Labels["managed-by"] != "dotenvy" {
+		t.Errorf("label updates = %d, labels = %v", mock.labelUpdates, mock.created["EXISTING"].Labels)
+	}
+}
+
+func TestProvider_Set_SkipsUnchanged(t *testing.T) {
+	mock := newMockSMClient("my-project", map[string]string{"KEY": "value"})
+	p := &Provider{client: newClient(mock, "my-project"), project: "my-project"}
+
+	if err := p.Set(context.Background(), "KEY", "value", "default"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(mock.versions["KEY"]) != 0 {
+		t.Errorf("versions = %v, want none added", mock.versions["KEY"])
+	}
+}
+
+func TestProvider_Set_PrunesOldVersions(t *testing.T) {
+	for _, tt := range []struct {
+		oldVersions string
+		want        secretmanagerpb.SecretVersion_State
+	}{
+		{"", secretmanagerpb.SecretVersion_DISABLED},
+		{"destroy", secretmanagerpb.SecretVersion_DESTROYED},
+	} {
+		mock := newMockSMClient("my-project", map[string]string{})
+		p := &Provider{
+			client:  newClient(mock, "my-project"),
+			project: "my-project",
+			config:  map[string]any{"keep_versions": 2, "old_versions": tt.oldVersions},
+		}
+		for i := range 4 {
+			if err := p.Set(context.Background(), "KEY", fmt.Sprint(i), "default"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+		}
+
+		versions := mock.versions["KEY"]
+		for i, v := range versions {
+			want := tt.want
+			if i >= 2 {
+				want = secretmanagerpb.SecretVersion_ENABLED
+			}
+			if v.State != want {
+				t.Errorf("old_versions %q: version %d state = %s, want %s", tt.oldVersions, i+1, v.State, want)
+			}
+		}
+	}
+}
+
+func TestNew_InvalidOldVersions(t *testing.T) {
+	_, err := New(map[string]any{"project": "my-project", "old_versions": "delete"})
+	if err == nil || !strings.Contains(err.Error(), "old_versions") {
+		t.Errorf("New() error = %v", err)
+	}
+}
+
+func TestProvider_List_Many(t *testing.T) {
+	secrets := make(map[string]string)
+	for i := range 57 {
+		secrets[fmt.Sprintf("KEY_%d", i)] = fmt.Sprint(i)
+	}
+	mock := newMockSMClient("my-project", secrets)
+	p := &Provider{client: newClient(mock, "my-project"), project: "my-project"}
+
+	got, err := p.List(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(got) != 57 {
+		t.Fatalf("List() returned %d secrets, want 57", len(got))
+	}
+	for _, s := range got {
+		if s.Value != secrets[s.Name] {
+			t.Errorf("%s = %q, want %q", s.Name, s.Value, secrets[s.Name])
+		}
+	}
+}
+
+// BenchmarkListSecrets lists 200 secrets with 5ms per value access, one at a
+// time and with the default concurrency
+func BenchmarkListSecrets(b *testing.B) {
+	secrets := make(map[string]string)
+	for i := range 200 {
+		secrets[fmt.Sprintf("KEY_%d", i)] = "value"
+	}
+	mock := newMockSMClient("my-project", secrets)
+	mock.latency = 5 * time.Millisecond
+
+	for _, concurrency := range []int{1, fetchConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			c := newClient(mock, "my-project")
+			c.concurrency = concurrency
+			c.limiter = rate.NewLimiter(rate.Inf, 0)
+			for b.Loop() {
+				if _, err := c.ListSecrets(context.Background(), ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
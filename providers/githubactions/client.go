@@ -0,0 +1,369 @@
+package githubactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	defaultBaseURL = "https://api.github.com"
+	apiVersion     = "2022-11-28"
+	pageSize       = 100
+)
+
+// errNotFound is returned for a 404 from the API
+var errNotFound = errors.New("github: not found")
+
+// Client handles GitHub REST API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*PublicKey // secrets path -> public key
+}
+
+// NewClient creates a new GitHub API client. baseURL is the REST API root,
+// e.g. https://github.example.com/api/v3 for GitHub Enterprise Server.
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{},
+		keys:    make(map[string]*PublicKey),
+	}
+}
+
+// Scope is where secrets and variables live: a repository, one of its
+// deployment environments, or an organization.
+type Scope struct {
+	SecretsPath   string
+	VariablesPath string
+	Org           bool // Organization scopes need a visibility on write
+}
+
+// RepoScope returns the scope for repository-level secrets
+func RepoScope(repo string) Scope {
+	return Scope{
+		SecretsPath:   "/repos/" + repo + "/actions/secrets",
+		VariablesPath: "/repos/" + repo + "/actions/variables",
+	}
+}
+
+// EnvironmentScope returns the scope for a deployment environment
+func EnvironmentScope(repo, environment string) Scope {
+	base := "/repos/" + repo + "/environments/" + url.PathEscape(environment)
+	return Scope{
+		SecretsPath:   base + "/secrets",
+		VariablesPath: base + "/variables",
+	}
+}
+
+// OrgScope returns the scope for organization-level secrets
+func OrgScope(org string) Scope {
+	return Scope{
+		SecretsPath:   "/orgs/" + org + "/actions/secrets",
+		VariablesPath: "/orgs/" + org + "/actions/variables",
+		Org:           true,
+	}
+}
+
+// PublicKey is the key secrets must be encrypted with before upload
+type PublicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"` // base64
+}
+
+// Variable is an Actions variable (readable, unlike secrets)
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ListSecretNames returns the names of the secrets in a scope (values can't
+// be read back)
+func (c *Client) ListSecretNames(ctx context.Context, scope Scope) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		var result struct {
+			Secrets []struct {
+				Name string `json:"name"`
+			} `json:"secrets"`
+		}
+		if err := c.getPage(ctx, scope.SecretsPath, page, &result); err != nil {
+			return nil, err
+		}
+		for _, s := range result.Secrets {
+			names = append(names, s.Name)
+		}
+		if len(result.Secrets) < pageSize {
+			return names, nil
+		}
+	}
+}
+
+// ListVariables returns the variables in a scope
+func (c *Client) ListVariables(ctx context.Context, scope Scope) ([]Variable, error) {
+	var variables []Variable
+	for page := 1; ; page++ {
+		var result struct {
+			Variables []Variable `json:"variables"`
+		}
+		if err := c.getPage(ctx, scope.VariablesPath, page, &result); err != nil {
+			return nil, err
+		}
+		variables = append(variables, result.Variables...)
+		if len(result.Variables) < pageSize {
+			return variables, nil
+		}
+	}
+}
+
+// SetSecret encrypts a value with the scope's public key and stores it.
+// Returns errNotFound if the scope does not exist.
+func (c *Client) SetSecret(ctx context.Context, scope Scope, name, value, visibility string) error {
+	key, err := c.publicKey(ctx, scope)
+	if err != nil {
+		return err
+	}
+	encrypted, err := Encrypt(key.Key, value)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]string{
+		"encrypted_value": encrypted,
+		"key_id":          key.KeyID,
+	}
+	if scope.Org {
+		body["visibility"] = visibility
+	}
+
+	resp, err := c.doJSON(ctx, "PUT", scope.SecretsPath+"/"+name, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// SetVariable updates a variable, creating it if it does not exist.
+// Returns errNotFound if the scope itself does not exist.
+func (c *Client) SetVariable(ctx context.Context, scope Scope, name, value, visibility string) error {
+	body := map[string]string{"name": name, "value": value}
+	if scope.Org {
+		body["visibility"] = visibility
+	}
+
+	resp, err := c.doJSON(ctx, "PATCH", scope.VariablesPath+"/"+name, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return c.parseError(resp)
+	}
+
+	resp, err = c.doJSON(ctx, "POST", scope.VariablesPath, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// DeleteSecret deletes a secret. Returns errNotFound if it does not exist.
+func (c *Client) DeleteSecret(ctx context.Context, scope Scope, name string) error {
+	return c.delete(ctx, scope.SecretsPath+"/"+name)
+}
+
+// DeleteVariable deletes a variable. Returns errNotFound if it does not exist.
+func (c *Client) DeleteVariable(ctx context.Context, scope Scope, name string) error {
+	return c.delete(ctx, scope.VariablesPath+"/"+name)
+}
+
+// CreateEnvironment creates a deployment environment (a no-op if it exists)
+func (c *Client) CreateEnvironment(ctx context.Context, repo, environment string) error {
+	resp, err := c.doRequest(ctx, "PUT", "/repos/"+repo+"/environments/"+url.PathEscape(environment), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// CheckAccess verifies the token can see a repository or organization path
+func (c *Client) CheckAccess(ctx context.Context, path string) error {
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// Encrypt seals a value for GitHub with an anonymous NaCl sealed box
+func Encrypt(publicKey, value string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != 32 {
+		return "", fmt.Errorf("github: invalid public key")
+	}
+	var key [32]byte
+	copy(key[:], raw)
+
+	sealed, err := box.SealAnonymous(nil, []byte(value), &key, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("github: failed to encrypt secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// publicKey returns the scope's encryption key, fetched once per run
+func (c *Client) publicKey(ctx context.Context, scope Scope) (*PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[scope.SecretsPath]; ok {
+		return key, nil
+	}
+
+	resp, err := c.doRequest(ctx, "GET", scope.SecretsPath+"/public-key", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var key PublicKey
+	if err := json.NewDecoder(resp.Body).Decode(&key); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	c.keys[scope.SecretsPath] = &key
+	return &key, nil
+}
+
+func (c *Client) getPage(ctx context.Context, path string, page int, result any) error {
+	endpoint := fmt.Sprintf("%s?per_page=%d&page=%d", path, pageSize, page)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) delete(ctx context.Context, endpoint string) error {
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, endpoint string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.doRequest(ctx, method, endpoint, body)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Message != "" {
+			return fmt.Errorf("github API error: %s", errResp.Message)
+		}
+	}
+
+	return fmt.Errorf("github API error: status %d, body: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,244 @@
+package githubactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "github-actions",
+		DisplayName: "GitHub Actions",
+		Factory:     New,
+		EnvVar:      "GITHUB_TOKEN",
+		Beta:        true,
+	})
+}
+
+const (
+	// RepositoryEnv is the remote environment for repository-level secrets
+	RepositoryEnv = "repository"
+	// OrganizationEnv is the remote environment for organization-level secrets
+	OrganizationEnv = "organization"
+)
+
+// Provider implements the GitHub Actions secrets and variables provider.
+// Sensitive keys are stored as encrypted secrets; keys marked
+// sensitive: false in the schema become readable Actions variables.
+type Provider struct {
+	client     *Client
+	config     map[string]any
+	repo       string // owner/name
+	org        string
+	visibility string // Organization secret visibility: all or private
+}
+
+// New creates a new GitHub Actions provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("github: API token is required")
+	}
+
+	repo, _ := config["repository"].(string)
+	org, _ := config["org"].(string)
+	if repo == "" && org == "" {
+		return nil, fmt.Errorf("github: repository (owner/name) or org is required")
+	}
+	if repo != "" && strings.Count(repo, "/") != 1 {
+		return nil, fmt.Errorf("github: repository must be owner/name, got %q", repo)
+	}
+
+	visibility, _ := config["visibility"].(string)
+	switch visibility {
+	case "":
+		visibility = "private"
+	case "all", "private":
+	default:
+		return nil, fmt.Errorf("github: unsupported visibility %q (use all or private)", visibility)
+	}
+
+	baseURL, _ := config["base_url"].(string)
+
+	return &Provider{
+		client:     NewClient(baseURL, token),
+		config:     config,
+		repo:       repo,
+		org:        org,
+		visibility: visibility,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "github-actions" }
+func (p *Provider) DisplayName() string { return "GitHub Actions" }
+
+// Environments returns the repository scope, each configured deployment
+// environment, and the organization scope if an org is set
+func (p *Provider) Environments() []string {
+	var envs []string
+	if p.repo != "" {
+		envs = append(envs, RepositoryEnv)
+		envs = append(envs, provider.ConfiguredEnvironments(p.config)...)
+	}
+	if p.org != "" {
+		envs = append(envs, OrganizationEnv)
+	}
+	return envs
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	if p.repo == "" {
+		return map[string]string{OrganizationEnv: "test"}
+	}
+	return map[string]string{RepositoryEnv: "test"}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	if p.repo != "" {
+		if err := p.client.CheckAccess(ctx, "/repos/"+p.repo); err != nil {
+			return err
+		}
+	}
+	if p.org != "" {
+		if err := p.client.CheckAccess(ctx, "/orgs/"+p.org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scope maps a remote environment to where its values live
+func (p *Provider) scope(environment string) (Scope, error) {
+	switch {
+	case environment == OrganizationEnv:
+		if p.org == "" {
+			return Scope{}, fmt.Errorf("github: org is required for the %s environment", OrganizationEnv)
+		}
+		return OrgScope(p.org), nil
+	case p.repo == "":
+		return Scope{}, fmt.Errorf("github: repository is required for the %s environment", environment)
+	case environment == RepositoryEnv:
+		return RepoScope(p.repo), nil
+	default:
+		return EnvironmentScope(p.repo, environment), nil
+	}
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	scope, err := p.scope(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	// A deployment environment that doesn't exist yet has nothing in it
+	names, err := p.client.ListSecretNames(ctx, scope)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	variables, err := p.client.ListVariables(ctx, scope)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+
+	// Secrets can't be read back. A key stored as the wrong kind for its
+	// schema, or as both, is reported unreadable too so a sync rewrites it.
+	secrets := make(map[string]bool, len(names))
+	for _, name := range names {
+		secrets[name] = true
+	}
+	var result []model.SecretValue
+	for _, v := range variables {
+		if secrets[v.Name] || p.sensitive(v.Name) {
+			secrets[v.Name] = true
+			continue
+		}
+		result = append(result, model.SecretValue{
+			Name:        v.Name,
+			Value:       v.Value,
+			Environment: environment,
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(secrets)) {
+		result = append(result, model.SecretValue{
+			Name:        name,
+			Environment: environment,
+			Unreadable:  true,
+		})
+	}
+
+	return result, nil
+}
+
+func (p *Provider) sensitive(name string) bool {
+	return provider.SecretSchema(p.config, name).IsSensitive()
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	scope, err := p.scope(environment)
+	if err != nil {
+		return err
+	}
+
+	sensitive := p.sensitive(name)
+	write := func() error {
+		if sensitive {
+			return p.client.SetSecret(ctx, scope, name, value, p.visibility)
+		}
+		return p.client.SetVariable(ctx, scope, name, value, p.visibility)
+	}
+
+	err = write()
+	if errors.Is(err, errNotFound) && environment != RepositoryEnv && environment != OrganizationEnv {
+		// Deployment environments are created on first write
+		if err := p.client.CreateEnvironment(ctx, p.repo, environment); err != nil {
+			return err
+		}
+		err = write()
+	}
+	if errors.Is(err, errNotFound) {
+		return fmt.Errorf("github: %s not found or token lacks access", strings.TrimPrefix(scope.SecretsPath, "/"))
+	}
+	if err != nil {
+		return err
+	}
+
+	// Remove the other kind, left behind when the key's sensitivity changed
+	if sensitive {
+		err = p.client.DeleteVariable(ctx, scope, name)
+	} else {
+		err = p.client.DeleteSecret(ctx, scope, name)
+	}
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Delete removes the key whether it is stored as a secret or a variable
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	scope, err := p.scope(environment)
+	if err != nil {
+		return err
+	}
+
+	if err := p.client.DeleteSecret(ctx, scope, name); err != nil && !errors.Is(err, errNotFound) {
+		return err
+	}
+	if err := p.client.DeleteVariable(ctx, scope, name); err != nil && !errors.Is(err, errNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package githubactions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fakeGitHub is an in-memory stand-in for the Actions secrets and variables
+// REST API. Secrets are decrypted on receipt so tests can check them.
+type fakeGitHub struct {
+	mu           sync.Mutex
+	srv          *httptest.Server
+	pub, priv    *[32]byte
+	secrets      map[string]map[string]string // scope path -> name -> plaintext
+	variables    map[string]map[string]string
+	visibility   map[string]string // name -> visibility sent for org writes
+	environments map[string]bool
+	keyFetches   int
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeGitHub{
+		pub:          pub,
+		priv:         priv,
+		secrets:      make(map[string]map[string]string),
+		variables:    make(map[string]map[string]string),
+		visibility:   make(map[string]string),
+		environments: make(map[string]bool),
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer gh-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+
+	path := r.URL.Path
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	}
+
+	// Deployment environments must exist before anything is stored in them
+	if strings.Contains(path, "/environments/") {
+		rest := path[strings.Index(path, "/environments/")+len("/environments/"):]
+		env := strings.SplitN(rest, "/", 2)[0]
+		if r.Method == "PUT" && !strings.Contains(rest, "/") {
+			f.environments[env] = true
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		if !f.environments[env] {
+			notFound()
+			return
+		}
+	}
+
+	switch {
+	case path == "/repos/acme/web" || path == "/orgs/acme":
+		_, _ = w.Write([]byte(`{}`))
+
+	case strings.HasSuffix(path, "/secrets/public-key"):
+		f.keyFetches++
+		_ = json.NewEncoder(w).Encode(PublicKey{KeyID: "key-1", Key: base64.StdEncoding.EncodeToString(f.pub[:])})
+
+	case strings.HasSuffix(path, "/secrets") && r.Method == "GET":
+		var items []map[string]string
+		for name := range f.secrets[path] {
+			items = append(items, map[string]string{"name": name})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"total_count": len(items), "secrets": items})
+
+	case strings.HasSuffix(path, "/variables") && r.Method == "GET":
+		var items []Variable
+		for name, value := range f.variables[path] {
+			items = append(items, Variable{Name: name, Value: value})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"total_count": len(items), "variables": items})
+
+	case strings.Contains(path, "/secrets/"):
+		scope, name := splitLast(path)
+		switch r.Method {
+		case "PUT":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			sealed, _ := base64.StdEncoding.DecodeString(body["encrypted_value"])
+			plain, ok := box.OpenAnonymous(nil, sealed, f.pub, f.priv)
+			if !ok || body["key_id"] != "key-1" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"message":"Bad encryption"}`))
+				return
+			}
+			if f.secrets[scope] == nil {
+				f.secrets[scope] = make(map[string]string)
+			}
+			f.secrets[scope][name] = string(plain)
+			f.visibility[name] = body["visibility"]
+			w.WriteHeader(http.StatusCreated)
+		case "DELETE":
+			if _, ok := f.secrets[scope][name]; !ok {
+				notFound()
+				return
+			}
+			delete(f.secrets[scope], name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	case strings.HasSuffix(path, "/variables") && r.Method == "POST":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if f.variables[path] == nil {
+			f.variables[path] = make(map[string]string)
+		}
+		f.variables[path][body["name"]] = body["value"]
+		w.WriteHeader(http.StatusCreated)
+
+	case strings.Contains(path, "/variables/"):
+		scope, name := splitLast(path)
+		if _, ok := f.variables[scope][name]; !ok {
+			notFound()
+			return
+		}
+		switch r.Method {
+		case "PATCH":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			f.variables[scope][name] = body["value"]
+		case "DELETE":
+			delete(f.variables[scope], name)
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		notFound()
+	}
+}
+
+func splitLast(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	return path[:i], path[i+1:]
+}
+
+func newTestProvider(t *testing.T, f *fakeGitHub, config map[string]any) *Provider {
+	t.Helper()
+	config["base_url"] = f.srv.URL
+	config["token"] = "gh-token"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return prov.(*Provider)
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"repository": "acme/web"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "x"}); err == nil {
+		t.Error("expected error for missing repository and org")
+	}
+	if _, err := New(map[string]any{"token": "x", "repository": "web"}); err == nil {
+		t.Error("expected error for repository without owner")
+	}
+	if _, err := New(map[string]any{"token": "x", "org": "acme", "visibility": "selected"}); err == nil {
+		t.Error("expected error for unsupported visibility")
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	pub, priv, _ := box.GenerateKey(rand.Reader)
+	encrypted, err := Encrypt(base64.StdEncoding.EncodeToString(pub[:]), "s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(encrypted)
+	plain, ok := box.OpenAnonymous(nil, sealed, pub, priv)
+	if !ok || string(plain) != "s3cret" {
+		t.Errorf("decrypted = %q, %v", plain, ok)
+	}
+
+	if _, err := Encrypt("bm90LWEta2V5", "x"); err == nil {
+		t.Error("expected error for short key")
+	}
+}
+
+func TestProvider_Environments(t *testing.T) {
+	f := newFakeGitHub(t)
+	p := newTestProvider(t, f, map[string]any{
+		"repository": "acme/web",
+		"org":        "acme",
+		"environments": map[string]map[string]string{
+			"production": {},
+			"staging":    {},
+		},
+	})
+
+	got := strings.Join(p.Environments(), ",")
+	if got != "repository,production,staging,organization" {
+		t.Errorf("Environments() = %s", got)
+	}
+}
+
+func TestProvider_SecretsAndVariables(t *testing.T) {
+	f := newFakeGitHub(t)
+	plain := false
+	p := newTestProvider(t, f, map[string]any{
+		"repository": "acme/web",
+		"_schema": map[string]model.Secret{
+			"PUBLIC_URL": {Sensitive: &plain},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_123", RepositoryEnv); err != nil {
+		t.Fatalf("Set secret failed: %v", err)
+	}
+	if err := p.Set(ctx, "DATABASE_URL", "postgres://x", RepositoryEnv); err != nil {
+		t.Fatalf("Set secret failed: %v", err)
+	}
+	if err := p.Set(ctx, "PUBLIC_URL", "https://example.com", RepositoryEnv); err != nil {
+		t.Fatalf("Set variable failed: %v", err)
+	}
+	if err := p.Set(ctx, "PUBLIC_URL", "https://example.org", RepositoryEnv); err != nil {
+		t.Fatalf("Update variable failed: %v", err)
+	}
+
+	secrets := f.secrets["/repos/acme/web/actions/secrets"]
+	if secrets["API_KEY"] != "sk_123" || secrets["DATABASE_URL"] != "postgres://x" {
+		t.Errorf("secrets = %v", secrets)
+	}
+	if v := f.variables["/repos/acme/web/actions/variables"]; v["PUBLIC_URL"] != "https://example.org" {
+		t.Errorf("variables = %v", v)
+	}
+	if f.keyFetches != 1 {
+		t.Errorf("public key fetched %d times, want 1", f.keyFetches)
+	}
+
+	list, err := p.List(ctx, RepositoryEnv)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range list {
+		if s.Unreadable != (s.Name != "PUBLIC_URL") {
+			t.Errorf("%s: Unreadable = %v", s.Name, s.Unreadable)
+		}
+		got[s.Name] = s.Value
+	}
+	if len(got) != 3 || got["API_KEY"] != "" || got["PUBLIC_URL"] != "https://example.org" {
+		t.Errorf("List() = %v", got)
+	}
+
+	if err := p.Delete(ctx, "API_KEY", RepositoryEnv); err != nil {
+		t.Fatalf("Delete secret failed: %v", err)
+	}
+	if err := p.Delete(ctx, "PUBLIC_URL", RepositoryEnv); err != nil {
+		t.Fatalf("Delete variable failed: %v", err)
+	}
+	if err := p.Delete(ctx, "MISSING", RepositoryEnv); err != nil {
+		t.Errorf("Delete of missing key failed: %v", err)
+	}
+	if len(secrets) != 1 || len(f.variables["/repos/acme/web/actions/variables"]) != 0 {
+		t.Errorf("after delete: secrets = %v, variables = %v", secrets, f.variables)
+	}
+}
+
+func TestProvider_SensitivityChange(t *testing.T) {
+	f := newFakeGitHub(t)
+	plain := false
+	schema := map[string]model.Secret{}
+	p := newTestProvider(t, f, map[string]any{"repository": "acme/web", "_schema": schema})
+	ctx := context.Background()
+	secrets := func() map[string]string { return f.secrets["/repos/acme/web/actions/secrets"] }
+	variables := func() map[string]string { return f.variables["/repos/acme/web/actions/variables"] }
+
+	if err := p.Set(ctx, "API_URL", "https://api", RepositoryEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Marked plain: the variable still lists as unreadable until it is
+	// rewritten, which moves it out of secrets
+	schema["API_URL"] = model.Secret{Sensitive: &plain}
+	list, _ := p.List(ctx, RepositoryEnv)
+	if len(list) != 1 || !list[0].Unreadable {
+		t.Errorf("List() = %+v, want API_URL unreadable", list)
+	}
+	if err := p.Set(ctx, "API_URL", "https://api", RepositoryEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if len(secrets()) != 0 || variables()["API_URL"] != "https://api" {
+		t.Errorf("secrets = %v, variables = %v, want API_URL as a variable only", secrets(), variables())
+	}
+	list, _ = p.List(ctx, RepositoryEnv)
+	if len(list) != 1 || list[0].Unreadable || list[0].Value != "https://api" {
+		t.Errorf("List() = %+v, want API_URL readable", list)
+	}
+
+	// And back to sensitive
+	delete(schema, "API_URL")
+	list, _ = p.List(ctx, RepositoryEnv)
+	if len(list) != 1 || !list[0].Unreadable {
+		t.Errorf("List() = %+v, want API_URL unreadable", list)
+	}
+	if err := p.Set(ctx, "API_URL", "https://api", RepositoryEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if secrets()["API_URL"] != "https://api" || len(variables()) != 0 {
+		t.Errorf("secrets = %v, variables = %v, want API_URL as a secret only", secrets(), variables())
+	}
+}
+
+func TestProvider_DeploymentEnvironment(t *testing.T) {
+	f := newFakeGitHub(t)
+	p := newTestProvider(t, f, map[string]any{"repository": "acme/web"})
+	ctx := context.Background()
+
+	// A missing environment lists as empty and is created on first write
+	list, err := p.List(ctx, "production")
+	if err != nil || len(list) != 0 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if !f.environments["production"] {
+		t.Error("production environment was not created")
+	}
+	if v := f.secrets["/repos/acme/web/environments/production/secrets"]["API_KEY"]; v != "sk_live" {
+		t.Errorf("environment secret = %q", v)
+	}
+}
+
+func TestProvider_Organization(t *testing.T) {
+	f := newFakeGitHub(t)
+	p := newTestProvider(t, f, map[string]any{"org": "acme", "visibility": "all"})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "NPM_TOKEN", "npm_x", OrganizationEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.secrets["/orgs/acme/actions/secrets"]["NPM_TOKEN"] != "npm_x" {
+		t.Errorf("org secrets = %v", f.secrets)
+	}
+	if f.visibility["NPM_TOKEN"] != "all" {
+		t.Errorf("visibility = %q, want 'all'", f.visibility["NPM_TOKEN"])
+	}
+
+	if err := p.Set(ctx, "X", "y", RepositoryEnv); err == nil {
+		t.Error("expected error for repository environment without repository")
+	}
+}
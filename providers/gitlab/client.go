@@ -0,0 +1,208 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultBaseURL = "https://gitlab.com"
+	pageSize       = 100
+)
+
+// Client handles GitLab REST API requests
+type Client struct {
+	baseURL string // e.g. https://gitlab.com/api/v4
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new GitLab API client. baseURL is the instance URL,
+// e.g. https://gitlab.example.com for a self-managed instance.
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/api/v4") {
+		baseURL += "/api/v4"
+	}
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Variable is a CI/CD variable
+type Variable struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type,omitempty"`
+	Protected        bool   `json:"protected"`
+	Masked           bool   `json:"masked"`
+	Raw              bool   `json:"raw"`
+	EnvironmentScope string `json:"environment_scope"`
+}
+
+// ProjectPath returns the API path for a project's variables. project is a
+// numeric ID or a full path like group/subgroup/project.
+func ProjectPath(project string) string {
+	return "/projects/" + url.PathEscape(project) + "/variables"
+}
+
+// GroupPath returns the API path for a group's variables
+func GroupPath(group string) string {
+	return "/groups/" + url.PathEscape(group) + "/variables"
+}
+
+// ListVariables returns every variable under a project or group path
+func (c *Client) ListVariables(ctx context.Context, path string) ([]Variable, error) {
+	var variables []Variable
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("%s?per_page=%d&page=%d", path, pageSize, page)
+		resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := c.parseError(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var batch []Variable
+		err = json.NewDecoder(resp.Body).Decode(&batch)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		variables = append(variables, batch...)
+		if len(batch) < pageSize {
+			return variables, nil
+		}
+	}
+}
+
+// SetVariable updates the variable with v's key and environment scope,
+// creating it if it does not exist
+func (c *Client) SetVariable(ctx context.Context, path string, v Variable) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", variablePath(path, v.Key, v.EnvironmentScope), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return c.parseError(resp)
+	}
+
+	resp, err = c.doRequest(ctx, "POST", path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// DeleteVariable deletes a variable in one environment scope. Deleting a
+// missing variable is not an error.
+func (c *Client) DeleteVariable(ctx context.Context, path, key, scope string) error {
+	resp, err := c.doRequest(ctx, "DELETE", variablePath(path, key, scope), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// CheckAccess verifies the token can read a project's or group's variables
+func (c *Client) CheckAccess(ctx context.Context, path string) error {
+	resp, err := c.doRequest(ctx, "GET", path+"?per_page=1", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// variablePath addresses one variable; the scope filter picks between
+// variables that share a key across environments
+func variablePath(path, key, scope string) string {
+	return path + "/" + url.PathEscape(key) + "?filter[environment_scope]=" + url.QueryEscape(scope)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	// message is a string or a map of field errors
+	var errResp struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		switch msg := errResp.Message.(type) {
+		case string:
+			if msg != "" {
+				return fmt.Errorf("gitlab API error: %s", msg)
+			}
+		case map[string]any:
+			var parts []string
+			for field, v := range msg {
+				parts = append(parts, fmt.Sprintf("%s %v", field, v))
+			}
+			return fmt.Errorf("gitlab API error: %s", strings.Join(parts, "; "))
+		}
+		if errResp.Error != "" {
+			return fmt.Errorf("gitlab API error: %s", errResp.Error)
+		}
+	}
+
+	return fmt.Errorf("gitlab API error: status %d, body: %s", resp.StatusCode, string(body))
+}
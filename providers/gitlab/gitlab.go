@@ -0,0 +1,125 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "gitlab",
+		DisplayName: "GitLab CI/CD",
+		Factory:     New,
+		EnvVar:      "GITLAB_TOKEN",
+		Beta:        true,
+	})
+}
+
+// AllEnvironments is GitLab's wildcard environment scope
+const AllEnvironments = "*"
+
+// Provider implements the GitLab CI/CD variables provider. Remote
+// environments are environment scopes, so "*" holds variables that apply
+// everywhere.
+type Provider struct {
+	client *Client
+	config map[string]any
+	path   string // Project or group variables API path
+}
+
+// New creates a new GitLab provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("gitlab: API token is required")
+	}
+
+	project, _ := config["project"].(string)
+	group, _ := config["group"].(string)
+	var path string
+	switch {
+	case project != "" && group != "":
+		return nil, fmt.Errorf("gitlab: set either project or group, not both")
+	case project != "":
+		path = ProjectPath(project)
+	case group != "":
+		path = GroupPath(group)
+	default:
+		return nil, fmt.Errorf("gitlab: project (ID or path) or group is required")
+	}
+
+	baseURL, _ := config["base_url"].(string)
+
+	return &Provider{
+		client: NewClient(baseURL, token),
+		config: config,
+		path:   path,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "gitlab" }
+func (p *Provider) DisplayName() string { return "GitLab CI/CD" }
+
+func (p *Provider) Environments() []string {
+	return append([]string{AllEnvironments}, provider.ConfiguredEnvironments(p.config)...)
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		AllEnvironments: "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	return p.client.CheckAccess(ctx, p.path)
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	variables, err := p.client.ListVariables(ctx, p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for _, v := range variables {
+		if v.EnvironmentScope != environment {
+			continue
+		}
+		secrets = append(secrets, model.SecretValue{
+			Name:        v.Key,
+			Value:       v.Value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	schema := provider.SecretSchema(p.config, name)
+	return p.client.SetVariable(ctx, p.path, Variable{
+		Key:              name,
+		Value:            value,
+		Protected:        schema.Protected,
+		Masked:           schema.IsSensitive() && Maskable(value),
+		Raw:              schema.Raw,
+		EnvironmentScope: environment,
+	})
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.client.DeleteVariable(ctx, p.path, name, environment)
+}
+
+// Maskable reports whether GitLab can mask a value in job logs: it must be
+// a single line of at least 8 characters without spaces.
+func Maskable(value string) bool {
+	return len(value) >= 8 && !strings.ContainsAny(value, " \t\r\n")
+}
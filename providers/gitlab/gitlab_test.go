@@ -0,0 +1,254 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// fakeGitLab is an in-memory stand-in for the CI/CD variables API
+type fakeGitLab struct {
+	mu        sync.Mutex
+	srv       *httptest.Server
+	variables []Variable
+	paths     []string // Request paths, for checking project/group routing
+}
+
+func newFakeGitLab(t *testing.T) *fakeGitLab {
+	f := &fakeGitLab{}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeGitLab) find(key, scope string) int {
+	for i, v := range f.variables {
+		if v.Key == key && v.EnvironmentScope == scope {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *fakeGitLab) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("PRIVATE-TOKEN") != "glpat-test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"401 Unauthorized"}`))
+		return
+	}
+	f.paths = append(f.paths, r.URL.EscapedPath())
+
+	parts := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	// api/v4/{projects|groups}/{id}/variables[/{key}]
+	if len(parts) < 5 || parts[0] != "api" || parts[1] != "v4" || parts[4] != "variables" {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Not Found"}`))
+		return
+	}
+
+	if len(parts) == 5 {
+		switch r.Method {
+		case "GET":
+			_ = json.NewEncoder(w).Encode(f.variables)
+		case "POST":
+			var v Variable
+			_ = json.NewDecoder(r.Body).Decode(&v)
+			if f.find(v.Key, v.EnvironmentScope) >= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"message":{"key":["(` + v.Key + `) has already been taken"]}}`))
+				return
+			}
+			if v.Masked && !Maskable(v.Value) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"message":{"value":["is invalid"]}}`))
+				return
+			}
+			f.variables = append(f.variables, v)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(v)
+		}
+		return
+	}
+
+	key := parts[5]
+	scope := r.URL.Query().Get("filter[environment_scope]")
+	i := f.find(key, scope)
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Variable Not Found"}`))
+		return
+	}
+
+	switch r.Method {
+	case "PUT":
+		var v Variable
+		_ = json.NewDecoder(r.Body).Decode(&v)
+		v.VariableType = f.variables[i].VariableType
+		f.variables[i] = v
+		_ = json.NewEncoder(w).Encode(v)
+	case "DELETE":
+		f.variables = append(f.variables[:i], f.variables[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeGitLab, config map[string]any) *Provider {
+	t.Helper()
+	config["base_url"] = f.srv.URL
+	config["token"] = "glpat-test"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return prov.(*Provider)
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"project": "acme/web"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "x"}); err == nil {
+		t.Error("expected error for missing project and group")
+	}
+	if _, err := New(map[string]any{"token": "x", "project": "acme/web", "group": "acme"}); err == nil {
+		t.Error("expected error for both project and group")
+	}
+}
+
+func TestNewClient_BaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "https://gitlab.com/api/v4",
+		"https://gitlab.example.com/":        "https://gitlab.example.com/api/v4",
+		"https://gitlab.example.com/api/v4":  "https://gitlab.example.com/api/v4",
+		"https://example.com/gitlab/api/v4/": "https://example.com/gitlab/api/v4",
+	}
+	for in, want := range tests {
+		if got := NewClient(in, "x").baseURL; got != want {
+			t.Errorf("NewClient(%q).baseURL = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMaskable(t *testing.T) {
+	tests := map[string]bool{
+		"sk_live_abc123": true,
+		"short":          false,
+		"has a space in": false,
+		"two\nlines____": false,
+	}
+	for value, want := range tests {
+		if got := Maskable(value); got != want {
+			t.Errorf("Maskable(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestProvider_EnvironmentScopes(t *testing.T) {
+	f := newFakeGitLab(t)
+	p := newTestProvider(t, f, map[string]any{"project": "acme/web"})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_test_12345", AllEnvironments); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_live_12345", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	// Update in place
+	if err := p.Set(ctx, "API_KEY", "sk_live_67890", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if len(f.variables) != 2 {
+		t.Fatalf("variables = %+v, want 2", f.variables)
+	}
+	if !strings.Contains(f.paths[0], "/projects/acme%2Fweb/variables") {
+		t.Errorf("project path not escaped: %s", f.paths[0])
+	}
+
+	all, err := p.List(ctx, AllEnvironments)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 1 || all[0].Value != "sk_test_12345" {
+		t.Errorf("List(*) = %+v", all)
+	}
+	prod, _ := p.List(ctx, "production")
+	if len(prod) != 1 || prod[0].Value != "sk_live_67890" {
+		t.Errorf("List(production) = %+v", prod)
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(f.variables) != 1 || f.variables[0].EnvironmentScope != AllEnvironments {
+		t.Errorf("after delete = %+v", f.variables)
+	}
+	if err := p.Delete(ctx, "MISSING", "production"); err != nil {
+		t.Errorf("Delete of missing variable failed: %v", err)
+	}
+}
+
+func TestProvider_SchemaFlags(t *testing.T) {
+	f := newFakeGitLab(t)
+	plain := false
+	p := newTestProvider(t, f, map[string]any{
+		"group": "acme",
+		"_schema": map[string]model.Secret{
+			"DEPLOY_TOKEN": {Protected: true},
+			"TEMPLATE":     {Raw: true, Sensitive: &plain},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "DEPLOY_TOKEN", "glpat-abcdefgh", AllEnvironments); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "TEMPLATE", "Hello $USER", AllEnvironments); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	// Sensitive but too short to mask
+	if err := p.Set(ctx, "PIN", "1234", AllEnvironments); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	byKey := make(map[string]Variable)
+	for _, v := range f.variables {
+		byKey[v.Key] = v
+	}
+	if v := byKey["DEPLOY_TOKEN"]; !v.Protected || !v.Masked || v.Raw {
+		t.Errorf("DEPLOY_TOKEN = %+v, want protected and masked", v)
+	}
+	if v := byKey["TEMPLATE"]; v.Protected || v.Masked || !v.Raw {
+		t.Errorf("TEMPLATE = %+v, want raw and unmasked", v)
+	}
+	if v := byKey["PIN"]; v.Masked {
+		t.Errorf("PIN = %+v, want unmasked", v)
+	}
+	if !strings.Contains(f.paths[0], "/groups/acme/variables") {
+		t.Errorf("group path = %s", f.paths[0])
+	}
+}
+
+func TestProvider_APIError(t *testing.T) {
+	f := newFakeGitLab(t)
+	p := newTestProvider(t, f, map[string]any{"project": "42"})
+	p.client.token = "wrong"
+
+	err := p.Validate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package heroku
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const defaultBaseURL = "https://api.heroku.com"
+
+// Client handles Heroku Platform API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new Heroku API client
+func NewClient(token string) *Client {
+	return &Client{
+		baseURL: defaultBaseURL,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Coupling attaches an app to a pipeline stage
+type Coupling struct {
+	Stage string `json:"stage"`
+	App   struct {
+		ID   string `json:"id"`
+		Name string `json:"name,omitempty"`
+	} `json:"app"`
+}
+
+// AppConfigPath returns the config vars path for an app (name or ID)
+func AppConfigPath(app string) string {
+	return "/apps/" + url.PathEscape(app) + "/config-vars"
+}
+
+// ReviewConfigPath returns the config vars path shared by a pipeline's
+// review apps
+func ReviewConfigPath(pipelineID string) string {
+	return "/pipelines/" + url.PathEscape(pipelineID) + "/stage/review/config-vars"
+}
+
+// GetConfigVars returns the config vars at a config path
+func (c *Client) GetConfigVars(ctx context.Context, path string) (map[string]string, error) {
+	var vars map[string]*string
+	if err := c.do(ctx, "GET", path, nil, &vars); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if v != nil {
+			result[k] = *v
+		}
+	}
+	return result, nil
+}
+
+// PatchConfigVars updates config vars in one request. A nil value removes
+// the variable. Heroku restarts the app once for the whole change.
+func (c *Client) PatchConfigVars(ctx context.Context, path string, vars map[string]*string) error {
+	return c.do(ctx, "PATCH", path, vars, nil)
+}
+
+// GetPipelineID resolves a pipeline name or ID
+func (c *Client) GetPipelineID(ctx context.Context, pipeline string) (string, error) {
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, "GET", "/pipelines/"+url.PathEscape(pipeline), nil, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// ListCouplings returns the apps coupled to a pipeline
+func (c *Client) ListCouplings(ctx context.Context, pipelineID string) ([]Coupling, error) {
+	var couplings []Coupling
+	if err := c.do(ctx, "GET", "/pipelines/"+url.PathEscape(pipelineID)+"/pipeline-couplings", nil, &couplings); err != nil {
+		return nil, err
+	}
+	return couplings, nil
+}
+
+// GetAccount checks that the token is valid
+func (c *Client) GetAccount(ctx context.Context) error {
+	return c.do(ctx, "GET", "/account", nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.heroku+json; version=3")
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Message != "" {
+			return fmt.Errorf("heroku API error: %s", errResp.Message)
+		}
+	}
+
+	return fmt.Errorf("heroku API error: status %d, body: %s", resp.StatusCode, string(body))
+}
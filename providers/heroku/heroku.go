@@ -0,0 +1,199 @@
+package heroku
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "heroku",
+		DisplayName: "Heroku",
+		Factory:     New,
+		EnvVar:      "HEROKU_API_KEY",
+		Beta:        true,
+	})
+}
+
+// Pipeline stages used as remote environments
+var pipelineStages = []string{"review", "staging", "production"}
+
+// Provider implements the Heroku config vars provider. Each remote
+// environment is an app; with a pipeline, stages resolve to their coupled
+// apps and "review" is the pipeline's review app config.
+type Provider struct {
+	client   *Client
+	config   map[string]any
+	pipeline string
+
+	mu         sync.Mutex
+	pipelineID string
+	stageApps  map[string][]string // stage -> coupled app IDs
+}
+
+// New creates a new Heroku provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("heroku: API token is required")
+	}
+
+	pipeline, _ := config["pipeline"].(string)
+	if pipeline == "" {
+		for _, env := range append([]string{"default"}, provider.ConfiguredEnvironments(config)...) {
+			if provider.EnvSetting(config, env, "app_name") == "" {
+				return nil, fmt.Errorf("heroku: app_name or pipeline is required (environment %q)", env)
+			}
+		}
+	}
+
+	return &Provider{
+		client:   NewClient(token),
+		config:   config,
+		pipeline: pipeline,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "heroku" }
+func (p *Provider) DisplayName() string { return "Heroku" }
+
+func (p *Provider) Environments() []string {
+	if p.pipeline != "" {
+		return pipelineStages
+	}
+	return append([]string{"default"}, provider.ConfiguredEnvironments(p.config)...)
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	if p.pipeline != "" {
+		return map[string]string{
+			"review":     "test",
+			"staging":    "test",
+			"production": "live",
+		}
+	}
+	return map[string]string{
+		"default": "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	if err := p.client.GetAccount(ctx); err != nil {
+		return err
+	}
+	if p.pipeline != "" {
+		_, err := p.resolvePipeline(ctx)
+		return err
+	}
+	return nil
+}
+
+// configPath resolves where an environment's config vars live. An app_name
+// set for the environment always wins over the pipeline coupling.
+func (p *Provider) configPath(ctx context.Context, environment string) (string, error) {
+	if app := provider.EnvSetting(p.config, environment, "app_name"); app != "" {
+		return AppConfigPath(app), nil
+	}
+	if p.pipeline == "" {
+		return "", fmt.Errorf("heroku: no app_name for environment %q", environment)
+	}
+
+	pipelineID, err := p.resolvePipeline(ctx)
+	if err != nil {
+		return "", err
+	}
+	if environment == "review" {
+		return ReviewConfigPath(pipelineID), nil
+	}
+
+	apps := p.stageApps[environment]
+	switch len(apps) {
+	case 0:
+		return "", fmt.Errorf("heroku: no app in the %s stage of pipeline %s", environment, p.pipeline)
+	case 1:
+		return AppConfigPath(apps[0]), nil
+	default:
+		return "", fmt.Errorf("heroku: %d apps in the %s stage of pipeline %s, set app_name for this environment", len(apps), environment, p.pipeline)
+	}
+}
+
+// resolvePipeline looks up the pipeline ID and its stage couplings once
+func (p *Provider) resolvePipeline(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pipelineID != "" {
+		return p.pipelineID, nil
+	}
+
+	id, err := p.client.GetPipelineID(ctx, p.pipeline)
+	if err != nil {
+		return "", err
+	}
+	couplings, err := p.client.ListCouplings(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	p.stageApps = make(map[string][]string)
+	for _, c := range couplings {
+		p.stageApps[c.Stage] = append(p.stageApps[c.Stage], c.App.ID)
+	}
+	p.pipelineID = id
+	return id, nil
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	path, err := p.configPath(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := p.client.GetConfigVars(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for name, value := range vars {
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one PATCH, so the app restarts once
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	path, err := p.configPath(ctx, environment)
+	if err != nil {
+		return err
+	}
+
+	vars := make(map[string]*string, len(values))
+	for name, value := range values {
+		vars[name] = &value
+	}
+	return p.client.PatchConfigVars(ctx, path, vars)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	path, err := p.configPath(ctx, environment)
+	if err != nil {
+		return err
+	}
+	return p.client.PatchConfigVars(ctx, path, map[string]*string{name: nil})
+}
@@ -0,0 +1,204 @@
+package heroku
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHeroku is an in-memory stand-in for the Platform API
+type fakeHeroku struct {
+	mu        sync.Mutex
+	srv       *httptest.Server
+	vars      map[string]map[string]string // config path -> vars
+	couplings []Coupling
+	patches   int
+}
+
+func newFakeHeroku(t *testing.T) *fakeHeroku {
+	f := &fakeHeroku{vars: make(map[string]map[string]string)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeHeroku) couple(stage, appID string) {
+	var c Coupling
+	c.Stage = stage
+	c.App.ID = appID
+	f.couplings = append(f.couplings, c)
+}
+
+func (f *fakeHeroku) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer hrku-token" || !strings.Contains(r.Header.Get("Accept"), "version=3") {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"id":"unauthorized","message":"Invalid credentials provided."}`))
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/account":
+		_, _ = w.Write([]byte(`{"email":"dev@example.com"}`))
+
+	case path == "/pipelines/shop":
+		_, _ = w.Write([]byte(`{"id":"pipe-1","name":"shop"}`))
+
+	case path == "/pipelines/pipe-1/pipeline-couplings":
+		_ = json.NewEncoder(w).Encode(f.couplings)
+
+	case strings.HasSuffix(path, "/config-vars"):
+		switch r.Method {
+		case "GET":
+			if f.vars[path] == nil && !strings.HasPrefix(path, "/pipelines/") {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"id":"not_found","message":"Couldn't find that app."}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(f.vars[path])
+		case "PATCH":
+			f.patches++
+			var patch map[string]*string
+			_ = json.NewDecoder(r.Body).Decode(&patch)
+			if f.vars[path] == nil {
+				f.vars[path] = make(map[string]string)
+			}
+			for k, v := range patch {
+				if v == nil {
+					delete(f.vars[path], k)
+				} else {
+					f.vars[path][k] = *v
+				}
+			}
+			_ = json.NewEncoder(w).Encode(f.vars[path])
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"id":"not_found","message":"Not found."}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeHeroku, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "hrku-token"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"app_name": "web"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "x"}); err == nil {
+		t.Error("expected error for missing app_name and pipeline")
+	}
+	if _, err := New(map[string]any{"token": "x", "pipeline": "shop"}); err != nil {
+		t.Errorf("pipeline alone should be enough: %v", err)
+	}
+}
+
+func TestProvider_SingleApp(t *testing.T) {
+	f := newFakeHeroku(t)
+	f.vars["/apps/web/config-vars"] = map[string]string{"EXISTING": "1"}
+	p := newTestProvider(t, f, map[string]any{"app_name": "web"})
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.SetMany(ctx, map[string]string{"API_KEY": "sk_1", "DB_URL": "postgres://x"}, "default"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.patches != 1 {
+		t.Errorf("patches = %d, want 1", f.patches)
+	}
+
+	list, err := p.List(ctx, "default")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("List() = %+v, want 3 vars", list)
+	}
+
+	if err := p.Delete(ctx, "EXISTING", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.vars["/apps/web/config-vars"]["EXISTING"]; ok {
+		t.Error("EXISTING should be deleted")
+	}
+}
+
+func TestProvider_PipelineStages(t *testing.T) {
+	f := newFakeHeroku(t)
+	f.couple("staging", "app-stg")
+	f.couple("production", "app-prd")
+	p := newTestProvider(t, f, map[string]any{"pipeline": "shop"})
+	ctx := context.Background()
+
+	if got := strings.Join(p.Environments(), ","); got != "review,staging,production" {
+		t.Errorf("Environments() = %s", got)
+	}
+
+	for env, value := range map[string]string{"review": "r", "staging": "s", "production": "p"} {
+		if err := p.Set(ctx, "API_KEY", value, env); err != nil {
+			t.Fatalf("Set(%s) failed: %v", env, err)
+		}
+	}
+
+	if f.vars["/pipelines/pipe-1/stage/review/config-vars"]["API_KEY"] != "r" {
+		t.Errorf("review vars = %v", f.vars)
+	}
+	if f.vars["/apps/app-stg/config-vars"]["API_KEY"] != "s" || f.vars["/apps/app-prd/config-vars"]["API_KEY"] != "p" {
+		t.Errorf("stage vars = %v", f.vars)
+	}
+}
+
+func TestProvider_PipelineAmbiguousStage(t *testing.T) {
+	f := newFakeHeroku(t)
+	f.couple("staging", "app-a")
+	f.couple("staging", "app-b")
+	p := newTestProvider(t, f, map[string]any{
+		"pipeline": "shop",
+		"environments": map[string]map[string]string{
+			"production": {"app_name": "shop-prod"},
+		},
+	})
+	ctx := context.Background()
+
+	err := p.Set(ctx, "API_KEY", "x", "staging")
+	if err == nil || !strings.Contains(err.Error(), "set app_name") {
+		t.Errorf("Set() error = %v, want ambiguity error", err)
+	}
+
+	// An explicit app_name overrides the coupling
+	if err := p.Set(ctx, "API_KEY", "x", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.vars["/apps/shop-prod/config-vars"]["API_KEY"] != "x" {
+		t.Errorf("vars = %v", f.vars)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	f := newFakeHeroku(t)
+	p := newTestProvider(t, f, map[string]any{"app_name": "missing"})
+
+	_, err := p.List(context.Background(), "default")
+	if err == nil || !strings.Contains(err.Error(), "Couldn't find that app.") {
+		t.Errorf("List() error = %v", err)
+	}
+}
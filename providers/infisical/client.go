@@ -0,0 +1,164 @@
+package infisical
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultBaseURL = "https://app.infisical.com"
+
+// Client handles Infisical API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new Infisical API client. baseURL is the instance URL,
+// e.g. https://infisical.example.com for a self-hosted instance.
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/api"),
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Scope identifies a folder of secrets in one project environment
+type Scope struct {
+	ProjectID   string
+	Environment string // Environment slug, e.g. dev, staging, prod
+	Path        string // Folder path, "/" for the root
+}
+
+// Secret is a shared (non-personal) secret
+type Secret struct {
+	Key   string `json:"secretKey"`
+	Value string `json:"secretValue"`
+}
+
+// ListSecrets returns the shared secrets in a scope
+func (c *Client) ListSecrets(ctx context.Context, scope Scope) ([]Secret, error) {
+	query := url.Values{
+		"workspaceId": {scope.ProjectID},
+		"environment": {scope.Environment},
+		"secretPath":  {scope.Path},
+	}
+
+	var result struct {
+		Secrets []struct {
+			Secret
+			Type string `json:"type"`
+		} `json:"secrets"`
+	}
+	if err := c.do(ctx, "GET", "/api/v3/secrets/raw?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	var secrets []Secret
+	for _, s := range result.Secrets {
+		if s.Type == "" || s.Type == "shared" {
+			secrets = append(secrets, s.Secret)
+		}
+	}
+	return secrets, nil
+}
+
+// CreateSecrets creates several secrets in one request. Fails if any exists.
+func (c *Client) CreateSecrets(ctx context.Context, scope Scope, secrets []Secret) error {
+	return c.do(ctx, "POST", "/api/v3/secrets/batch/raw", batchBody(scope, secrets), nil)
+}
+
+// UpdateSecrets updates several existing secrets in one request
+func (c *Client) UpdateSecrets(ctx context.Context, scope Scope, secrets []Secret) error {
+	return c.do(ctx, "PATCH", "/api/v3/secrets/batch/raw", batchBody(scope, secrets), nil)
+}
+
+// DeleteSecret deletes a shared secret
+func (c *Client) DeleteSecret(ctx context.Context, scope Scope, name string) error {
+	body := map[string]string{
+		"workspaceId": scope.ProjectID,
+		"environment": scope.Environment,
+		"secretPath":  scope.Path,
+		"type":        "shared",
+	}
+	return c.do(ctx, "DELETE", "/api/v3/secrets/raw/"+url.PathEscape(name), body, nil)
+}
+
+func batchBody(scope Scope, secrets []Secret) map[string]any {
+	return map[string]any{
+		"workspaceId": scope.ProjectID,
+		"environment": scope.Environment,
+		"secretPath":  scope.Path,
+		"secrets":     secrets,
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Message != "" {
+			return fmt.Errorf("infisical API error: %s", errResp.Message)
+		}
+	}
+
+	return fmt.Errorf("infisical API error: status %d, body: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,150 @@
+package infisical
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "infisical",
+		DisplayName: "Infisical",
+		Factory:     New,
+		EnvVar:      "INFISICAL_TOKEN",
+		Beta:        true,
+	})
+}
+
+// Provider implements the Infisical provider. Remote environments are
+// environment slugs in a project; path selects a folder.
+type Provider struct {
+	client *Client
+	config map[string]any
+}
+
+// New creates a new Infisical provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("infisical: API token is required")
+	}
+
+	if projectID, _ := config["project_id"].(string); projectID == "" {
+		return nil, fmt.Errorf("infisical: project_id is required")
+	}
+
+	baseURL, _ := config["base_url"].(string)
+
+	return &Provider{
+		client: NewClient(baseURL, token),
+		config: config,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "infisical" }
+func (p *Provider) DisplayName() string { return "Infisical" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"dev", "staging", "prod"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"dev":     "test",
+		"staging": "test",
+		"prod":    "live",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	_, err := p.client.ListSecrets(ctx, p.scope(p.Environments()[0]))
+	return err
+}
+
+// scope returns where an environment's secrets live. The slug defaults to
+// the environment name and the folder to the root.
+func (p *Provider) scope(environment string) Scope {
+	slug := provider.EnvSetting(p.config, environment, "environment")
+	if slug == "" {
+		slug = environment
+	}
+	path := provider.EnvSetting(p.config, environment, "path")
+	if path == "" {
+		path = "/"
+	}
+	return Scope{
+		ProjectID:   provider.EnvSetting(p.config, environment, "project_id"),
+		Environment: slug,
+		Path:        path,
+	}
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	list, err := p.client.ListSecrets(ctx, p.scope(environment))
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for _, s := range list {
+		secrets = append(secrets, model.SecretValue{
+			Name:        s.Key,
+			Value:       s.Value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany creates new secrets and updates existing ones, one batch request
+// for each
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	scope := p.scope(environment)
+	existing, err := p.client.ListSecrets(ctx, scope)
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		exists[s.Key] = true
+	}
+
+	var creates, updates []Secret
+	for name, value := range values {
+		if exists[name] {
+			updates = append(updates, Secret{Key: name, Value: value})
+		} else {
+			creates = append(creates, Secret{Key: name, Value: value})
+		}
+	}
+
+	if len(creates) > 0 {
+		if err := p.client.CreateSecrets(ctx, scope, creates); err != nil {
+			return err
+		}
+	}
+	if len(updates) > 0 {
+		if err := p.client.UpdateSecrets(ctx, scope, updates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.client.DeleteSecret(ctx, p.scope(environment), name)
+}
@@ -0,0 +1,214 @@
+package infisical
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeInfisical is an in-memory stand-in for the Infisical v3 raw secrets API
+type fakeInfisical struct {
+	mu      sync.Mutex
+	srv     *httptest.Server
+	folders map[string]map[string]string // "project/env/path" -> secrets
+	creates int
+	updates int
+}
+
+func newFakeInfisical(t *testing.T) *fakeInfisical {
+	f := &fakeInfisical{folders: make(map[string]map[string]string)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+type fakeRequest struct {
+	WorkspaceID string   `json:"workspaceId"`
+	Environment string   `json:"environment"`
+	SecretPath  string   `json:"secretPath"`
+	Secrets     []Secret `json:"secrets"`
+}
+
+func (f *fakeInfisical) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer st.test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Token missing"}`))
+		return
+	}
+
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": msg})
+	}
+
+	var req fakeRequest
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		req = fakeRequest{WorkspaceID: q.Get("workspaceId"), Environment: q.Get("environment"), SecretPath: q.Get("secretPath")}
+	} else {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+	folder, ok := f.folders[req.WorkspaceID+"/"+req.Environment+req.SecretPath]
+	if !ok {
+		fail(http.StatusNotFound, "Folder not found")
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/v3/secrets/raw":
+		var out struct {
+			Secrets []map[string]string `json:"secrets"`
+		}
+		for k, v := range folder {
+			out.Secrets = append(out.Secrets, map[string]string{"secretKey": k, "secretValue": v, "type": "shared"})
+		}
+		out.Secrets = append(out.Secrets, map[string]string{"secretKey": "MINE", "secretValue": "x", "type": "personal"})
+		_ = json.NewEncoder(w).Encode(out)
+
+	case r.Method == "POST" && r.URL.Path == "/api/v3/secrets/batch/raw":
+		f.creates++
+		for _, s := range req.Secrets {
+			if _, exists := folder[s.Key]; exists {
+				fail(http.StatusBadRequest, "Secret already exist")
+				return
+			}
+			folder[s.Key] = s.Value
+		}
+		_, _ = w.Write([]byte(`{"secrets":[]}`))
+
+	case r.Method == "PATCH" && r.URL.Path == "/api/v3/secrets/batch/raw":
+		f.updates++
+		for _, s := range req.Secrets {
+			if _, exists := folder[s.Key]; !exists {
+				fail(http.StatusNotFound, "Secret not found")
+				return
+			}
+			folder[s.Key] = s.Value
+		}
+		_, _ = w.Write([]byte(`{"secrets":[]}`))
+
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/api/v3/secrets/raw/"):
+		delete(folder, strings.TrimPrefix(r.URL.Path, "/api/v3/secrets/raw/"))
+		_, _ = w.Write([]byte(`{"secret":{}}`))
+
+	default:
+		fail(http.StatusNotFound, "Route not found")
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeInfisical, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "st.test"
+	config["base_url"] = f.srv.URL
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return prov.(*Provider)
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"project_id": "p1"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "st.test"}); err == nil {
+		t.Error("expected error for missing project_id")
+	}
+}
+
+func TestNewClient_BaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                  "https://app.infisical.com",
+		"https://infisical.example.com/":    "https://infisical.example.com",
+		"https://infisical.example.com/api": "https://infisical.example.com",
+	}
+	for in, want := range tests {
+		if got := NewClient(in, "t").baseURL; got != want {
+			t.Errorf("NewClient(%q).baseURL = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProvider_ListSharedOnly(t *testing.T) {
+	f := newFakeInfisical(t)
+	f.folders["p1/prod/"] = map[string]string{"API_KEY": "sk_live"}
+	p := newTestProvider(t, f, map[string]any{"project_id": "p1"})
+	ctx := context.Background()
+
+	secrets, err := p.List(ctx, "prod")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "API_KEY" || secrets[0].Value != "sk_live" {
+		t.Errorf("List() = %+v", secrets)
+	}
+}
+
+func TestProvider_SetManySplitsCreatesAndUpdates(t *testing.T) {
+	f := newFakeInfisical(t)
+	f.folders["p1/dev/"] = map[string]string{"A": "old", "OLD": "x"}
+	p := newTestProvider(t, f, map[string]any{"project_id": "p1"})
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"A": "1", "B": "2"}, "dev"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.creates != 1 || f.updates != 1 {
+		t.Errorf("creates = %d, updates = %d", f.creates, f.updates)
+	}
+	if f.folders["p1/dev/"]["A"] != "1" || f.folders["p1/dev/"]["B"] != "2" {
+		t.Errorf("secrets = %v", f.folders["p1/dev/"])
+	}
+
+	if err := p.Delete(ctx, "OLD", "dev"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.folders["p1/dev/"]["OLD"]; ok {
+		t.Error("OLD should be deleted")
+	}
+}
+
+func TestProvider_EnvironmentOverrides(t *testing.T) {
+	f := newFakeInfisical(t)
+	f.folders["p1/staging/web"] = map[string]string{}
+	f.folders["p1/prod/web"] = map[string]string{}
+	p := newTestProvider(t, f, map[string]any{
+		"project_id": "p1",
+		"path":       "/web",
+		"environments": map[string]map[string]string{
+			"preview":    {"environment": "staging"},
+			"production": {"environment": "prod"},
+		},
+	})
+	ctx := context.Background()
+
+	if got := strings.Join(p.Environments(), ","); got != "preview,production" {
+		t.Errorf("Environments() = %s", got)
+	}
+	if err := p.Set(ctx, "KEY", "pv", "preview"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if f.folders["p1/staging/web"]["KEY"] != "pv" {
+		t.Errorf("folders = %v", f.folders)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	f := newFakeInfisical(t)
+	p := newTestProvider(t, f, map[string]any{"project_id": "p1"})
+
+	_, err := p.List(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "Folder not found") {
+		t.Errorf("List() error = %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package koyeb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const defaultBaseURL = "https://app.koyeb.com/v1"
+
+// errConflict is returned when a service was redeployed between read and write
+var errConflict = errors.New("koyeb: service was modified concurrently")
+
+// Client handles Koyeb API requests
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a new Koyeb API client
+func NewClient(token string) *Client {
+	return &Client{
+		baseURL: defaultBaseURL,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Service is a Koyeb service with the definition of its latest deployment.
+// The definition is kept as decoded JSON so that fields dotenvy doesn't know
+// about survive a round trip.
+type Service struct {
+	ID                 string
+	Name               string
+	LatestDeploymentID string
+	Definition         map[string]any
+}
+
+// GetService returns a service and its current definition
+func (c *Client) GetService(ctx context.Context, serviceID string) (*Service, error) {
+	var svc struct {
+		Service struct {
+			ID                 string `json:"id"`
+			Name               string `json:"name"`
+			LatestDeploymentID string `json:"latest_deployment_id"`
+		} `json:"service"`
+	}
+	if err := c.do(ctx, "GET", "/services/"+url.PathEscape(serviceID), nil, &svc); err != nil {
+		return nil, err
+	}
+
+	var dep struct {
+		Deployment struct {
+			Definition map[string]any `json:"definition"`
+		} `json:"deployment"`
+	}
+	if err := c.do(ctx, "GET", "/deployments/"+url.PathEscape(svc.Service.LatestDeploymentID), nil, &dep); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		ID:                 svc.Service.ID,
+		Name:               svc.Service.Name,
+		LatestDeploymentID: svc.Service.LatestDeploymentID,
+		Definition:         dep.Deployment.Definition,
+	}, nil
+}
+
+// UpdateService deploys a new definition. It returns errConflict if the
+// service was redeployed since it was read. The API has no precondition for
+// this, so the check is a separate request: it narrows the window for
+// overwriting a concurrent deploy but can't close it.
+func (c *Client) UpdateService(ctx context.Context, svc *Service) error {
+	var current struct {
+		Service struct {
+			LatestDeploymentID string `json:"latest_deployment_id"`
+		} `json:"service"`
+	}
+	if err := c.do(ctx, "GET", "/services/"+url.PathEscape(svc.ID), nil, &current); err != nil {
+		return err
+	}
+	if current.Service.LatestDeploymentID != svc.LatestDeploymentID {
+		return errConflict
+	}
+	return c.do(ctx, "PUT", "/services/"+url.PathEscape(svc.ID), map[string]any{"definition": svc.Definition}, nil)
+}
+
+// FindSecret returns the ID of the secret with the given name, or "" if
+// there is none
+func (c *Client) FindSecret(ctx context.Context, name string) (string, error) {
+	query := url.Values{"name": {name}, "limit": {"1"}}
+	var result struct {
+		Secrets []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"secrets"`
+	}
+	if err := c.do(ctx, "GET", "/secrets?"+query.Encode(), nil, &result); err != nil {
+		return "", err
+	}
+	for _, s := range result.Secrets {
+		if s.Name == name {
+			return s.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// RevealSecret returns a secret's value. ok is false if there is no secret
+// with that name or it isn't a simple secret.
+func (c *Client) RevealSecret(ctx context.Context, name string) (value string, ok bool, err error) {
+	id, err := c.FindSecret(ctx, name)
+	if err != nil || id == "" {
+		return "", false, err
+	}
+	var result struct {
+		Value any `json:"value"`
+	}
+	if err := c.do(ctx, "POST", "/secrets/"+url.PathEscape(id)+"/reveal", map[string]any{}, &result); err != nil {
+		return "", false, err
+	}
+	value, ok = result.Value.(string)
+	return value, ok, nil
+}
+
+// PutSecret creates or updates a simple secret
+func (c *Client) PutSecret(ctx context.Context, name, value string) error {
+	id, err := c.FindSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"name": name, "type": "SIMPLE", "value": value}
+	if id == "" {
+		return c.do(ctx, "POST", "/secrets", body, nil)
+	}
+	return c.do(ctx, "PUT", "/secrets/"+url.PathEscape(id), body, nil)
+}
+
+// DeleteSecret deletes a secret by name. Missing secrets are ignored.
+func (c *Client) DeleteSecret(ctx context.Context, name string) error {
+	id, err := c.FindSecret(ctx, name)
+	if err != nil || id == "" {
+		return err
+	}
+	return c.do(ctx, "DELETE", "/secrets/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Message != "" {
+			return fmt.Errorf("koyeb API error: %s", errResp.Message)
+		}
+	}
+
+	return fmt.Errorf("koyeb API error: status %d, body: %s", resp.StatusCode, string(body))
+}
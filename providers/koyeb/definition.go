@@ -0,0 +1,47 @@
+package koyeb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Env is an environment variable in a service definition. It holds either
+// a plain value or a reference to a Koyeb secret.
+type Env struct {
+	Key    string   `json:"key"`
+	Value  string   `json:"value,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// getEnvs decodes the envs of a service definition
+func getEnvs(def map[string]any) ([]Env, error) {
+	raw, ok := def["env"]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var envs []Env
+	if err := json.Unmarshal(data, &envs); err != nil {
+		return nil, fmt.Errorf("koyeb: unexpected env in service definition: %w", err)
+	}
+	return envs, nil
+}
+
+// setEnvs replaces the envs of a service definition
+func setEnvs(def map[string]any, envs []Env) {
+	if envs == nil {
+		envs = []Env{}
+	}
+	def["env"] = envs
+}
+
+// managedSecretName is the name of the Koyeb secret dotenvy creates for a
+// sensitive key, e.g. web-stripe-key for STRIPE_KEY on service web
+func managedSecretName(service, key string) string {
+	return strings.ToLower(service + "-" + strings.ReplaceAll(key, "_", "-"))
+}
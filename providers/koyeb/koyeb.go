@@ -0,0 +1,288 @@
+package koyeb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "koyeb",
+		DisplayName: "Koyeb",
+		Factory:     New,
+		EnvVar:      "KOYEB_TOKEN",
+		Beta:        true,
+	})
+}
+
+// Provider implements the Koyeb provider. Each remote environment is a
+// service; vars are edited in its definition. Sensitive keys are stored as
+// Koyeb secrets, named after the service and key, and referenced from the
+// definition. Secret values are revealed to compare them.
+type Provider struct {
+	client *Client
+	config map[string]any
+}
+
+// New creates a new Koyeb provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token, _ = config["token"].(string)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("koyeb: API token is required")
+	}
+
+	p := &Provider{
+		client: NewClient(token),
+		config: config,
+	}
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "service_id") == "" {
+			return nil, fmt.Errorf("koyeb: service_id is required for environment %s", env)
+		}
+	}
+	return p, nil
+}
+
+func (p *Provider) Name() string        { return "koyeb" }
+func (p *Provider) DisplayName() string { return "Koyeb" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"production"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"staging":    "test",
+		"production": "live",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	for _, env := range p.Environments() {
+		if _, err := p.client.GetService(ctx, provider.EnvSetting(p.config, env, "service_id")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	svc, err := p.client.GetService(ctx, provider.EnvSetting(p.config, environment, "service_id"))
+	if err != nil {
+		return nil, err
+	}
+	envs, err := getEnvs(svc.Definition)
+	if err != nil {
+		return nil, err
+	}
+	values, err := p.secretValues(ctx, envs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for _, e := range envs {
+		s := model.SecretValue{Name: e.Key, Value: e.Value, Environment: environment}
+		if e.Secret != "" {
+			s.Value, s.Unreadable = values[e.Secret], !hasKey(values, e.Secret)
+		}
+		secrets = append(secrets, s)
+	}
+
+	return secrets, nil
+}
+
+// secretValues reveals the Koyeb secrets referenced by envs, limited to the
+// keys in changes if it isn't nil. Secrets that can't be revealed are left
+// out.
+func (p *Provider) secretValues(ctx context.Context, envs []Env, changes map[string]*string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, e := range envs {
+		if e.Secret == "" || hasKey(values, e.Secret) {
+			continue
+		}
+		if _, ok := changes[e.Key]; changes != nil && !ok {
+			continue
+		}
+		value, ok, err := p.client.RevealSecret(ctx, e.Secret)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values[e.Secret] = value
+		}
+	}
+	return values, nil
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one definition update, so the service
+// redeploys once
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// edit is the outcome of merging changes into a service's envs
+type edit struct {
+	envs    []Env
+	secrets map[string]string // Koyeb secrets to write before deploying
+	orphans []string          // Managed secrets no longer referenced
+	changed bool
+}
+
+// update applies changes to the environment's service with a definition
+// read-modify-write. A nil value deletes the var. Nothing is deployed if
+// the definition would be unchanged.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	serviceID := provider.EnvSetting(p.config, environment, "service_id")
+
+	// Secrets written by an attempt that then hit a conflict still have to
+	// be deployed, so a retry doesn't count them as unchanged
+	written := make(map[string]bool)
+
+	var orphans []string
+	err := provider.RetryOnConflict(errConflict, "koyeb: service "+serviceID, func() error {
+		svc, err := p.client.GetService(ctx, serviceID)
+		if err != nil {
+			return err
+		}
+		envs, err := getEnvs(svc.Definition)
+		if err != nil {
+			return err
+		}
+		current, err := p.secretValues(ctx, envs, changes)
+		if err != nil {
+			return err
+		}
+		for name := range written {
+			delete(current, name)
+		}
+		e := p.merge(svc.Name, envs, current, changes)
+		if !e.changed {
+			return nil
+		}
+
+		names := make([]string, 0, len(e.secrets))
+		for name := range e.secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := p.client.PutSecret(ctx, name, e.secrets[name]); err != nil {
+				return err
+			}
+			written[name] = true
+		}
+
+		setEnvs(svc.Definition, e.envs)
+		orphans = e.orphans
+		return p.client.UpdateService(ctx, svc)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range orphans {
+		if err := p.client.DeleteSecret(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge applies changes to a service's envs, keeping the order and scopes
+// of existing ones. current holds the values of referenced secrets. A
+// sensitive value is left alone if its secret already holds it; otherwise
+// it goes to the managed secret named after the service and key, and the
+// var is pointed at it. Secrets dotenvy doesn't manage are never written, as
+// other services may use them.
+func (p *Provider) merge(service string, envs []Env, current map[string]string, changes map[string]*string) edit {
+	e := edit{secrets: make(map[string]string)}
+	seen := make(map[string]bool, len(envs))
+
+	for _, env := range envs {
+		seen[env.Key] = true
+		managed := managedSecretName(service, env.Key)
+		value, ok := changes[env.Key]
+		switch {
+		case !ok:
+			e.envs = append(e.envs, env)
+		case value == nil:
+			if env.Secret == managed {
+				e.orphans = append(e.orphans, managed)
+			}
+			e.changed = true
+		case p.sensitive(env.Key):
+			if v, ok := current[env.Secret]; ok && env.Secret != "" && v == *value {
+				e.envs = append(e.envs, env)
+				continue
+			}
+			env.Secret, env.Value = managed, ""
+			e.secrets[managed] = *value
+			e.envs = append(e.envs, env)
+			e.changed = true
+		default:
+			if env.Secret == "" && env.Value == *value {
+				e.envs = append(e.envs, env)
+				continue
+			}
+			if env.Secret == managed {
+				e.orphans = append(e.orphans, managed)
+			}
+			env.Secret, env.Value = "", *value
+			e.envs = append(e.envs, env)
+			e.changed = true
+		}
+	}
+
+	var added []string
+	for name, value := range changes {
+		if value != nil && !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		env := Env{Key: name}
+		if p.sensitive(name) {
+			env.Secret = managedSecretName(service, name)
+			e.secrets[env.Secret] = *changes[name]
+		} else {
+			env.Value = *changes[name]
+		}
+		e.envs = append(e.envs, env)
+		e.changed = true
+	}
+
+	return e
+}
+
+func (p *Provider) sensitive(name string) bool {
+	return provider.SecretSchema(p.config, name).IsSensitive()
+}
@@ -0,0 +1,290 @@
+package koyeb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+)
+
+// fakeKoyeb is an in-memory stand-in for the Koyeb API with one service
+type fakeKoyeb struct {
+	mu         sync.Mutex
+	srv        *httptest.Server
+	definition string            // latest deployment definition JSON
+	deployment int               // latest deployment number
+	secrets    map[string]string // name -> value
+	puts       int
+	writes     []string // secrets written
+	raceReads  int      // service reads that trigger a concurrent redeploy
+}
+
+func newFakeKoyeb(t *testing.T, definition string) *fakeKoyeb {
+	f := &fakeKoyeb{definition: definition, secrets: make(map[string]string)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeKoyeb) envs(t *testing.T) []Env {
+	t.Helper()
+	var def map[string]any
+	if err := json.Unmarshal([]byte(f.definition), &def); err != nil {
+		t.Fatal(err)
+	}
+	envs, err := getEnvs(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return envs
+}
+
+func (f *fakeKoyeb) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer koyeb-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":401,"code":"unauthorized","message":"Invalid token"}`))
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/services/svc-1" && r.Method == "GET":
+		fmt.Fprintf(w, `{"service":{"id":"svc-1","name":"web","latest_deployment_id":"dep-%d"}}`, f.deployment)
+		if f.raceReads > 0 {
+			f.raceReads--
+			f.deployment++
+		}
+
+	case path == "/services/svc-1" && r.Method == "PUT":
+		var body struct {
+			Definition json.RawMessage `json:"definition"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.definition = string(body.Definition)
+		f.deployment++
+		f.puts++
+		_, _ = w.Write([]byte(`{"service":{}}`))
+
+	case strings.HasPrefix(path, "/deployments/dep-"):
+		fmt.Fprintf(w, `{"deployment":{"id":"dep","definition":%s}}`, f.definition)
+
+	case path == "/secrets" && r.Method == "GET":
+		name := r.URL.Query().Get("name")
+		var out struct {
+			Secrets []map[string]string `json:"secrets"`
+		}
+		if _, ok := f.secrets[name]; ok {
+			out.Secrets = append(out.Secrets, map[string]string{"id": "id-" + name, "name": name})
+		}
+		_ = json.NewEncoder(w).Encode(out)
+
+	case path == "/secrets" && r.Method == "POST", strings.HasPrefix(path, "/secrets/id-") && r.Method == "PUT":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[body["name"]] = body["value"]
+		f.writes = append(f.writes, body["name"])
+		_, _ = w.Write([]byte(`{"secret":{}}`))
+
+	case strings.HasPrefix(path, "/secrets/id-") && strings.HasSuffix(path, "/reveal") && r.Method == "POST":
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/secrets/id-"), "/reveal")
+		_ = json.NewEncoder(w).Encode(map[string]string{"value": f.secrets[name]})
+
+	case strings.HasPrefix(path, "/secrets/id-") && r.Method == "DELETE":
+		delete(f.secrets, strings.TrimPrefix(path, "/secrets/id-"))
+		_, _ = w.Write([]byte(`{}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"not found"}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeKoyeb, config map[string]any) *Provider {
+	t.Helper()
+	config["token"] = "koyeb-token"
+	config["service_id"] = "svc-1"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+const testDefinition = `{
+	"name": "web",
+	"type": "WEB",
+	"regions": ["fra"],
+	"env": [
+		{"key": "LOG_LEVEL", "value": "info", "scopes": ["region:fra"]},
+		{"key": "DATABASE_URL", "secret": "shared-db"}
+	]
+}`
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{"service_id": "svc-1"}); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"token": "t"}); err == nil {
+		t.Error("expected error for missing service_id")
+	}
+}
+
+func TestProvider_List(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	f.secrets["shared-db"] = "postgres://db"
+	p := newTestProvider(t, f, map[string]any{})
+
+	secrets, err := p.List(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 2 || secrets[0].Value != "info" || secrets[1].Value != "postgres://db" || secrets[1].Unreadable {
+		t.Errorf("List() = %+v", secrets)
+	}
+
+	// A reference to a missing secret can't be read
+	delete(f.secrets, "shared-db")
+	secrets, _ = p.List(context.Background(), "production")
+	if len(secrets) != 2 || !secrets[1].Unreadable {
+		t.Errorf("List() = %+v, want DATABASE_URL unreadable", secrets)
+	}
+}
+
+func TestProvider_SetManyUsesSecrets(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	f.secrets["shared-db"] = "postgres://old"
+	notSensitive := false
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{"LOG_LEVEL": {Sensitive: &notSensitive}},
+	})
+
+	values := map[string]string{"LOG_LEVEL": "debug", "DATABASE_URL": "postgres://new", "STRIPE_KEY": "sk_live"}
+	if err := p.SetMany(context.Background(), values, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("puts = %d, want 1", f.puts)
+	}
+
+	want := []Env{
+		{Key: "LOG_LEVEL", Value: "debug", Scopes: []string{"region:fra"}},
+		{Key: "DATABASE_URL", Secret: "web-database-url"},
+		{Key: "STRIPE_KEY", Secret: "web-stripe-key"},
+	}
+	if got := f.envs(t); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("envs = %v, want %v", got, want)
+	}
+	// The shared secret other services may use is left alone
+	if f.secrets["shared-db"] != "postgres://old" || f.secrets["web-database-url"] != "postgres://new" || f.secrets["web-stripe-key"] != "sk_live" {
+		t.Errorf("secrets = %v", f.secrets)
+	}
+	if !strings.Contains(f.definition, `"regions":["fra"]`) {
+		t.Errorf("definition fields lost: %s", f.definition)
+	}
+}
+
+func TestProvider_UnchangedSkipsDeploy(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	notSensitive := false
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{"LOG_LEVEL": {Sensitive: &notSensitive}},
+	})
+
+	if err := p.Set(context.Background(), "LOG_LEVEL", "info", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 0 {
+		t.Errorf("puts = %d, want 0", f.puts)
+	}
+}
+
+func TestProvider_UnchangedSecretSkipsDeploy(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	f.secrets["shared-db"] = "postgres://db"
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"STRIPE_KEY": "sk_live"}, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	f.puts, f.writes = 0, nil
+
+	// Neither the managed secret nor a shared one holding the same value is
+	// rewritten or redeployed
+	values := map[string]string{"STRIPE_KEY": "sk_live", "DATABASE_URL": "postgres://db"}
+	if err := p.SetMany(ctx, values, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if f.puts != 0 || len(f.writes) != 0 {
+		t.Errorf("puts = %d, secret writes = %v, want none", f.puts, f.writes)
+	}
+
+	// A changed secret value redeploys with the same definition
+	if err := p.Set(ctx, "STRIPE_KEY", "sk_new", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 1 || f.secrets["web-stripe-key"] != "sk_new" {
+		t.Errorf("puts = %d, secrets = %v", f.puts, f.secrets)
+	}
+}
+
+func TestProvider_DeleteRemovesManagedSecret(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "STRIPE_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "STRIPE_KEY", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := p.Delete(ctx, "DATABASE_URL", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.secrets["web-stripe-key"]; ok {
+		t.Error("managed secret should be deleted")
+	}
+	if got := f.envs(t); len(got) != 1 || got[0].Key != "LOG_LEVEL" {
+		t.Errorf("envs = %v", got)
+	}
+}
+
+func TestProvider_ConflictRetries(t *testing.T) {
+	f := newFakeKoyeb(t, testDefinition)
+	p := newTestProvider(t, f, map[string]any{})
+
+	f.raceReads = 1
+	if err := p.Set(context.Background(), "STRIPE_KEY", "sk_live", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("puts = %d, want 1", f.puts)
+	}
+
+	// The retry still deploys a secret the failed attempt already wrote
+	f.raceReads = 1
+	if err := p.Set(context.Background(), "STRIPE_KEY", "sk_other", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.puts != 2 {
+		t.Errorf("puts = %d, want 2", f.puts)
+	}
+
+	f.raceReads = 100
+	err := p.Set(context.Background(), "STRIPE_KEY", "sk_new", "production")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// errConflict is returned when a Secret changed between read and write
+var errConflict = errors.New("kubernetes: secret was modified concurrently")
+
+// managedByLabel marks Secrets created by dotenvy
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// Client handles Kubernetes API requests for one cluster
+type Client struct {
+	access *clusterAccess
+}
+
+// NewClient creates a new Kubernetes API client
+func NewClient(access *clusterAccess) *Client {
+	return &Client{access: access}
+}
+
+// Secret is the subset of a core/v1 Secret dotenvy reads and writes
+type Secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   SecretMetadata    `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"` // encoding/json base64-encodes []byte
+}
+
+// SecretMetadata is the subset of ObjectMeta dotenvy uses
+type SecretMetadata struct {
+	Name            string            `json:"name" yaml:"name"`
+	Namespace       string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty" yaml:"-"`
+	Labels          map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// newSecret returns an empty Opaque Secret labeled as managed by dotenvy
+func newSecret(namespace, name string) *Secret {
+	return &Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: SecretMetadata{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{managedByLabel: "dotenvy"},
+		},
+		Type: "Opaque",
+		Data: make(map[string][]byte),
+	}
+}
+
+// GetSecret reads a Secret. Returns nil if it does not exist.
+func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*Secret, error) {
+	resp, err := c.doRequest(ctx, "GET", secretPath(namespace, name), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var secret Secret
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &secret, nil
+}
+
+// CreateSecret creates a Secret
+func (c *Client) CreateSecret(ctx context.Context, secret *Secret) error {
+	body, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/secrets", secret.Metadata.Namespace)
+	resp, err := c.doRequest(ctx, "POST", endpoint, "application/json", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return errConflict
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// PatchData sets (or with a nil value, removes) one data key. The patch
+// carries resourceVersion, so it fails if the Secret changed since it was read.
+func (c *Client) PatchData(ctx context.Context, namespace, name, resourceVersion, key string, value []byte) error {
+	data := map[string]any{key: nil}
+	if value != nil {
+		data[key] = value
+	}
+	body, err := json.Marshal(map[string]any{
+		"metadata": map[string]string{"resourceVersion": resourceVersion},
+		"data":     data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PATCH", secretPath(namespace, name), "application/merge-patch+json", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return errConflict
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// CanAccess checks that the namespace's Secrets can be listed
+func (c *Client) CanAccess(ctx context.Context, namespace string) error {
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/secrets?limit=1", namespace)
+	resp, err := c.doRequest(ctx, "GET", endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+func secretPath(namespace, name string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, name)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint, contentType string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.access.Server+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	token, err := c.access.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	return c.access.HTTP.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	// Errors are returned as a meta/v1 Status
+	var status struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &status); err == nil {
+		if status.Message != "" {
+			return fmt.Errorf("kubernetes API error: %s", status.Message)
+		}
+	}
+
+	return fmt.Errorf("kubernetes API error: status %d, body: %s", resp.StatusCode, string(body))
+}
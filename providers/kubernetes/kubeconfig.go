@@ -0,0 +1,247 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfig is the subset of a kubeconfig file needed to reach an API server
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Exec                  *execConfig `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// execConfig is a credential plugin (e.g. aws eks get-token, gke-gcloud-auth-plugin)
+type execConfig struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Env     []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// clusterAccess is everything needed to call one cluster as one user
+type clusterAccess struct {
+	Server    string
+	Namespace string // Context default namespace
+	HTTP      *http.Client
+	Token     func() (string, error)
+}
+
+// kubeconfigPath returns the kubeconfig to read: the configured path, the
+// first entry of $KUBECONFIG, or ~/.kube/config.
+func kubeconfigPath(configured string) string {
+	if configured != "" {
+		return expandHome(configured)
+	}
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return strings.Split(env, string(os.PathListSeparator))[0]
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kube", "config")
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// loadClusterAccess reads a kubeconfig and resolves a context (the current
+// context if name is empty).
+func loadClusterAccess(path, contextName string) (*clusterAccess, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: failed to read kubeconfig: %w", err)
+	}
+
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("kubernetes: failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("kubernetes: no context set and kubeconfig has no current-context")
+	}
+
+	// Relative file references are resolved against the kubeconfig's directory
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		p = expandHome(p)
+		if p != "" && !filepath.IsAbs(p) {
+			return filepath.Join(base, p)
+		}
+		return p
+	}
+
+	access := &clusterAccess{}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			access.Namespace = c.Context.Namespace
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubernetes: context %q not found in %s", contextName, path)
+	}
+
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		access.Server = strings.TrimRight(c.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := fileOrData(resolve(c.Cluster.CertificateAuthority), c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: cluster %s CA: %w", clusterName, err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("kubernetes: cluster %s CA is not valid PEM", clusterName)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubernetes: cluster %q not found in %s", clusterName, path)
+	}
+
+	access.Token = func() (string, error) { return "", nil }
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		user := u.User
+
+		cert, err := fileOrData(resolve(user.ClientCertificate), user.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: user %s certificate: %w", userName, err)
+		}
+		key, err := fileOrData(resolve(user.ClientKey), user.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: user %s key: %w", userName, err)
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("kubernetes: user %s client certificate: %w", userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+
+		switch {
+		case user.Token != "":
+			token := user.Token
+			access.Token = func() (string, error) { return token, nil }
+		case user.TokenFile != "":
+			tokenFile := resolve(user.TokenFile)
+			access.Token = func() (string, error) {
+				b, err := os.ReadFile(tokenFile)
+				return strings.TrimSpace(string(b)), err
+			}
+		case user.Exec != nil:
+			// Plugin tokens outlive a dotenvy run, so run the plugin once
+			plugin := user.Exec
+			var token string
+			access.Token = func() (string, error) {
+				if token != "" {
+					return token, nil
+				}
+				t, err := runExecPlugin(plugin)
+				token = t
+				return t, err
+			}
+		}
+	}
+
+	access.HTTP = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return access, nil
+}
+
+// fileOrData returns base64 inline data if set, otherwise the file contents
+func fileOrData(file, data string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+// runExecPlugin runs a client-go credential plugin and returns its token
+func runExecPlugin(plugin *execConfig) (string, error) {
+	cmd := exec.Command(plugin.Command, plugin.Args...)
+	cmd.Env = os.Environ()
+	for _, e := range plugin.Env {
+		cmd.Env = append(cmd.Env, e.Name+"="+e.Value)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("kubernetes: credential plugin %s failed: %w", plugin.Command, err)
+	}
+
+	var cred struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &cred); err != nil || cred.Status.Token == "" {
+		return "", fmt.Errorf("kubernetes: credential plugin %s returned no token", plugin.Command)
+	}
+	return cred.Status.Token, nil
+}
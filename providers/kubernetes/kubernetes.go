@@ -0,0 +1,214 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "kubernetes",
+		DisplayName: "Kubernetes",
+		Factory:     New,
+		SdkAuth:     true, // Uses kubeconfig credentials
+		Beta:        true,
+	})
+}
+
+// Provider implements the Kubernetes Secret provider. Each remote
+// environment is one Secret, either in a cluster (api mode) or in a manifest
+// file on disk (file mode).
+type Provider struct {
+	config     map[string]any
+	fileMode   bool
+	kubeconfig string
+
+	mu      sync.Mutex
+	clients map[string]*Client // context name -> client
+}
+
+// New creates a new Kubernetes provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	mode, _ := config["mode"].(string)
+	switch mode {
+	case "", "api", "file":
+	default:
+		return nil, fmt.Errorf("kubernetes: unknown mode %q (use api or file)", mode)
+	}
+
+	p := &Provider{
+		config:   config,
+		fileMode: mode == "file",
+		clients:  make(map[string]*Client),
+	}
+	kubeconfig, _ := config["kubeconfig"].(string)
+	p.kubeconfig = kubeconfigPath(kubeconfig)
+
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "secret_name") == "" {
+			return nil, fmt.Errorf("kubernetes: secret_name is required (environment %q)", env)
+		}
+		if p.fileMode && provider.EnvSetting(config, env, "path") == "" {
+			return nil, fmt.Errorf("kubernetes: path is required in file mode (environment %q)", env)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Provider) Name() string        { return "kubernetes" }
+func (p *Provider) DisplayName() string { return "Kubernetes" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"default"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"default": "test",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	if p.fileMode {
+		return nil
+	}
+	for _, env := range p.Environments() {
+		client, namespace, _, err := p.target(env)
+		if err != nil {
+			return err
+		}
+		if err := client.CanAccess(ctx, namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// target resolves the client, namespace and Secret name for an environment.
+// The namespace defaults to the kubeconfig context's namespace, then "default".
+func (p *Provider) target(environment string) (*Client, string, string, error) {
+	contextName := provider.EnvSetting(p.config, environment, "context")
+	secretName := provider.EnvSetting(p.config, environment, "secret_name")
+	namespace := provider.EnvSetting(p.config, environment, "namespace")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	client, ok := p.clients[contextName]
+	if !ok {
+		access, err := loadClusterAccess(p.kubeconfig, contextName)
+		if err != nil {
+			return nil, "", "", err
+		}
+		client = NewClient(access)
+		p.clients[contextName] = client
+	}
+
+	if namespace == "" {
+		namespace = client.access.Namespace
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	return client, namespace, secretName, nil
+}
+
+// read returns the environment's Secret, or nil if it does not exist yet
+func (p *Provider) read(ctx context.Context, environment string) (*Secret, error) {
+	if p.fileMode {
+		return readManifest(provider.EnvSetting(p.config, environment, "path"))
+	}
+	client, namespace, name, err := p.target(environment)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetSecret(ctx, namespace, name)
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	secret, err := p.read(ctx, environment)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for name, value := range secret.Data {
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       string(value),
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.update(ctx, environment, name, []byte(value))
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, name, nil)
+}
+
+// update sets one key (or removes it when value is nil)
+func (p *Provider) update(ctx context.Context, environment, key string, value []byte) error {
+	if p.fileMode {
+		return p.updateFile(environment, key, value)
+	}
+
+	client, namespace, name, err := p.target(environment)
+	if err != nil {
+		return err
+	}
+
+	return provider.RetryOnConflict(errConflict, fmt.Sprintf("kubernetes: secret %s/%s", namespace, name), func() error {
+		secret, err := client.GetSecret(ctx, namespace, name)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case secret == nil && value == nil:
+			return nil
+		case secret == nil:
+			secret = newSecret(namespace, name)
+			secret.Data[key] = value
+			err = client.CreateSecret(ctx, secret)
+		default:
+			if _, ok := secret.Data[key]; !ok && value == nil {
+				return nil
+			}
+			err = client.PatchData(ctx, namespace, name, secret.Metadata.ResourceVersion, key, value)
+		}
+		return err
+	})
+}
+
+// updateFile rewrites the environment's manifest with one key changed
+func (p *Provider) updateFile(environment, key string, value []byte) error {
+	path := provider.EnvSetting(p.config, environment, "path")
+	secret, err := readManifest(path)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		namespace := provider.EnvSetting(p.config, environment, "namespace")
+		secret = newSecret(namespace, provider.EnvSetting(p.config, environment, "secret_name"))
+	}
+
+	if value == nil {
+		delete(secret.Data, key)
+	} else {
+		secret.Data[key] = value
+	}
+
+	return writeManifest(path, secret, provider.EnvSetting(p.config, environment, "sealed_secret"))
+}
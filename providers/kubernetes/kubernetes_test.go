@@ -0,0 +1,393 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAPIServer is an in-memory stand-in for the core/v1 Secrets API
+type fakeAPIServer struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	secrets  map[string]*Secret // "namespace/name" -> Secret
+	version  int
+	races    int // PATCHes that fail with a conflict before one succeeds
+	lastAuth string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	f := &fakeAPIServer{secrets: make(map[string]*Secret)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{"kind": "Status", "code": code, "message": message})
+}
+
+func (f *fakeAPIServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastAuth = r.Header.Get("Authorization")
+	if f.lastAuth != "Bearer kube-token" {
+		writeStatus(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// /api/v1/namespaces/{ns}/secrets[/{name}]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[2] != "namespaces" || parts[4] != "secrets" {
+		writeStatus(w, http.StatusNotFound, "no route")
+		return
+	}
+	namespace := parts[3]
+
+	if len(parts) == 5 {
+		switch r.Method {
+		case "GET":
+			_, _ = w.Write([]byte(`{"kind":"SecretList","items":[]}`))
+		case "POST":
+			var secret Secret
+			_ = json.NewDecoder(r.Body).Decode(&secret)
+			key := namespace + "/" + secret.Metadata.Name
+			if _, ok := f.secrets[key]; ok {
+				writeStatus(w, http.StatusConflict, "already exists")
+				return
+			}
+			f.version++
+			secret.Metadata.ResourceVersion = strconv.Itoa(f.version)
+			f.secrets[key] = &secret
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(secret)
+		}
+		return
+	}
+
+	key := namespace + "/" + parts[5]
+	secret, ok := f.secrets[key]
+	if !ok {
+		writeStatus(w, http.StatusNotFound, fmt.Sprintf("secrets %q not found", parts[5]))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		_ = json.NewEncoder(w).Encode(secret)
+	case "PATCH":
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			writeStatus(w, http.StatusUnsupportedMediaType, "unsupported patch type")
+			return
+		}
+		var patch struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+			} `json:"metadata"`
+			Data map[string]*[]byte `json:"data"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&patch)
+
+		if f.races > 0 {
+			// Someone else wrote in between
+			f.races--
+			f.version++
+			secret.Metadata.ResourceVersion = strconv.Itoa(f.version)
+		}
+		if patch.Metadata.ResourceVersion != secret.Metadata.ResourceVersion {
+			writeStatus(w, http.StatusConflict, "the object has been modified")
+			return
+		}
+
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		for k, v := range patch.Data {
+			if v == nil {
+				delete(secret.Data, k)
+			} else {
+				secret.Data[k] = *v
+			}
+		}
+		f.version++
+		secret.Metadata.ResourceVersion = strconv.Itoa(f.version)
+		_ = json.NewEncoder(w).Encode(secret)
+	}
+}
+
+// writeKubeconfig writes a kubeconfig with one context per namespace entry
+func writeKubeconfig(t *testing.T, server string, contexts map[string]string) string {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: v1\nkind: Config\ncurrent-context: dev\n")
+	fmt.Fprintf(&b, "clusters:\n- name: test\n  cluster:\n    server: %s\n", server)
+	fmt.Fprintf(&b, "users:\n- name: test\n  user:\n    token: kube-token\n")
+	fmt.Fprintf(&b, "contexts:\n")
+	for name, namespace := range contexts {
+		fmt.Fprintf(&b, "- name: %s\n  context:\n    cluster: test\n    user: test\n    namespace: %s\n", name, namespace)
+	}
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func listValues(t *testing.T, p *Provider, env string) map[string]string {
+	t.Helper()
+	secrets, err := p.List(context.Background(), env)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	return got
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(map[string]any{}); err == nil {
+		t.Error("expected error for missing secret_name")
+	}
+	if _, err := New(map[string]any{"secret_name": "app", "mode": "helm"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if _, err := New(map[string]any{"secret_name": "app", "mode": "file"}); err == nil {
+		t.Error("expected error for file mode without path")
+	}
+}
+
+func TestProvider_API_SetListDelete(t *testing.T) {
+	f := newFakeAPIServer(t)
+	kubeconfig := writeKubeconfig(t, f.srv.URL, map[string]string{"dev": "dev-ns"})
+	prov, err := New(map[string]any{"secret_name": "app-env", "kubeconfig": kubeconfig})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	// A missing Secret lists as empty
+	if got := listValues(t, p, "default"); len(got) != 0 {
+		t.Errorf("List() = %v, want empty", got)
+	}
+
+	// The first write creates the Secret in the context's namespace
+	if err := p.Set(ctx, "DATABASE_URL", "postgres://x", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	created := f.secrets["dev-ns/app-env"]
+	if created == nil {
+		t.Fatalf("secret not created, have %v", f.secrets)
+	}
+	if created.Metadata.Labels[managedByLabel] != "dotenvy" {
+		t.Errorf("labels = %v", created.Metadata.Labels)
+	}
+
+	if err := p.Set(ctx, "API_KEY", "sk_123", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got := listValues(t, p, "default")
+	if len(got) != 2 || got["DATABASE_URL"] != "postgres://x" || got["API_KEY"] != "sk_123" {
+		t.Errorf("List() = %v", got)
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := listValues(t, p, "default"); len(got) != 1 || got["DATABASE_URL"] == "" {
+		t.Errorf("List() after delete = %v", got)
+	}
+
+	// Deleting a missing key is not an error
+	if err := p.Delete(ctx, "MISSING", "default"); err != nil {
+		t.Errorf("Delete of missing key failed: %v", err)
+	}
+}
+
+func TestProvider_API_PerEnvironmentContext(t *testing.T) {
+	f := newFakeAPIServer(t)
+	kubeconfig := writeKubeconfig(t, f.srv.URL, map[string]string{"dev": "dev-ns", "prod": "prod-ns"})
+	prov, err := New(map[string]any{
+		"secret_name": "app-env",
+		"kubeconfig":  kubeconfig,
+		"environments": map[string]map[string]string{
+			"staging":    {"context": "dev", "namespace": "staging"},
+			"production": {"context": "prod"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "stg", "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "prd", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if s := f.secrets["staging/app-env"]; s == nil || string(s.Data["API_KEY"]) != "stg" {
+		t.Errorf("staging secret = %+v", s)
+	}
+	if s := f.secrets["prod-ns/app-env"]; s == nil || string(s.Data["API_KEY"]) != "prd" {
+		t.Errorf("production secret = %+v", s)
+	}
+}
+
+func TestProvider_API_ConflictRetry(t *testing.T) {
+	f := newFakeAPIServer(t)
+	kubeconfig := writeKubeconfig(t, f.srv.URL, map[string]string{"dev": "default"})
+	prov, err := New(map[string]any{"secret_name": "app-env", "kubeconfig": kubeconfig})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "A", "1", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Two concurrent writers, then success
+	f.races = 2
+	if err := p.Set(ctx, "B", "2", "default"); err != nil {
+		t.Fatalf("Set with conflicts failed: %v", err)
+	}
+	if got := listValues(t, p, "default"); got["B"] != "2" {
+		t.Errorf("List() = %v", got)
+	}
+
+	// A Secret that never settles gives up
+	f.races = 100
+	err = p.Set(ctx, "C", "3", "default")
+	if err == nil || !strings.Contains(err.Error(), "kept changing") {
+		t.Errorf("Set() error = %v, want conflict error", err)
+	}
+}
+
+func TestProvider_File_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k8s", "secret.yaml")
+	prov, err := New(map[string]any{
+		"secret_name": "app-env",
+		"namespace":   "web",
+		"mode":        "file",
+		"path":        path,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	ctx := context.Background()
+
+	if err := p.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.Set(ctx, "DATABASE_URL", "postgres://x", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", "sk_123", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "API_KEY", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"kind: Secret", "name: app-env", "namespace: web", "DATABASE_URL: cG9zdGdyZXM6Ly94"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("manifest missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "API_KEY") {
+		t.Errorf("deleted key still in manifest:\n%s", content)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("manifest mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if got := listValues(t, p, "default"); len(got) != 1 || got["DATABASE_URL"] != "postgres://x" {
+		t.Errorf("List() = %v", got)
+	}
+}
+
+func TestProvider_File_StringData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.yaml")
+	manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-env
+data:
+  A: b2xk
+stringData:
+  A: new
+  B: plain
+`
+	if err := os.WriteFile(path, []byte(manifest), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prov, err := New(map[string]any{"secret_name": "app-env", "mode": "file", "path": path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	got := listValues(t, prov.(*Provider), "default")
+	if got["A"] != "new" || got["B"] != "plain" {
+		t.Errorf("List() = %v", got)
+	}
+}
+
+func TestProvider_File_Sealed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.yaml")
+	prov, err := New(map[string]any{
+		"secret_name":   "app-env",
+		"mode":          "file",
+		"path":          path,
+		"sealed_secret": "namespace-wide",
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := prov.Set(context.Background(), "API_KEY", "x", "default"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "# Plaintext Secret") || !strings.Contains(content, "kubeseal") {
+		t.Errorf("missing kubeseal header:\n%s", content)
+	}
+	if !strings.Contains(content, "sealedsecrets.bitnami.com/namespace-wide") {
+		t.Errorf("missing scope annotation:\n%s", content)
+	}
+
+	// An unknown scope is rejected
+	prov, _ = New(map[string]any{"secret_name": "app-env", "mode": "file", "path": path, "sealed_secret": "global"})
+	if err := prov.Set(context.Background(), "API_KEY", "x", "default"); err == nil {
+		t.Error("expected error for unknown sealed_secret scope")
+	}
+}
@@ -0,0 +1,124 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SealedSecret scopes understood by kubeseal
+var sealedScopes = map[string]string{
+	"strict":         "",
+	"namespace-wide": "sealedsecrets.bitnami.com/namespace-wide",
+	"cluster-wide":   "sealedsecrets.bitnami.com/cluster-wide",
+}
+
+// manifest is the on-disk YAML form of a Secret
+type manifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   SecretMetadata    `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"` // base64
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+// readManifest loads a Secret manifest. Returns nil if the file does not exist.
+func readManifest(path string) (*Secret, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: failed to read %s: %w", path, err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("kubernetes: failed to parse %s: %w", path, err)
+	}
+	if m.Kind != "" && m.Kind != "Secret" {
+		return nil, fmt.Errorf("kubernetes: %s is a %s, not a Secret", path, m.Kind)
+	}
+
+	secret := newSecret(m.Metadata.Namespace, m.Metadata.Name)
+	secret.Metadata = m.Metadata
+	if m.Type != "" {
+		secret.Type = m.Type
+	}
+	for k, v := range m.Data {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: %s: data.%s is not valid base64", path, k)
+		}
+		secret.Data[k] = decoded
+	}
+	// stringData wins over data, as it does on the API server
+	for k, v := range m.StringData {
+		secret.Data[k] = []byte(v)
+	}
+
+	return secret, nil
+}
+
+// writeManifest writes a Secret manifest readable only by the owner. With a
+// sealed scope, the manifest is annotated for kubeseal and a reminder to seal
+// it is added.
+func writeManifest(path string, secret *Secret, sealedScope string) error {
+	m := manifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   secret.Metadata,
+		Type:       secret.Type,
+		Data:       make(map[string]string, len(secret.Data)),
+	}
+	for k, v := range secret.Data {
+		m.Data[k] = base64.StdEncoding.EncodeToString(v)
+	}
+
+	var header string
+	if sealedScope != "" {
+		annotation, ok := sealedScopes[sealedScope]
+		if !ok {
+			return fmt.Errorf("kubernetes: unknown sealed_secret scope %q (use strict, namespace-wide or cluster-wide)", sealedScope)
+		}
+		annotations := make(map[string]string)
+		for k, v := range m.Metadata.Annotations {
+			annotations[k] = v
+		}
+		for _, a := range sealedScopes {
+			delete(annotations, a)
+		}
+		if annotation != "" {
+			annotations[annotation] = "true"
+		}
+		m.Metadata.Annotations = annotations
+		if len(annotations) == 0 {
+			m.Metadata.Annotations = nil
+		}
+		header = fmt.Sprintf("# Plaintext Secret - seal it before committing:\n#   kubeseal --format yaml < %s > %s\n",
+			filepath.Base(path), "sealed-"+filepath.Base(path))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("kubernetes: failed to encode manifest: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("kubernetes: failed to create %s: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("kubernetes: failed to write %s: %w", path, err)
+	}
+	return nil
+}
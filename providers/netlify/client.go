@@ -10,10 +10,11 @@ import (
 	"net/url"
 )
 
-const baseURL = "https://api.netlify.com/api/v1"
+const defaultBaseURL = "https://api.netlify.com/api/v1"
 
 // Client handles Netlify API requests
 type Client struct {
+	baseURL   string
 	token     string
 	accountID string
 	siteID    string
@@ -23,6 +24,7 @@ type Client struct {
 // NewClient creates a new Netlify API client
 func NewClient(token, accountID, siteID string) *Client {
 	return &Client{
+		baseURL:   defaultBaseURL,
 		token:     token,
 		accountID: accountID,
 		siteID:    siteID,
@@ -32,45 +34,30 @@ func NewClient(token, accountID, siteID string) *Client {
 
 // EnvVar represents a Netlify environment variable
 type EnvVar struct {
-	Key    string         `json:"key"`
-	Scopes []string       `json:"scopes"`
-	Values []EnvVarValue  `json:"values"`
+	Key      string        `json:"key"`
+	Scopes   []string      `json:"scopes"`
+	Values   []EnvVarValue `json:"values"`
+	IsSecret bool          `json:"is_secret,omitempty"` // Values can't be read back
 }
 
 // EnvVarValue represents a context-specific value for a Netlify env var
 type EnvVarValue struct {
-	ID      string `json:"id,omitempty"`
-	Value   string `json:"value"`
-	Context string `json:"context"`
+	ID               string `json:"id,omitempty"`
+	Value            string `json:"value"`
+	Context          string `json:"context"`
+	ContextParameter string `json:"context_parameter,omitempty"` // Branch name for the branch context
 }
 
 // ListEnvVars retrieves all environment variables, filtered by deploy context
 func (c *Client) ListEnvVars(ctx context.Context, deployContext string) ([]EnvVar, error) {
-	endpoint := fmt.Sprintf("/accounts/%s/env", c.accountID)
-
 	params := url.Values{}
-	if c.siteID != "" {
-		params.Set("site_id", c.siteID)
-	}
 	if deployContext != "" {
 		params.Set("context_name", deployContext)
 	}
 
-	fullURL := baseURL + endpoint
-	if len(params) > 0 {
-		fullURL += "?" + params.Encode()
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.http.Do(req)
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/accounts/%s/env", c.accountID), params, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -86,47 +73,16 @@ func (c *Client) ListEnvVars(ctx context.Context, deployContext string) ([]EnvVa
 	return result, nil
 }
 
-// SetEnvVar creates or updates an environment variable for a specific context
-func (c *Client) SetEnvVar(ctx context.Context, key, value, deployContext string) error {
-	// Check if the env var already exists
-	existing, err := c.getEnvVar(ctx, key)
-	if err != nil {
-		// If not found, create new
-		return c.createEnvVar(ctx, key, value, deployContext)
-	}
-
-	// Update existing: replace the value for this context
-	return c.updateEnvVar(ctx, key, value, deployContext, existing)
-}
-
-func (c *Client) getEnvVar(ctx context.Context, key string) (*EnvVar, error) {
-	endpoint := fmt.Sprintf("/accounts/%s/env/%s", c.accountID, url.PathEscape(key))
-
-	params := url.Values{}
-	if c.siteID != "" {
-		params.Set("site_id", c.siteID)
-	}
-
-	fullURL := baseURL + endpoint
-	if len(params) > 0 {
-		fullURL += "?" + params.Encode()
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+// GetEnvVar retrieves an environment variable, or nil if it doesn't exist
+func (c *Client) GetEnvVar(ctx context.Context, key string) (*EnvVar, error) {
+	resp, err := c.doRequest(ctx, "GET", c.envPath(key), nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("env var not found")
+		return nil, nil
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, c.parseError(resp)
@@ -140,45 +96,53 @@ func (c *Client) getEnvVar(ctx context.Context, key string) (*EnvVar, error) {
 	return &result, nil
 }
 
-func (c *Client) createEnvVar(ctx context.Context, key, value, deployContext string) error {
-	endpoint := fmt.Sprintf("/accounts/%s/env", c.accountID)
+// CreateEnvVar creates an environment variable
+func (c *Client) CreateEnvVar(ctx context.Context, env EnvVar) error {
+	return c.write(ctx, "POST", fmt.Sprintf("/accounts/%s/env", c.accountID), []EnvVar{env})
+}
 
-	params := url.Values{}
-	if c.siteID != "" {
-		params.Set("site_id", c.siteID)
-	}
+// UpdateEnvVar replaces an environment variable's scopes, values and secret
+// flag
+func (c *Client) UpdateEnvVar(ctx context.Context, env EnvVar) error {
+	return c.write(ctx, "PUT", c.envPath(env.Key), env)
+}
 
-	envVars := []EnvVar{
-		{
-			Key:    key,
-			Scopes: []string{"builds", "functions", "runtime", "post_processing"},
-			Values: []EnvVarValue{
-				{Value: value, Context: deployContext},
-			},
-		},
-	}
+// SetEnvVarValue sets the value for one deploy context, leaving the others
+// alone
+func (c *Client) SetEnvVarValue(ctx context.Context, key string, value EnvVarValue) error {
+	value.ID = ""
+	return c.write(ctx, "PATCH", c.envPath(key), value)
+}
 
-	body, err := json.Marshal(envVars)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
+// DeleteEnvVar deletes an environment variable
+func (c *Client) DeleteEnvVar(ctx context.Context, key string) error {
+	return c.delete(ctx, c.envPath(key))
+}
 
-	fullURL := baseURL + endpoint
-	if len(params) > 0 {
-		fullURL += "?" + params.Encode()
-	}
+// DeleteEnvVarValue deletes the value for one deploy context
+func (c *Client) DeleteEnvVarValue(ctx context.Context, key, valueID string) error {
+	return c.delete(ctx, c.envPath(key)+"/value/"+url.PathEscape(valueID))
+}
+
+// ValidateToken checks if the API token is valid
+func (c *Client) ValidateToken(ctx context.Context) error {
+	_, err := c.ListEnvVars(ctx, "")
+	return err
+}
+
+func (c *Client) envPath(key string) string {
+	return fmt.Sprintf("/accounts/%s/env/%s", c.accountID, url.PathEscape(key))
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(body))
+func (c *Client) write(ctx context.Context, method, endpoint string, payload any) error {
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(req)
+	resp, err := c.doRequest(ctx, method, endpoint, nil, body)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -189,104 +153,53 @@ func (c *Client) createEnvVar(ctx context.Context, key, value, deployContext str
 	return nil
 }
 
-func (c *Client) updateEnvVar(ctx context.Context, key, value, deployContext string, existing *EnvVar) error {
-	endpoint := fmt.Sprintf("/accounts/%s/env/%s", c.accountID, url.PathEscape(key))
-
-	params := url.Values{}
-	if c.siteID != "" {
-		params.Set("site_id", c.siteID)
-	}
-
-	// Build updated values: replace or add the context value
-	var values []EnvVarValue
-	found := false
-	for _, v := range existing.Values {
-		if v.Context == deployContext {
-			values = append(values, EnvVarValue{Value: value, Context: deployContext})
-			found = true
-		} else {
-			values = append(values, v)
-		}
-	}
-	if !found {
-		values = append(values, EnvVarValue{Value: value, Context: deployContext})
-	}
-
-	update := EnvVar{
-		Key:    key,
-		Scopes: existing.Scopes,
-		Values: values,
-	}
-
-	body, err := json.Marshal(update)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	fullURL := baseURL + endpoint
-	if len(params) > 0 {
-		fullURL += "?" + params.Encode()
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", fullURL, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(req)
+func (c *Client) delete(ctx context.Context, endpoint string) error {
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil, nil)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		return c.parseError(resp)
 	}
 
 	return nil
 }
 
-// DeleteEnvVar deletes an environment variable
-func (c *Client) DeleteEnvVar(ctx context.Context, key string) error {
-	endpoint := fmt.Sprintf("/accounts/%s/env/%s", c.accountID, url.PathEscape(key))
-
-	params := url.Values{}
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, body []byte) (*http.Response, error) {
+	if params == nil {
+		params = url.Values{}
+	}
 	if c.siteID != "" {
 		params.Set("site_id", c.siteID)
 	}
 
-	fullURL := baseURL + endpoint
+	fullURL := c.baseURL + endpoint
 	if len(params) > 0 {
 		fullURL += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", fullURL, nil)
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return c.parseError(resp)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
-
-	return nil
-}
-
-// ValidateToken checks if the API token is valid
-func (c *Client) ValidateToken(ctx context.Context) error {
-	_, err := c.ListEnvVars(ctx, "")
-	return err
+	return resp, nil
 }
 
 func (c *Client) parseError(resp *http.Response) error {
@@ -3,6 +3,8 @@ package netlify
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
@@ -17,11 +19,24 @@ func init() {
 	})
 }
 
+// standardContexts are the deploy contexts every site has. A value in the
+// all context applies to each of them.
+var standardContexts = []string{"production", "deploy-preview", "branch-deploy", "dev"}
+
+// allScopes are where a variable can be used, in Netlify's spelling
+var allScopes = []string{"builds", "functions", "runtime", "post_processing"}
+
+// branchPrefix marks a remote environment as one branch's deploys, e.g.
+// branch:feature-x
+const branchPrefix = "branch:"
+
 // Provider implements the Netlify secrets provider
 type Provider struct {
 	client    *Client
 	accountID string
 	siteID    string
+	config    map[string]any
+	scopes    []string // Scopes for written variables; nil keeps existing ones
 }
 
 // New creates a new Netlify provider
@@ -41,10 +56,31 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 
 	siteID, _ := config["site_id"].(string)
 
+	var scopes []string
+	switch configured := config["scopes"].(type) {
+	case []string:
+		scopes = configured
+	case []any:
+		for _, s := range configured {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	for i, s := range scopes {
+		// The UI calls it post-processing
+		scopes[i] = strings.ReplaceAll(s, "-", "_")
+		if !slices.Contains(allScopes, scopes[i]) {
+			return nil, fmt.Errorf("netlify: unknown scope %q, want builds, functions, runtime or post-processing", s)
+		}
+	}
+
 	return &Provider{
 		client:    NewClient(token, accountID, siteID),
 		accountID: accountID,
 		siteID:    siteID,
+		config:    config,
+		scopes:    scopes,
 	}, nil
 }
 
@@ -52,7 +88,13 @@ func (p *Provider) Name() string        { return "netlify" }
 func (p *Provider) DisplayName() string { return "Netlify" }
 
 func (p *Provider) Environments() []string {
-	return []string{"production", "deploy-preview", "branch-deploy", "dev"}
+	envs := slices.Clone(standardContexts)
+	for _, env := range provider.ConfiguredEnvironments(p.config) {
+		if !slices.Contains(envs, env) {
+			envs = append(envs, env)
+		}
+	}
+	return envs
 }
 
 func (p *Provider) DefaultMapping() map[string]string {
@@ -68,37 +110,211 @@ func (p *Provider) Validate(ctx context.Context) error {
 	return p.client.ValidateToken(ctx)
 }
 
+// deployContext returns the value context of a remote environment:
+// branch:feature-x is the branch context with feature-x as its parameter
+func deployContext(environment string) (EnvVarValue, error) {
+	branch, ok := strings.CutPrefix(environment, branchPrefix)
+	if !ok {
+		return EnvVarValue{Context: environment}, nil
+	}
+	if branch == "" {
+		return EnvVarValue{}, fmt.Errorf("netlify: %q is missing a branch name", environment)
+	}
+	return EnvVarValue{Context: "branch", ContextParameter: branch}, nil
+}
+
+// valueFor returns the value that applies in a context: its own, then for a
+// branch the branch-deploy value, then the all value
+func valueFor(values []EnvVarValue, target EnvVarValue) (EnvVarValue, bool) {
+	fallbacks := []EnvVarValue{target}
+	if target.Context == "branch" {
+		fallbacks = append(fallbacks, EnvVarValue{Context: "branch-deploy"})
+	}
+	fallbacks = append(fallbacks, EnvVarValue{Context: "all"})
+	for _, f := range fallbacks {
+		for _, v := range values {
+			if v.Context == f.Context && v.ContextParameter == f.ContextParameter {
+				return v, true
+			}
+		}
+	}
+	return EnvVarValue{}, false
+}
+
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	envVars, err := p.client.ListEnvVars(ctx, environment)
+	target, err := deployContext(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API can only filter by the standard contexts
+	filter := environment
+	if target.Context == "branch" {
+		filter = ""
+	}
+	envVars, err := p.client.ListEnvVars(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
 	var secrets []model.SecretValue
 	for _, ev := range envVars {
-		// Find the value for the requested context
-		value := ""
-		for _, v := range ev.Values {
-			if v.Context == environment {
-				value = v.Value
-				break
-			}
+		v, ok := valueFor(ev.Values, target)
+		if !ok {
+			continue
 		}
-
 		secrets = append(secrets, model.SecretValue{
 			Name:        ev.Key,
-			Value:       value,
+			Value:       v.Value,
 			Environment: environment,
+			Unreadable:  ev.IsSecret,
 		})
 	}
 
 	return secrets, nil
 }
 
+// isSecret reports whether a variable should be secret. Schema metadata
+// decides: sensitive: true makes it secret, sensitive: false readable, and
+// otherwise an existing variable keeps its flag.
+func (p *Provider) isSecret(name string, existing *EnvVar) bool {
+	schema := provider.SecretSchema(p.config, name)
+	if schema.Sensitive == nil {
+		return existing != nil && existing.IsSecret
+	}
+	return *schema.Sensitive
+}
+
+// scopesFor returns the scopes to write a variable with: the configured ones,
+// or else the existing ones. Secret values can't be used in post-processing.
+func (p *Provider) scopesFor(secret bool, existing *EnvVar) []string {
+	scopes := p.scopes
+	if scopes == nil && existing != nil {
+		scopes = existing.Scopes
+	}
+	if scopes == nil {
+		scopes = allScopes
+	}
+	if secret {
+		scopes = slices.DeleteFunc(slices.Clone(scopes), func(s string) bool { return s == "post_processing" })
+	}
+	return scopes
+}
+
+// expandAll replaces a value for the all context with one per standard
+// context, so one of them can change without affecting the others
+func expandAll(values []EnvVarValue) []EnvVarValue {
+	var expanded []EnvVarValue
+	for _, v := range values {
+		if v.Context != "all" {
+			expanded = append(expanded, v)
+			continue
+		}
+		for _, c := range standardContexts {
+			expanded = append(expanded, EnvVarValue{Value: v.Value, Context: c})
+		}
+	}
+	return expanded
+}
+
+func sameContext(a, b EnvVarValue) bool {
+	return a.Context == b.Context && a.ContextParameter == b.ContextParameter
+}
+
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	return p.client.SetEnvVar(ctx, name, value, environment)
+	target, err := deployContext(environment)
+	if err != nil {
+		return err
+	}
+	target.Value = value
+
+	existing, err := p.client.GetEnvVar(ctx, name)
+	if err != nil {
+		return err
+	}
+	secret := p.isSecret(name, existing)
+	if existing == nil {
+		return p.client.CreateEnvVar(ctx, EnvVar{
+			Key:      name,
+			Scopes:   p.scopesFor(secret, nil),
+			Values:   []EnvVarValue{target},
+			IsSecret: secret,
+		})
+	}
+
+	if existing.IsSecret && !secret {
+		// Netlify can't make a secret variable readable again, so it is
+		// recreated if no other context would lose its value
+		if slices.ContainsFunc(existing.Values, func(v EnvVarValue) bool { return !sameContext(v, target) }) {
+			return fmt.Errorf("netlify: %s is secret and has values for other contexts; Netlify can't make it readable in place", name)
+		}
+		if err := p.client.DeleteEnvVar(ctx, name); err != nil {
+			return err
+		}
+		return p.client.CreateEnvVar(ctx, EnvVar{
+			Key:    name,
+			Scopes: p.scopesFor(false, existing),
+			Values: []EnvVarValue{target},
+		})
+	}
+
+	scopes := p.scopesFor(secret, existing)
+	_, shared := valueFor(existing.Values, EnvVarValue{Context: "all"})
+	if existing.IsSecret || (secret == existing.IsSecret && slices.Equal(scopes, existing.Scopes) && !shared) {
+		// Secret values can't be read back to rewrite the variable, so only
+		// this context's value is set
+		return p.client.SetEnvVarValue(ctx, name, target)
+	}
+
+	// Rewrite the variable with its new flag or scopes, keeping the other
+	// contexts' values
+	values := slices.DeleteFunc(expandAll(existing.Values), func(v EnvVarValue) bool { return sameContext(v, target) })
+	for i := range values {
+		values[i].ID = ""
+	}
+	return p.client.UpdateEnvVar(ctx, EnvVar{
+		Key:      name,
+		Scopes:   scopes,
+		Values:   append(values, target),
+		IsSecret: secret,
+	})
 }
 
+// Delete removes the variable's value for this context, and the variable
+// once no values are left
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	return p.client.DeleteEnvVar(ctx, name)
+	target, err := deployContext(environment)
+	if err != nil {
+		return err
+	}
+
+	existing, err := p.client.GetEnvVar(ctx, name)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	i := slices.IndexFunc(existing.Values, func(v EnvVarValue) bool { return sameContext(v, target) })
+	if i >= 0 {
+		if len(existing.Values) == 1 {
+			return p.client.DeleteEnvVar(ctx, name)
+		}
+		return p.client.DeleteEnvVarValue(ctx, name, existing.Values[i].ID)
+	}
+
+	if _, shared := valueFor(existing.Values, EnvVarValue{Context: "all"}); !shared || target.Context == "branch" {
+		return nil
+	}
+	if existing.IsSecret {
+		return fmt.Errorf("netlify: %s is secret and set for all contexts; delete it in every context instead", name)
+	}
+	// Give the other contexts their own copy of the all value
+	values := slices.DeleteFunc(expandAll(existing.Values), func(v EnvVarValue) bool { return sameContext(v, target) })
+	for i := range values {
+		values[i].ID = ""
+	}
+	return p.client.UpdateEnvVar(ctx, EnvVar{
+		Key:    name,
+		Scopes: existing.Scopes,
+		Values: values,
+	})
 }
@@ -1,7 +1,17 @@
 package netlify
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
 )
 
 func TestNew_ValidConfig(t *testing.T) {
@@ -130,3 +140,291 @@ func TestProvider_DefaultMapping(t *testing.T) {
 		t.Errorf("DefaultMapping()[dev] = %q, want 'test'", mapping["dev"])
 	}
 }
+
+func TestNew_Scopes(t *testing.T) {
+	config := map[string]any{
+		"_resolved_token": "nf_token_123",
+		"account_id":      "my-team-slug",
+		"scopes":          []any{"builds", "post-processing"},
+	}
+
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := prov.(*Provider).scopes; !slices.Equal(got, []string{"builds", "post_processing"}) {
+		t.Errorf("scopes = %v", got)
+	}
+
+	config["scopes"] = []string{"edge"}
+	if _, err := New(config); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
+// fakeNetlify is an in-memory stand-in for the Netlify env API
+type fakeNetlify struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	vars     map[string]*EnvVar
+	nextID   int
+	requests []string
+}
+
+func newFakeNetlify(t *testing.T) *fakeNetlify {
+	f := &fakeNetlify{vars: map[string]*EnvVar{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeNetlify) add(e EnvVar) {
+	for i := range e.Values {
+		f.nextID++
+		e.Values[i].ID = fmt.Sprintf("val_%d", f.nextID)
+	}
+	f.vars[e.Key] = &e
+}
+
+func (f *fakeNetlify) get(key string) *EnvVar {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.vars[key]
+}
+
+// masked returns a variable as the API returns it, without secret values
+func masked(e EnvVar) EnvVar {
+	if e.IsSecret {
+		e.Values = slices.Clone(e.Values)
+		for i := range e.Values {
+			e.Values[i].Value = ""
+		}
+	}
+	return e
+}
+
+func (f *fakeNetlify) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer nf_token_123" || r.URL.Query().Get("site_id") != "site-1" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Unauthorized"}`))
+		return
+	}
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	path := strings.TrimPrefix(r.URL.Path, "/accounts/team/env")
+	key, valueID, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/value/")
+	e := f.vars[key]
+	switch {
+	case r.Method == "GET" && path == "":
+		vars := []EnvVar{}
+		for _, e := range f.vars {
+			vars = append(vars, masked(*e))
+		}
+		_ = json.NewEncoder(w).Encode(vars)
+
+	case r.Method == "POST" && path == "":
+		var envs []EnvVar
+		_ = json.NewDecoder(r.Body).Decode(&envs)
+		for _, e := range envs {
+			f.add(e)
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case e == nil:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not found"}`))
+
+	case r.Method == "GET":
+		_ = json.NewEncoder(w).Encode(masked(*e))
+
+	case r.Method == "PUT":
+		var updated EnvVar
+		_ = json.NewDecoder(r.Body).Decode(&updated)
+		f.add(updated)
+
+	case r.Method == "PATCH":
+		var v EnvVarValue
+		_ = json.NewDecoder(r.Body).Decode(&v)
+		e.Values = slices.DeleteFunc(e.Values, func(old EnvVarValue) bool { return sameContext(old, v) })
+		f.nextID++
+		v.ID = fmt.Sprintf("val_%d", f.nextID)
+		e.Values = append(e.Values, v)
+
+	case r.Method == "DELETE" && valueID != "":
+		e.Values = slices.DeleteFunc(e.Values, func(v EnvVarValue) bool { return v.ID == valueID })
+
+	case r.Method == "DELETE":
+		delete(f.vars, key)
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeNetlify, config map[string]any) *Provider {
+	config["_resolved_token"] = "nf_token_123"
+	config["account_id"] = "team"
+	config["site_id"] = "site-1"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func value(e *EnvVar, context, branch string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	v, ok := valueFor(e.Values, EnvVarValue{Context: context, ContextParameter: branch})
+	return v.Value, ok && v.Context == context
+}
+
+func TestProvider_BranchContexts(t *testing.T) {
+	f := newFakeNetlify(t)
+	f.add(EnvVar{Key: "API_URL", Scopes: allScopes, Values: []EnvVarValue{
+		{Value: "https://branches", Context: "branch-deploy"},
+		{Value: "https://feature-x", Context: "branch", ContextParameter: "feature-x"},
+		{Value: "https://prod", Context: "production"},
+	}})
+	f.add(EnvVar{Key: "REGION", Scopes: allScopes, Values: []EnvVarValue{{Value: "eu", Context: "all"}}})
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	for env, want := range map[string]string{"branch:feature-x": "https://feature-x", "branch:other": "https://branches", "production": "https://prod"} {
+		secrets, err := p.List(ctx, env)
+		if err != nil {
+			t.Fatalf("List(%s) failed: %v", env, err)
+		}
+		got := map[string]string{}
+		for _, s := range secrets {
+			got[s.Name] = s.Value
+		}
+		if len(got) != 2 || got["API_URL"] != want || got["REGION"] != "eu" {
+			t.Errorf("List(%s) = %v, want API_URL=%s and REGION=eu", env, got, want)
+		}
+	}
+
+	if err := p.Set(ctx, "API_URL", "https://feature-y", "branch:feature-y"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, _ := value(f.get("API_URL"), "branch", "feature-y"); got != "https://feature-y" {
+		t.Errorf("feature-y API_URL = %q", got)
+	}
+	if got, _ := value(f.get("API_URL"), "production", ""); got != "https://prod" {
+		t.Errorf("production API_URL = %q after a branch write", got)
+	}
+
+	if err := p.Delete(ctx, "API_URL", "branch:feature-x"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := value(f.get("API_URL"), "branch", "feature-x"); ok {
+		t.Error("feature-x value should be gone")
+	}
+	if len(f.get("API_URL").Values) != 3 {
+		t.Errorf("values = %v", f.get("API_URL").Values)
+	}
+
+	// Changing one context of an all value gives the others their own copy
+	if err := p.Set(ctx, "REGION", "us", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for _, c := range standardContexts {
+		want := "eu"
+		if c == "production" {
+			want = "us"
+		}
+		if got, _ := value(f.get("REGION"), c, ""); got != want {
+			t.Errorf("%s REGION = %q, want %q", c, got, want)
+		}
+	}
+
+	if _, err := p.List(ctx, "branch:"); err == nil {
+		t.Error("expected error for a branch context without a branch")
+	}
+}
+
+func TestProvider_ResyncUnchanged(t *testing.T) {
+	f := newFakeNetlify(t)
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{
+			"API_KEY":    {Sensitive: boolPtr(true)},
+			"PUBLIC_URL": {Sensitive: boolPtr(false)},
+		},
+	})
+	ctx := context.Background()
+
+	values := map[string]string{"API_KEY": "sk_live", "PUBLIC_URL": "https://shop.example"}
+	for name, value := range values {
+		if err := p.Set(ctx, name, value, "production"); err != nil {
+			t.Fatalf("Set(%s) failed: %v", name, err)
+		}
+	}
+
+	// The secret comes back without its value; it must be reported as
+	// unreadable rather than as a value that differs
+	secrets, err := p.List(ctx, "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("List() = %+v", secrets)
+	}
+	for _, s := range secrets {
+		if s.Unreadable != (s.Name == "API_KEY") || (!s.Unreadable && s.Value != values[s.Name]) {
+			t.Errorf("%s = %+v, want it unreadable or unchanged", s.Name, s)
+		}
+	}
+}
+
+func TestProvider_SecretsAndScopes(t *testing.T) {
+	f := newFakeNetlify(t)
+	f.add(EnvVar{Key: "TOKEN", Scopes: allScopes, Values: []EnvVarValue{{Value: "old", Context: "production"}, {Value: "dev", Context: "dev"}}})
+	f.add(EnvVar{Key: "LOCKED", Scopes: []string{"builds"}, IsSecret: true, Values: []EnvVarValue{{Context: "production"}, {Context: "dev"}}})
+	f.add(EnvVar{Key: "OTHER", Scopes: []string{"runtime"}, Values: []EnvVarValue{{Value: "o", Context: "production"}}})
+	p := newTestProvider(t, f, map[string]any{
+		"scopes": []string{"builds", "functions", "post_processing"},
+		"_schema": map[string]model.Secret{
+			"API_KEY": {Sensitive: boolPtr(true)},
+			"TOKEN":   {Sensitive: boolPtr(true)},
+			"LOCKED":  {Sensitive: boolPtr(false)},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "k", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if e := f.get("API_KEY"); !e.IsSecret || !slices.Equal(e.Scopes, []string{"builds", "functions"}) {
+		t.Errorf("API_KEY = %+v, want secret without post-processing", e)
+	}
+
+	// Making a variable secret keeps its other values
+	if err := p.Set(ctx, "TOKEN", "new", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	e := f.get("TOKEN")
+	if got, _ := value(e, "dev", ""); !e.IsSecret || got != "dev" {
+		t.Errorf("TOKEN = %+v", e)
+	}
+	if got, _ := value(e, "production", ""); got != "new" {
+		t.Errorf("production TOKEN = %q", got)
+	}
+
+	// Configured scopes replace existing ones
+	if err := p.Set(ctx, "OTHER", "p", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if e := f.get("OTHER"); e.IsSecret || !slices.Equal(e.Scopes, []string{"builds", "functions", "post_processing"}) {
+		t.Errorf("OTHER = %+v", e)
+	}
+
+	err := p.Set(ctx, "LOCKED", "l", "production")
+	if err == nil || !strings.Contains(err.Error(), "can't make it readable") {
+		t.Errorf("Set(LOCKED) error = %v", err)
+	}
+}
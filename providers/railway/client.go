@@ -7,29 +7,41 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 )
 
 const graphqlURL = "https://backboard.railway.com/graphql/v2"
 
+// sharedService is the pseudo-service for project-level shared variables
+const sharedService = "shared"
+
 // Client handles Railway GraphQL API requests
 type Client struct {
+	url       string
 	token     string
 	projectID string
-	serviceID string
 	http      *http.Client
-	envIDs    map[string]string // environment name -> ID cache
+
+	mu      sync.Mutex
+	project *project // Looked up on first use
 }
 
 // NewClient creates a new Railway API client
-func NewClient(token, projectID, serviceID string) *Client {
+func NewClient(token, projectID string) *Client {
 	return &Client{
+		url:       graphqlURL,
 		token:     token,
 		projectID: projectID,
-		serviceID: serviceID,
 		http:      &http.Client{},
 	}
 }
 
+// project holds a project's environment and service IDs by name
+type project struct {
+	envIDs     map[string]string
+	serviceIDs map[string]string
+}
+
 type graphqlRequest struct {
 	Query     string         `json:"query"`
 	Variables map[string]any `json:"variables,omitempty"`
@@ -42,18 +54,30 @@ type graphqlResponse struct {
 	} `json:"errors"`
 }
 
-// ValidateToken checks if the token is valid and fetches environment name->ID mapping
+// ValidateToken checks if the token is valid and fetches the project's
+// environments and services. It always queries the API, refreshing the
+// cached lookup.
 func (c *Client) ValidateToken(ctx context.Context) error {
-	_, err := c.fetchEnvironments(ctx)
+	_, err := c.loadProject(ctx)
 	return err
 }
 
-// fetchEnvironments queries the project and caches environment name->ID mapping
-func (c *Client) fetchEnvironments(ctx context.Context) (map[string]string, error) {
-	if c.envIDs != nil {
-		return c.envIDs, nil
+// fetchProject returns the project's environments and services, queried
+// once per client. Read the result under c.mu, since CreateEnvironment adds
+// to it.
+func (c *Client) fetchProject(ctx context.Context) (*project, error) {
+	c.mu.Lock()
+	p := c.project
+	c.mu.Unlock()
+	if p != nil {
+		return p, nil
 	}
+	return c.loadProject(ctx)
+}
 
+// loadProject queries the project's environments and services and caches
+// them
+func (c *Client) loadProject(ctx context.Context) (*project, error) {
 	query := `query project($id: String!) {
 		project(id: $id) {
 			environments {
@@ -64,6 +88,14 @@ func (c *Client) fetchEnvironments(ctx context.Context) (map[string]string, erro
 					}
 				}
 			}
+			services {
+				edges {
+					node {
+						id
+						name
+					}
+				}
+			}
 		}
 	}`
 
@@ -72,16 +104,18 @@ func (c *Client) fetchEnvironments(ctx context.Context) (map[string]string, erro
 		return nil, err
 	}
 
+	type edges struct {
+		Edges []struct {
+			Node struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"node"`
+		} `json:"edges"`
+	}
 	var result struct {
 		Project struct {
-			Environments struct {
-				Edges []struct {
-					Node struct {
-						ID   string `json:"id"`
-						Name string `json:"name"`
-					} `json:"node"`
-				} `json:"edges"`
-			} `json:"environments"`
+			Environments edges `json:"environments"`
+			Services     edges `json:"services"`
 		} `json:"project"`
 	}
 
@@ -89,41 +123,105 @@ func (c *Client) fetchEnvironments(ctx context.Context) (map[string]string, erro
 		return nil, fmt.Errorf("railway: failed to decode project response: %w", err)
 	}
 
-	c.envIDs = make(map[string]string)
+	p := &project{envIDs: make(map[string]string), serviceIDs: make(map[string]string)}
 	for _, edge := range result.Project.Environments.Edges {
-		c.envIDs[edge.Node.Name] = edge.Node.ID
+		p.envIDs[edge.Node.Name] = edge.Node.ID
 	}
+	for _, edge := range result.Project.Services.Edges {
+		p.serviceIDs[edge.Node.Name] = edge.Node.ID
+	}
+	c.mu.Lock()
+	c.project = p
+	c.mu.Unlock()
 
-	return c.envIDs, nil
+	return p, nil
 }
 
-// ResolveEnvironmentID resolves an environment name to its UUID
+// ResolveEnvironmentID resolves an environment name to its UUID. It returns
+// "" if the environment doesn't exist.
 func (c *Client) ResolveEnvironmentID(ctx context.Context, name string) (string, error) {
-	envs, err := c.fetchEnvironments(ctx)
+	p, err := c.fetchProject(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return p.envIDs[name], nil
+}
+
+// ResolveServiceID resolves a service name or ID to its ID. The shared
+// pseudo-service resolves to "", which addresses shared variables.
+func (c *Client) ResolveServiceID(ctx context.Context, service string) (string, error) {
+	if service == sharedService {
+		return "", nil
+	}
+
+	p, err := c.fetchProject(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id, ok := p.serviceIDs[service]; ok {
+		return id, nil
+	}
+	for _, id := range p.serviceIDs {
+		if id == service {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("railway: service %q not found in project", service)
+}
+
+// CreateEnvironment creates an empty environment and returns its ID
+func (c *Client) CreateEnvironment(ctx context.Context, name string) (string, error) {
+	query := `mutation environmentCreate($input: EnvironmentCreateInput!) {
+		environmentCreate(input: $input) {
+			id
+		}
+	}`
+
+	resp, err := c.doGraphQL(ctx, query, map[string]any{
+		"input": map[string]any{"projectId": c.projectID, "name": name},
+	})
 	if err != nil {
 		return "", err
 	}
 
-	id, ok := envs[name]
-	if !ok {
-		return "", fmt.Errorf("railway: environment %q not found in project", name)
+	var result struct {
+		EnvironmentCreate struct {
+			ID string `json:"id"`
+		} `json:"environmentCreate"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", fmt.Errorf("railway: failed to decode environment response: %w", err)
 	}
 
-	return id, nil
+	c.mu.Lock()
+	if c.project != nil {
+		c.project.envIDs[name] = result.EnvironmentCreate.ID
+	}
+	c.mu.Unlock()
+	return result.EnvironmentCreate.ID, nil
 }
 
-// ListVariables retrieves all variables for an environment
-func (c *Client) ListVariables(ctx context.Context, environmentID string) (map[string]string, error) {
-	query := `query variables($projectId: String!, $environmentId: String!, $serviceId: String) {
-		variables(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
+// ListVariables retrieves all variables of a service, or the shared
+// variables if serviceID is empty. Unrendered values keep references such
+// as ${{ shared.API_KEY }} instead of resolving them.
+func (c *Client) ListVariables(ctx context.Context, environmentID, serviceID string, unrendered bool) (map[string]string, error) {
+	query := `query variables($projectId: String!, $environmentId: String!, $serviceId: String, $unrendered: Boolean) {
+		variables(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId, unrendered: $unrendered)
 	}`
 
 	vars := map[string]any{
 		"projectId":     c.projectID,
 		"environmentId": environmentID,
+		"unrendered":    unrendered,
 	}
-	if c.serviceID != "" {
-		vars["serviceId"] = c.serviceID
+	if serviceID != "" {
+		vars["serviceId"] = serviceID
 	}
 
 	resp, err := c.doGraphQL(ctx, query, vars)
@@ -142,20 +240,20 @@ func (c *Client) ListVariables(ctx context.Context, environmentID string) (map[s
 	return result.Variables, nil
 }
 
-// UpsertVariable creates or updates a variable
-func (c *Client) UpsertVariable(ctx context.Context, environmentID, name, value string) error {
-	query := `mutation variableUpsert($input: VariableUpsertInput!) {
-		variableUpsert(input: $input)
+// UpsertVariables creates or updates variables in one change, so the
+// service redeploys once
+func (c *Client) UpsertVariables(ctx context.Context, environmentID, serviceID string, variables map[string]string) error {
+	query := `mutation variableCollectionUpsert($input: VariableCollectionUpsertInput!) {
+		variableCollectionUpsert(input: $input)
 	}`
 
 	input := map[string]any{
 		"projectId":     c.projectID,
 		"environmentId": environmentID,
-		"name":          name,
-		"value":         value,
+		"variables":     variables,
 	}
-	if c.serviceID != "" {
-		input["serviceId"] = c.serviceID
+	if serviceID != "" {
+		input["serviceId"] = serviceID
 	}
 
 	_, err := c.doGraphQL(ctx, query, map[string]any{"input": input})
@@ -163,7 +261,7 @@ func (c *Client) UpsertVariable(ctx context.Context, environmentID, name, value
 }
 
 // DeleteVariable deletes a variable
-func (c *Client) DeleteVariable(ctx context.Context, environmentID, name string) error {
+func (c *Client) DeleteVariable(ctx context.Context, environmentID, serviceID, name string) error {
 	query := `mutation variableDelete($input: VariableDeleteInput!) {
 		variableDelete(input: $input)
 	}`
@@ -173,8 +271,8 @@ func (c *Client) DeleteVariable(ctx context.Context, environmentID, name string)
 		"environmentId": environmentID,
 		"name":          name,
 	}
-	if c.serviceID != "" {
-		input["serviceId"] = c.serviceID
+	if serviceID != "" {
+		input["serviceId"] = serviceID
 	}
 
 	_, err := c.doGraphQL(ctx, query, map[string]any{"input": input})
@@ -190,7 +288,7 @@ func (c *Client) doGraphQL(ctx context.Context, query string, variables map[stri
 		return nil, fmt.Errorf("railway: failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", graphqlURL, bytes.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("railway: failed to create request: %w", err)
 	}
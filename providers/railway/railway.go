@@ -3,6 +3,8 @@ package railway
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
@@ -17,9 +19,12 @@ func init() {
 	})
 }
 
-// Provider implements the Railway secrets provider
+// Provider implements the Railway secrets provider. Each variable is written
+// to every configured service.
 type Provider struct {
-	client *Client
+	client   *Client
+	services []string // Service names or IDs, with the shared pseudo-service first
+	create   bool     // Create missing environments on the first write
 }
 
 // New creates a new Railway provider
@@ -37,10 +42,36 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 		return nil, fmt.Errorf("railway: project_id is required")
 	}
 
-	serviceID, _ := config["service_id"].(string)
+	var services []string
+	switch configured := config["services"].(type) {
+	case []string:
+		services = slices.Clone(configured)
+	case []any:
+		for _, s := range configured {
+			if s, ok := s.(string); ok {
+				services = append(services, s)
+			}
+		}
+	}
+	if serviceID, _ := config["service_id"].(string); serviceID != "" && !slices.Contains(services, serviceID) {
+		services = append(services, serviceID)
+	}
+	if len(services) == 0 {
+		// Without a service, variables are the project's shared ones
+		services = []string{sharedService}
+	}
+	// Shared variables are written first, so references to them resolve to
+	// the new values when the services are checked
+	if i := slices.Index(services, sharedService); i > 0 {
+		services = slices.Insert(slices.Delete(services, i, i+1), 0, sharedService)
+	}
+
+	create, _ := config["create_environments"].(bool)
 
 	return &Provider{
-		client: NewClient(token, projectID, serviceID),
+		client:   NewClient(token, projectID),
+		services: services,
+		create:   create,
 	}, nil
 }
 
@@ -59,22 +90,67 @@ func (p *Provider) DefaultMapping() map[string]string {
 }
 
 func (p *Provider) Validate(ctx context.Context) error {
-	return p.client.ValidateToken(ctx)
+	if err := p.client.ValidateToken(ctx); err != nil {
+		return err
+	}
+	for _, service := range p.services {
+		if _, err := p.client.ResolveServiceID(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// environmentID resolves an environment, creating it for a write if
+// create_environments is set. A missing environment that would be created
+// resolves to "".
+func (p *Provider) environmentID(ctx context.Context, environment string, write bool) (string, error) {
+	id, err := p.client.ResolveEnvironmentID(ctx, environment)
+	if err != nil || id != "" {
+		return id, err
+	}
+	if !p.create {
+		return "", fmt.Errorf("railway: environment %q not found in project", environment)
+	}
+	if !write {
+		return "", nil
+	}
+	return p.client.CreateEnvironment(ctx, environment)
+}
+
+// List returns the variables every service has. A variable whose value
+// differs between services is reported empty, so a sync rewrites it.
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	envID, err := p.client.ResolveEnvironmentID(ctx, environment)
-	if err != nil {
+	envID, err := p.environmentID(ctx, environment, false)
+	if err != nil || envID == "" {
 		return nil, err
 	}
 
-	vars, err := p.client.ListVariables(ctx, envID)
-	if err != nil {
-		return nil, err
+	var common map[string]string
+	for _, service := range p.services {
+		serviceID, err := p.client.ResolveServiceID(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		vars, err := p.client.ListVariables(ctx, envID, serviceID, false)
+		if err != nil {
+			return nil, err
+		}
+		if common == nil {
+			common = vars
+			continue
+		}
+		for name, value := range common {
+			if other, ok := vars[name]; !ok {
+				delete(common, name)
+			} else if other != value {
+				common[name] = ""
+			}
+		}
 	}
 
-	secrets := make([]model.SecretValue, 0, len(vars))
-	for name, value := range vars {
+	secrets := make([]model.SecretValue, 0, len(common))
+	for name, value := range common {
 		secrets = append(secrets, model.SecretValue{
 			Name:        name,
 			Value:       value,
@@ -86,19 +162,81 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 }
 
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	envID, err := p.client.ResolveEnvironmentID(ctx, environment)
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes the values to each service in one change. Reference
+// variables such as ${{ shared.API_KEY }} that already resolve to the new
+// value are left alone, as are unchanged values.
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	envID, err := p.environmentID(ctx, environment, true)
 	if err != nil {
 		return err
 	}
 
-	return p.client.UpsertVariable(ctx, envID, name, value)
+	for _, service := range p.services {
+		serviceID, err := p.client.ResolveServiceID(ctx, service)
+		if err != nil {
+			return err
+		}
+		raw, err := p.client.ListVariables(ctx, envID, serviceID, true)
+		if err != nil {
+			return err
+		}
+
+		var rendered map[string]string
+		changes := make(map[string]string, len(values))
+		for name, value := range values {
+			current, exists := raw[name]
+			if exists && isReference(current) {
+				if rendered == nil {
+					if rendered, err = p.client.ListVariables(ctx, envID, serviceID, false); err != nil {
+						return err
+					}
+				}
+				current = rendered[name]
+			}
+			if !exists || current != value {
+				changes[name] = value
+			}
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+		if err := p.client.UpsertVariables(ctx, envID, serviceID, changes); err != nil {
+			return fmt.Errorf("railway: failed to update %s: %w", service, err)
+		}
+	}
+	return nil
 }
 
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	envID, err := p.client.ResolveEnvironmentID(ctx, environment)
-	if err != nil {
+	envID, err := p.environmentID(ctx, environment, false)
+	if err != nil || envID == "" {
 		return err
 	}
 
-	return p.client.DeleteVariable(ctx, envID, name)
+	for _, service := range p.services {
+		serviceID, err := p.client.ResolveServiceID(ctx, service)
+		if err != nil {
+			return err
+		}
+		raw, err := p.client.ListVariables(ctx, envID, serviceID, true)
+		if err != nil {
+			return err
+		}
+		if _, ok := raw[name]; !ok {
+			continue
+		}
+		if err := p.client.DeleteVariable(ctx, envID, serviceID, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReference reports whether a raw value refers to other variables
+func isReference(value string) bool {
+	return strings.Contains(value, "${{")
 }
@@ -1,6 +1,14 @@
 package railway
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -69,8 +77,8 @@ func TestNew_WithServiceID(t *testing.T) {
 	}
 
 	prov := p.(*Provider)
-	if prov.client.serviceID != "4bd252dc-c4ac-4c2e-a52f-051804292035" {
-		t.Errorf("expected service_id to be set, got %q", prov.client.serviceID)
+	if !slices.Equal(prov.services, []string{"4bd252dc-c4ac-4c2e-a52f-051804292035"}) {
+		t.Errorf("expected service_id to be the only service, got %v", prov.services)
 	}
 }
 
@@ -86,8 +94,8 @@ func TestNew_WithoutServiceID(t *testing.T) {
 	}
 
 	prov := p.(*Provider)
-	if prov.client.serviceID != "" {
-		t.Errorf("expected empty service_id, got %q", prov.client.serviceID)
+	if !slices.Equal(prov.services, []string{sharedService}) {
+		t.Errorf("expected shared variables, got %v", prov.services)
 	}
 }
 
@@ -137,3 +145,249 @@ func TestProvider_DefaultMapping(t *testing.T) {
 		t.Errorf("expected staging->test, got staging->%s", mapping["staging"])
 	}
 }
+
+func TestNew_Services(t *testing.T) {
+	config := map[string]any{
+		"_resolved_token": "test-token",
+		"project_id":      "test-project",
+		"services":        []any{"web", "shared", "worker"},
+		"service_id":      "svc-api",
+	}
+
+	p, err := New(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := p.(*Provider).services; !slices.Equal(got, []string{"shared", "web", "worker", "svc-api"}) {
+		t.Errorf("services = %v, want shared first", got)
+	}
+}
+
+// fakeRailway is an in-memory stand-in for the Railway GraphQL API.
+// Variables are keyed by environment ID, then service ID ("" for shared).
+type fakeRailway struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	envs     map[string]string
+	vars     map[string]map[string]map[string]string
+	projects int
+	upserts  []map[string]any
+	deletes  []string
+}
+
+func newFakeRailway(t *testing.T) *fakeRailway {
+	f := &fakeRailway{
+		envs: map[string]string{"production": "env-prod"},
+		vars: map[string]map[string]map[string]string{},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeRailway) set(envID, serviceID, name, value string) {
+	if f.vars[envID] == nil {
+		f.vars[envID] = map[string]map[string]string{}
+	}
+	if f.vars[envID][serviceID] == nil {
+		f.vars[envID][serviceID] = map[string]string{}
+	}
+	f.vars[envID][serviceID][name] = value
+}
+
+// render resolves ${{ shared.NAME }} references
+func (f *fakeRailway) render(envID, value string) string {
+	for name, shared := range f.vars[envID][""] {
+		value = strings.ReplaceAll(value, "${{ shared."+name+" }}", shared)
+	}
+	return value
+}
+
+func (f *fakeRailway) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req graphqlRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	input, _ := req.Variables["input"].(map[string]any)
+	str := func(m map[string]any, key string) string { s, _ := m[key].(string); return s }
+
+	var data any
+	switch op := strings.Fields(req.Query)[1]; {
+	case strings.HasPrefix(op, "project("):
+		f.projects++
+		node := func(id, name string) map[string]any {
+			return map[string]any{"node": map[string]string{"id": id, "name": name}}
+		}
+		var envs []any
+		for name, id := range f.envs {
+			envs = append(envs, node(id, name))
+		}
+		data = map[string]any{"project": map[string]any{
+			"environments": map[string]any{"edges": envs},
+			"services":     map[string]any{"edges": []any{node("svc-web", "web"), node("svc-worker", "worker")}},
+		}}
+
+	case strings.HasPrefix(op, "variables("):
+		envID, serviceID := str(req.Variables, "environmentId"), str(req.Variables, "serviceId")
+		vars := map[string]string{}
+		for name, value := range f.vars[envID][serviceID] {
+			if req.Variables["unrendered"] != true {
+				value = f.render(envID, value)
+			}
+			vars[name] = value
+		}
+		data = map[string]any{"variables": vars}
+
+	case strings.HasPrefix(op, "environmentCreate("):
+		id := "env-" + str(input, "name")
+		f.envs[str(input, "name")] = id
+		data = map[string]any{"environmentCreate": map[string]string{"id": id}}
+
+	case strings.HasPrefix(op, "variableCollectionUpsert("):
+		f.upserts = append(f.upserts, input)
+		for name, value := range input["variables"].(map[string]any) {
+			f.set(str(input, "environmentId"), str(input, "serviceId"), name, value.(string))
+		}
+		data = map[string]any{"variableCollectionUpsert": true}
+
+	case strings.HasPrefix(op, "variableDelete("):
+		f.deletes = append(f.deletes, str(input, "serviceId")+"/"+str(input, "name"))
+		delete(f.vars[str(input, "environmentId")][str(input, "serviceId")], str(input, "name"))
+		data = map[string]any{"variableDelete": true}
+
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": "unknown operation " + op}}})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func newTestProvider(t *testing.T, f *fakeRailway, config map[string]any) *Provider {
+	config["_resolved_token"] = "test-token"
+	config["project_id"] = "proj-1"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.url = f.srv.URL
+	return p
+}
+
+func listed(t *testing.T, p *Provider, env string) map[string]string {
+	t.Helper()
+	secrets, err := p.List(context.Background(), env)
+	if err != nil {
+		t.Fatalf("List(%s) failed: %v", env, err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	return got
+}
+
+func TestProvider_MultipleServices(t *testing.T) {
+	f := newFakeRailway(t)
+	f.set("env-prod", "", "API_KEY", "key1")
+	f.set("env-prod", "svc-web", "API_KEY", "${{ shared.API_KEY }}")
+	f.set("env-prod", "svc-web", "PORT", "8080")
+	f.set("env-prod", "svc-worker", "API_KEY", "key1")
+	f.set("env-prod", "svc-worker", "PORT", "9090")
+	p := newTestProvider(t, f, map[string]any{"services": []string{"web", "worker", "shared"}})
+	ctx := context.Background()
+
+	if got := listed(t, p, "production"); len(got) != 1 || got["API_KEY"] != "key1" {
+		t.Errorf("List = %v, want only the variable all services share", got)
+	}
+
+	// A value the services disagree on is reported empty
+	if got := listed(t, newTestProvider(t, f, map[string]any{"services": []string{"web", "worker"}}), "production"); got["PORT"] != "" || got["API_KEY"] != "key1" {
+		t.Errorf("List = %v, want PORT empty since web and worker differ", got)
+	}
+
+	if err := p.SetMany(ctx, map[string]string{"API_KEY": "key2", "PORT": "8080"}, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if got := f.vars["env-prod"]["svc-web"]["API_KEY"]; got != "${{ shared.API_KEY }}" {
+		t.Errorf("web API_KEY = %q, want the reference kept", got)
+	}
+	if got := f.vars["env-prod"]["svc-worker"]; got["API_KEY"] != "key2" || got["PORT"] != "8080" {
+		t.Errorf("worker = %v", got)
+	}
+	if got := f.vars["env-prod"][""]["API_KEY"]; got != "key2" {
+		t.Errorf("shared API_KEY = %q", got)
+	}
+	// web needs no change: its reference resolves to the new shared value
+	if len(f.upserts) != 2 || f.upserts[0]["serviceId"] != nil {
+		t.Errorf("upserts = %v, want shared first and no write to web", f.upserts)
+	}
+
+	if err := p.Delete(ctx, "PORT", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if !slices.Equal(f.deletes, []string{"/PORT", "svc-web/PORT", "svc-worker/PORT"}) {
+		t.Errorf("deletes = %v", f.deletes)
+	}
+
+	// Once for each of the two providers, however often they resolve names
+	if f.projects != 2 {
+		t.Errorf("project queried %d times, want once per provider", f.projects)
+	}
+}
+
+func TestProvider_CreateEnvironments(t *testing.T) {
+	f := newFakeRailway(t)
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	if _, err := p.List(ctx, "preview"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("List error = %v, want environment not found", err)
+	}
+
+	p.create = true
+	if got := listed(t, p, "preview"); len(got) != 0 {
+		t.Errorf("List = %v, want empty before the environment exists", got)
+	}
+	if _, ok := f.envs["preview"]; ok {
+		t.Error("List should not create the environment")
+	}
+
+	if err := p.Set(ctx, "DEBUG", "1", "preview"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := fmt.Sprint(f.vars["env-preview"][""]); got != "map[DEBUG:1]" {
+		t.Errorf("preview shared variables = %s", got)
+	}
+	if got := listed(t, p, "preview"); got["DEBUG"] != "1" {
+		t.Errorf("List = %v after creating the environment", got)
+	}
+}
+
+func TestProvider_ValidateQueriesEachTime(t *testing.T) {
+	f := newFakeRailway(t)
+	ctx := context.Background()
+
+	p := newTestProvider(t, f, map[string]any{})
+	for range 2 {
+		if err := p.Validate(ctx); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+	}
+	if f.projects != 2 {
+		t.Errorf("project queried %d times, want 2", f.projects)
+	}
+
+	// A revoked token fails even though the project was looked up before
+	bad := newTestProvider(t, f, map[string]any{})
+	bad.client.token = "revoked"
+	if err := bad.Validate(ctx); err == nil {
+		t.Error("expected Validate to fail with a revoked token")
+	}
+}
@@ -0,0 +1,40 @@
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// blueprintServices returns the names of the services in a render.yaml
+// blueprint that can have environment variables
+func blueprintServices(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("render: failed to read blueprint: %w", err)
+	}
+
+	var blueprint struct {
+		Services []struct {
+			Name string `yaml:"name"`
+			Type string `yaml:"type"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &blueprint); err != nil {
+		return nil, fmt.Errorf("render: failed to parse blueprint %s: %w", path, err)
+	}
+
+	var names []string
+	for _, s := range blueprint.Services {
+		// Key Value instances have no environment variables
+		if s.Type == "keyvalue" || s.Type == "redis" || s.Name == "" {
+			continue
+		}
+		names = append(names, s.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("render: blueprint %s has no services", path)
+	}
+	return names, nil
+}
@@ -7,23 +7,27 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
-const baseURL = "https://api.render.com/v1"
+const defaultBaseURL = "https://api.render.com/v1"
+
+// pageSize is the most items Render returns per page
+const pageSize = 100
 
 // Client handles Render API requests
 type Client struct {
-	apiKey    string
-	serviceID string
-	http      *http.Client
+	baseURL string
+	apiKey  string
+	http    *http.Client
 }
 
 // NewClient creates a new Render API client
-func NewClient(apiKey, serviceID string) *Client {
+func NewClient(apiKey string) *Client {
 	return &Client{
-		apiKey:    apiKey,
-		serviceID: serviceID,
-		http:      &http.Client{},
+		baseURL: defaultBaseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{},
 	}
 }
 
@@ -33,79 +37,185 @@ type EnvVar struct {
 	Value string `json:"value"`
 }
 
+// EnvGroup represents a Render environment group and the services linked
+// to it
+type EnvGroup struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	EnvVars      []EnvVar `json:"envVars"`
+	ServiceLinks []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"serviceLinks"`
+}
+
 // ListEnvVars retrieves all environment variables for a service
-func (c *Client) ListEnvVars(ctx context.Context) ([]EnvVar, error) {
-	endpoint := fmt.Sprintf("/services/%s/env-vars", c.serviceID)
+func (c *Client) ListEnvVars(ctx context.Context, serviceID string) ([]EnvVar, error) {
+	var envVars []EnvVar
+	cursor := ""
+	for {
+		params := url.Values{"limit": {fmt.Sprint(pageSize)}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		endpoint := fmt.Sprintf("/services/%s/env-vars?%s", url.PathEscape(serviceID), params.Encode())
 
-	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+		var page []struct {
+			EnvVar EnvVar `json:"envVar"`
+			Cursor string `json:"cursor"`
+		}
+		if err := c.get(ctx, endpoint, &page); err != nil {
+			return nil, err
+		}
+
+		for _, r := range page {
+			envVars = append(envVars, r.EnvVar)
+		}
+		if len(page) < pageSize {
+			return envVars, nil
+		}
+		cursor = page[len(page)-1].Cursor
+	}
+}
+
+// SetEnvVar creates or updates an environment variable
+func (c *Client) SetEnvVar(ctx context.Context, serviceID, key, value string) error {
+	endpoint := fmt.Sprintf("/services/%s/env-vars/%s", url.PathEscape(serviceID), url.PathEscape(key))
+	return c.put(ctx, endpoint, map[string]string{"value": value})
+}
+
+// ReplaceEnvVars replaces all of a service's environment variables in one
+// update
+func (c *Client) ReplaceEnvVars(ctx context.Context, serviceID string, envVars []EnvVar) error {
+	if envVars == nil {
+		envVars = []EnvVar{}
+	}
+	return c.put(ctx, fmt.Sprintf("/services/%s/env-vars", url.PathEscape(serviceID)), envVars)
+}
+
+// DeleteEnvVar deletes an environment variable by fetching all, filtering, and bulk-putting
+func (c *Client) DeleteEnvVar(ctx context.Context, serviceID, key string) error {
+	// Get all current env vars
+	envVars, err := c.ListEnvVars(ctx, serviceID)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to list env vars for delete: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
+	// Filter out the target key
+	var remaining []EnvVar
+	for _, ev := range envVars {
+		if ev.Key != key {
+			remaining = append(remaining, ev)
+		}
 	}
 
+	return c.ReplaceEnvVars(ctx, serviceID, remaining)
+}
+
+// FindService returns the ID of the service with the given name
+func (c *Client) FindService(ctx context.Context, name string) (string, error) {
 	var result []struct {
-		EnvVar EnvVar `json:"envVar"`
+		Service struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"service"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.get(ctx, "/services?"+url.Values{"name": {name}, "limit": {fmt.Sprint(pageSize)}}.Encode(), &result); err != nil {
+		return "", err
+	}
+	for _, r := range result {
+		if r.Service.Name == name {
+			return r.Service.ID, nil
+		}
 	}
+	return "", fmt.Errorf("render: no service named %q", name)
+}
 
-	envVars := make([]EnvVar, len(result))
-	for i, r := range result {
-		envVars[i] = r.EnvVar
+// FindEnvGroup returns the ID of the environment group with the given name
+func (c *Client) FindEnvGroup(ctx context.Context, name string) (string, error) {
+	var result []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
 	}
+	if err := c.get(ctx, "/env-groups?"+url.Values{"name": {name}, "limit": {fmt.Sprint(pageSize)}}.Encode(), &result); err != nil {
+		return "", err
+	}
+	for _, g := range result {
+		if g.Name == name {
+			return g.ID, nil
+		}
+	}
+	return "", fmt.Errorf("render: no environment group named %q", name)
+}
 
-	return envVars, nil
+// GetEnvGroup retrieves an environment group with its variables
+func (c *Client) GetEnvGroup(ctx context.Context, groupID string) (*EnvGroup, error) {
+	var group EnvGroup
+	if err := c.get(ctx, "/env-groups/"+url.PathEscape(groupID), &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
 }
 
-// SetEnvVar creates or updates an environment variable
-func (c *Client) SetEnvVar(ctx context.Context, key, value string) error {
-	endpoint := fmt.Sprintf("/services/%s/env-vars/%s", c.serviceID, key)
+// SetEnvGroupVar creates or updates a variable in an environment group
+func (c *Client) SetEnvGroupVar(ctx context.Context, groupID, key, value string) error {
+	endpoint := fmt.Sprintf("/env-groups/%s/env-vars/%s", url.PathEscape(groupID), url.PathEscape(key))
+	return c.put(ctx, endpoint, map[string]string{"value": value})
+}
+
+// DeleteEnvGroupVar removes a variable from an environment group
+func (c *Client) DeleteEnvGroupVar(ctx context.Context, groupID, key string) error {
+	endpoint := fmt.Sprintf("/env-groups/%s/env-vars/%s", url.PathEscape(groupID), url.PathEscape(key))
 
-	body, err := json.Marshal(map[string]string{
-		"value": value,
-	})
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
 
-	resp, err := c.doRequest(ctx, "PUT", endpoint, body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// TriggerDeploy starts a deploy of a service
+func (c *Client) TriggerDeploy(ctx context.Context, serviceID string) error {
+	endpoint := fmt.Sprintf("/services/%s/deploys", url.PathEscape(serviceID))
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, []byte(`{}`))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
 		return c.parseError(resp)
 	}
 
 	return nil
 }
 
-// DeleteEnvVar deletes an environment variable by fetching all, filtering, and bulk-putting
-func (c *Client) DeleteEnvVar(ctx context.Context, key string) error {
-	// Get all current env vars
-	envVars, err := c.ListEnvVars(ctx)
+func (c *Client) get(ctx context.Context, endpoint string, result any) error {
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("failed to list env vars for delete: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
 
-	// Filter out the target key
-	var remaining []EnvVar
-	for _, ev := range envVars {
-		if ev.Key != key {
-			remaining = append(remaining, ev)
-		}
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
 	}
 
-	// Bulk PUT the remaining env vars
-	endpoint := fmt.Sprintf("/services/%s/env-vars", c.serviceID)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
 
-	body, err := json.Marshal(remaining)
+func (c *Client) put(ctx context.Context, endpoint string, payload any) error {
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -116,7 +226,7 @@ func (c *Client) DeleteEnvVar(ctx context.Context, key string) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		return c.parseError(resp)
 	}
 
@@ -129,7 +239,7 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []
 		bodyReader = bytes.NewReader(body)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+endpoint, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -159,6 +269,6 @@ func (c *Client) parseError(resp *http.Response) error {
 
 // ValidateToken checks if the API key is valid
 func (c *Client) ValidateToken(ctx context.Context) error {
-	_, err := c.ListEnvVars(ctx)
-	return err
+	var result []json.RawMessage
+	return c.get(ctx, "/services?limit=1", &result)
 }
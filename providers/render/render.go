@@ -3,6 +3,9 @@ package render
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
@@ -17,10 +20,21 @@ func init() {
 	})
 }
 
-// Provider implements the Render secrets provider
+// Provider implements the Render secrets provider. An environment writes to
+// a service, an environment group, every service in a render.yaml
+// blueprint, or any combination of them.
 type Provider struct {
-	client    *Client
+	client  *Client
+	config  map[string]any
+	sinks   map[string][]sink   // Resolved destinations by environment
+	pending map[string][]string // Services to deploy by environment
+}
+
+// sink is a service or an environment group that variables are written to
+type sink struct {
+	name      string
 	serviceID string
+	groupID   string
 }
 
 // New creates a new Render provider
@@ -33,21 +47,29 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 		return nil, fmt.Errorf("render: API key is required")
 	}
 
-	serviceID, _ := config["service_id"].(string)
-	if serviceID == "" {
-		return nil, fmt.Errorf("render: service_id is required")
+	p := &Provider{
+		client:  NewClient(token),
+		config:  config,
+		sinks:   make(map[string][]sink),
+		pending: make(map[string][]string),
 	}
-
-	return &Provider{
-		client:    NewClient(token, serviceID),
-		serviceID: serviceID,
-	}, nil
+	for _, env := range p.Environments() {
+		if provider.EnvSetting(config, env, "service_id") == "" &&
+			provider.EnvSetting(config, env, "env_group") == "" &&
+			provider.EnvSetting(config, env, "blueprint") == "" {
+			return nil, fmt.Errorf("render: service_id, env_group or blueprint is required")
+		}
+	}
+	return p, nil
 }
 
 func (p *Provider) Name() string        { return "render" }
 func (p *Provider) DisplayName() string { return "Render" }
 
 func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
 	return []string{"default"}
 }
 
@@ -58,20 +80,117 @@ func (p *Provider) DefaultMapping() map[string]string {
 }
 
 func (p *Provider) Validate(ctx context.Context) error {
-	return p.client.ValidateToken(ctx)
+	if err := p.client.ValidateToken(ctx); err != nil {
+		return err
+	}
+	for _, env := range p.Environments() {
+		if _, err := p.resolve(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// resolve returns the destinations of an environment, looking up group and
+// blueprint service names once
+func (p *Provider) resolve(ctx context.Context, environment string) ([]sink, error) {
+	if sinks, ok := p.sinks[environment]; ok {
+		return sinks, nil
+	}
+
+	var sinks []sink
+	if id := provider.EnvSetting(p.config, environment, "service_id"); id != "" {
+		sinks = append(sinks, sink{name: id, serviceID: id})
+	}
+	if group := provider.EnvSetting(p.config, environment, "env_group"); group != "" {
+		id := group
+		if !strings.HasPrefix(group, "evg-") {
+			var err error
+			if id, err = p.client.FindEnvGroup(ctx, group); err != nil {
+				return nil, err
+			}
+		}
+		sinks = append(sinks, sink{name: group, groupID: id})
+	}
+	if path := provider.EnvSetting(p.config, environment, "blueprint"); path != "" {
+		names, err := blueprintServices(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			id, err := p.client.FindService(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink{name: name, serviceID: id})
+		}
+	}
+
+	p.sinks[environment] = sinks
+	return sinks, nil
+}
+
+// read returns the current variables of a destination, and for a group the
+// services linked to it
+func (p *Provider) read(ctx context.Context, s sink) (map[string]string, []string, error) {
+	var envVars []EnvVar
+	var services []string
+	if s.groupID != "" {
+		group, err := p.client.GetEnvGroup(ctx, s.groupID)
+		if err != nil {
+			return nil, nil, err
+		}
+		envVars = group.EnvVars
+		for _, link := range group.ServiceLinks {
+			services = append(services, link.ID)
+		}
+	} else {
+		var err error
+		if envVars, err = p.client.ListEnvVars(ctx, s.serviceID); err != nil {
+			return nil, nil, err
+		}
+		services = []string{s.serviceID}
+	}
+
+	vars := make(map[string]string, len(envVars))
+	for _, ev := range envVars {
+		vars[ev.Key] = ev.Value
+	}
+	return vars, services, nil
+}
+
+// List returns the variables every destination has. A variable whose value
+// differs between them is reported empty, so a sync rewrites it.
 func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	envs, err := p.client.ListEnvVars(ctx)
+	sinks, err := p.resolve(ctx, environment)
 	if err != nil {
 		return nil, err
 	}
 
+	var common map[string]string
+	for _, s := range sinks {
+		vars, _, err := p.read(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		if common == nil {
+			common = vars
+			continue
+		}
+		for name, value := range common {
+			if other, ok := vars[name]; !ok {
+				delete(common, name)
+			} else if other != value {
+				common[name] = ""
+			}
+		}
+	}
+
 	var secrets []model.SecretValue
-	for _, env := range envs {
+	for name, value := range common {
 		secrets = append(secrets, model.SecretValue{
-			Name:        env.Key,
-			Value:       env.Value,
+			Name:        name,
+			Value:       value,
 			Environment: environment,
 		})
 	}
@@ -80,9 +199,112 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 }
 
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	return p.client.SetEnvVar(ctx, name, value)
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes changed values to every destination. With defer_deploy,
+// each service's variables are replaced in one update and every affected
+// service, including those linked to a group, is deployed once on Deploy.
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	changes := make(map[string]*string, len(values))
+	for name, value := range values {
+		changes[name] = &value
+	}
+	return p.update(ctx, environment, changes)
 }
 
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	return p.client.DeleteEnvVar(ctx, name)
+	return p.update(ctx, environment, map[string]*string{name: nil})
+}
+
+// update applies changes to an environment. A nil value deletes the key.
+// With defer_deploy, the affected services are recorded for Deploy.
+func (p *Provider) update(ctx context.Context, environment string, changes map[string]*string) error {
+	sinks, err := p.resolve(ctx, environment)
+	if err != nil {
+		return err
+	}
+	deferDeploy, _ := p.config["defer_deploy"].(bool)
+
+	for _, s := range sinks {
+		current, services, err := p.read(ctx, s)
+		if err != nil {
+			return err
+		}
+
+		var changed []string
+		for _, name := range slices.Sorted(maps.Keys(changes)) {
+			value, exists := current[name]
+			if v := changes[name]; (v == nil && exists) || (v != nil && (!exists || value != *v)) {
+				changed = append(changed, name)
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		if err := p.write(ctx, s, current, changed, changes, deferDeploy); err != nil {
+			return fmt.Errorf("render: failed to update %s: %w", s.name, err)
+		}
+		if !deferDeploy {
+			continue
+		}
+		for _, id := range services {
+			if !slices.Contains(p.pending[environment], id) {
+				p.pending[environment] = append(p.pending[environment], id)
+			}
+		}
+	}
+	return nil
+}
+
+// Deploy deploys each service whose variables changed since the last
+// Deploy, once. Without defer_deploy nothing is pending.
+func (p *Provider) Deploy(ctx context.Context, environment string) error {
+	for len(p.pending[environment]) > 0 {
+		id := p.pending[environment][0]
+		if err := p.client.TriggerDeploy(ctx, id); err != nil {
+			return fmt.Errorf("render: failed to deploy %s: %w", id, err)
+		}
+		p.pending[environment] = p.pending[environment][1:]
+	}
+	return nil
+}
+
+// write applies the changed keys to one destination
+func (p *Provider) write(ctx context.Context, s sink, current map[string]string, changed []string, changes map[string]*string, replace bool) error {
+	if s.serviceID != "" && replace {
+		updated := maps.Clone(current)
+		for _, name := range changed {
+			if v := changes[name]; v == nil {
+				delete(updated, name)
+			} else {
+				updated[name] = *v
+			}
+		}
+		envVars := make([]EnvVar, 0, len(updated))
+		for _, name := range slices.Sorted(maps.Keys(updated)) {
+			envVars = append(envVars, EnvVar{Key: name, Value: updated[name]})
+		}
+		return p.client.ReplaceEnvVars(ctx, s.serviceID, envVars)
+	}
+
+	for _, name := range changed {
+		v := changes[name]
+		var err error
+		switch {
+		case s.groupID != "" && v == nil:
+			err = p.client.DeleteEnvGroupVar(ctx, s.groupID, name)
+		case s.groupID != "":
+			err = p.client.SetEnvGroupVar(ctx, s.groupID, name, *v)
+		case v == nil:
+			err = p.client.DeleteEnvVar(ctx, s.serviceID, name)
+		default:
+			err = p.client.SetEnvVar(ctx, s.serviceID, name, *v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
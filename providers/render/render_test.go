@@ -1,6 +1,18 @@
 package render
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -58,8 +70,8 @@ func TestNew_TokenFromConfig(t *testing.T) {
 	}
 
 	p := prov.(*Provider)
-	if p.serviceID != "srv-abc123def456" {
-		t.Errorf("serviceID = %q, want 'srv-abc123def456'", p.serviceID)
+	if p.client.apiKey != "rnd_key_456" {
+		t.Errorf("apiKey = %q, want 'rnd_key_456'", p.client.apiKey)
 	}
 }
 
@@ -75,6 +87,18 @@ func TestProvider_Environments(t *testing.T) {
 	if len(envs) != 1 || envs[0] != "default" {
 		t.Errorf("Environments() = %v, want ['default']", envs)
 	}
+
+	config["environments"] = map[string]map[string]string{
+		"staging":    {"service_id": "srv-staging"},
+		"production": {"env_group": "prod-secrets"},
+	}
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if envs := prov.Environments(); !slices.Equal(envs, []string{"production", "staging"}) {
+		t.Errorf("Environments() = %v, want configured environments", envs)
+	}
 }
 
 func TestProvider_DefaultMapping(t *testing.T) {
@@ -90,3 +114,271 @@ func TestProvider_DefaultMapping(t *testing.T) {
 		t.Errorf("DefaultMapping()[default] = %q, want 'test'", mapping["default"])
 	}
 }
+
+func TestNew_EnvGroupOrBlueprint(t *testing.T) {
+	for _, key := range []string{"env_group", "blueprint"} {
+		config := map[string]any{
+			"_resolved_token": "rnd_key_123",
+			key:               "value",
+		}
+		if _, err := New(config); err != nil {
+			t.Errorf("New with %s failed: %v", key, err)
+		}
+	}
+}
+
+func TestBlueprintServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "render.yaml")
+	blueprint := `services:
+  - type: web
+    name: api
+    envVars:
+      - fromGroup: shared
+  - type: worker
+    name: jobs
+  - type: keyvalue
+    name: cache
+envVarGroups:
+  - name: shared
+`
+	if err := os.WriteFile(path, []byte(blueprint), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := blueprintServices(path)
+	if err != nil {
+		t.Fatalf("blueprintServices failed: %v", err)
+	}
+	if !slices.Equal(names, []string{"api", "jobs"}) {
+		t.Errorf("services = %v, want [api jobs]", names)
+	}
+
+	if _, err := blueprintServices(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for a missing blueprint")
+	}
+}
+
+// fakeRender is an in-memory stand-in for the Render API
+type fakeRender struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	services map[string]string            // name -> ID
+	vars     map[string]map[string]string // service or group ID -> variables
+	groups   map[string]string            // name -> ID
+	links    map[string][]string          // group ID -> linked service IDs
+	requests []string
+}
+
+func newFakeRender(t *testing.T) *fakeRender {
+	f := &fakeRender{
+		services: map[string]string{"api": "srv-api", "jobs": "srv-jobs"},
+		groups:   map[string]string{"shared": "evg-shared"},
+		links:    map[string][]string{"evg-shared": {"srv-api", "srv-jobs"}},
+		vars: map[string]map[string]string{
+			"srv-api":    {},
+			"srv-jobs":   {},
+			"evg-shared": {},
+		},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeRender) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer rnd_key_123" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"unauthorized"}`))
+		return
+	}
+	if r.Method != "GET" {
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	encode := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/services":
+		var result []any
+		if id, ok := f.services[r.URL.Query().Get("name")]; ok {
+			result = append(result, map[string]any{"service": map[string]string{"id": id, "name": r.URL.Query().Get("name")}})
+		}
+		encode(result)
+
+	case r.Method == "GET" && r.URL.Path == "/env-groups":
+		var result []any
+		if id, ok := f.groups[r.URL.Query().Get("name")]; ok {
+			result = append(result, map[string]string{"id": id, "name": r.URL.Query().Get("name")})
+		}
+		encode(result)
+
+	case r.Method == "GET" && len(parts) == 2 && parts[0] == "env-groups":
+		group := map[string]any{"id": parts[1]}
+		var envVars []EnvVar
+		for _, k := range slices.Sorted(maps.Keys(f.vars[parts[1]])) {
+			envVars = append(envVars, EnvVar{Key: k, Value: f.vars[parts[1]][k]})
+		}
+		var links []map[string]string
+		for _, id := range f.links[parts[1]] {
+			links = append(links, map[string]string{"id": id})
+		}
+		group["envVars"], group["serviceLinks"] = envVars, links
+		encode(group)
+
+	case r.Method == "GET" && len(parts) == 3 && parts[2] == "env-vars":
+		// Paged by cursor, which is the last key returned
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var page []any
+		for _, k := range slices.Sorted(maps.Keys(f.vars[parts[1]])) {
+			if k > r.URL.Query().Get("cursor") && len(page) < limit {
+				page = append(page, map[string]any{"envVar": EnvVar{Key: k, Value: f.vars[parts[1]][k]}, "cursor": k})
+			}
+		}
+		encode(page)
+
+	case r.Method == "PUT" && len(parts) == 3:
+		var envVars []EnvVar
+		_ = json.NewDecoder(r.Body).Decode(&envVars)
+		f.vars[parts[1]] = map[string]string{}
+		for _, ev := range envVars {
+			f.vars[parts[1]][ev.Key] = ev.Value
+		}
+		encode(envVars)
+
+	case r.Method == "PUT" && len(parts) == 4:
+		var body struct{ Value string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.vars[parts[1]][parts[3]] = body.Value
+		encode(EnvVar{Key: parts[3], Value: body.Value})
+
+	case r.Method == "DELETE" && len(parts) == 4:
+		delete(f.vars[parts[1]], parts[3])
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "POST" && len(parts) == 3 && parts[2] == "deploys":
+		w.WriteHeader(http.StatusCreated)
+		encode(map[string]string{"id": "dep-1"})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"not found"}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeRender, config map[string]any) *Provider {
+	config["_resolved_token"] = "rnd_key_123"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func listed(t *testing.T, p *Provider, env string) map[string]string {
+	t.Helper()
+	secrets, err := p.List(context.Background(), env)
+	if err != nil {
+		t.Fatalf("List(%s) failed: %v", env, err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	return got
+}
+
+func TestProvider_EnvGroup(t *testing.T) {
+	f := newFakeRender(t)
+	f.vars["evg-shared"]["API_KEY"] = "old"
+	p := newTestProvider(t, f, map[string]any{"env_group": "shared"})
+	ctx := context.Background()
+
+	if got := listed(t, p, "default"); got["API_KEY"] != "old" {
+		t.Errorf("List = %v", got)
+	}
+	if err := p.SetMany(ctx, map[string]string{"API_KEY": "new", "DEBUG": "1"}, "default"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if err := p.Delete(ctx, "DEBUG", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := f.vars["evg-shared"]; !maps.Equal(got, map[string]string{"API_KEY": "new"}) {
+		t.Errorf("group vars = %v", got)
+	}
+	want := []string{
+		"PUT /env-groups/evg-shared/env-vars/API_KEY",
+		"PUT /env-groups/evg-shared/env-vars/DEBUG",
+		"DELETE /env-groups/evg-shared/env-vars/DEBUG",
+	}
+	if !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+}
+
+func TestProvider_BlueprintDeferredDeploy(t *testing.T) {
+	f := newFakeRender(t)
+	for i := range pageSize + 5 {
+		f.vars["srv-api"][fmt.Sprintf("VAR_%03d", i)] = "x"
+	}
+	f.vars["srv-api"]["API_KEY"] = "same"
+	f.vars["srv-jobs"]["API_KEY"] = "same"
+	path := filepath.Join(t.TempDir(), "render.yaml")
+	if err := os.WriteFile(path, []byte("services:\n  - type: web\n    name: api\n  - type: worker\n    name: jobs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProvider(t, f, map[string]any{
+		"blueprint":    path,
+		"env_group":    "evg-shared",
+		"defer_deploy": true,
+	})
+	ctx := context.Background()
+
+	if got := listed(t, p, "default"); len(got) != 0 {
+		t.Errorf("List = %v, want nothing since the group is empty", got)
+	}
+
+	if err := p.SetMany(ctx, map[string]string{"API_KEY": "same", "DEBUG": "1", "TMP": "t"}, "default"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if err := p.Delete(ctx, "TMP", "default"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := f.vars["srv-api"]; len(got) != pageSize+7 || got["DEBUG"] != "1" || got["VAR_104"] != "x" {
+		t.Errorf("api has %d vars, DEBUG = %q: the update should keep every page", len(got), got["DEBUG"])
+	}
+	if slices.ContainsFunc(f.requests, func(r string) bool { return strings.HasSuffix(r, "/deploys") }) {
+		t.Errorf("requests = %v, want no deploys before Deploy", f.requests)
+	}
+
+	// Every affected service deploys once, however many writes there were
+	if err := p.Deploy(ctx, "default"); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if err := p.Deploy(ctx, "default"); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	want := []string{
+		"PUT /env-groups/evg-shared/env-vars/API_KEY",
+		"PUT /env-groups/evg-shared/env-vars/DEBUG",
+		"PUT /env-groups/evg-shared/env-vars/TMP",
+		"PUT /services/srv-api/env-vars",
+		"PUT /services/srv-jobs/env-vars",
+		"DELETE /env-groups/evg-shared/env-vars/TMP",
+		"PUT /services/srv-api/env-vars",
+		"PUT /services/srv-jobs/env-vars",
+		"POST /services/srv-api/deploys",
+		"POST /services/srv-jobs/deploys",
+	}
+	if !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+
+	if got := listed(t, p, "default"); !maps.Equal(got, map[string]string{"API_KEY": "same", "DEBUG": "1"}) {
+		t.Errorf("List = %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// errCASMismatch is returned when a write's check-and-set version is stale
+var errCASMismatch = errors.New("vault: secret was modified concurrently")
+
+// Client handles Vault HTTP API requests
+type Client struct {
+	address   string
+	namespace string
+	http      *http.Client
+
+	mu       sync.Mutex
+	token    string
+	roleID   string
+	secretID string
+}
+
+// NewClient creates a new Vault API client. If token is empty, the client
+// logs in with AppRole on first use.
+func NewClient(address, namespace, token, roleID, secretID string) *Client {
+	return &Client{
+		address:   strings.TrimRight(address, "/"),
+		namespace: namespace,
+		http:      &http.Client{},
+		token:     token,
+		roleID:    roleID,
+		secretID:  secretID,
+	}
+}
+
+// KVSecret is the latest version of a KV v2 secret
+type KVSecret struct {
+	Data    map[string]string
+	Version int // 0 if the secret does not exist yet
+}
+
+// ReadKV reads the latest version of a KV v2 secret
+func (c *Client) ReadKV(ctx context.Context, mount, path string) (*KVSecret, error) {
+	resp, err := c.doRequest(ctx, "GET", kvDataPath(mount, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Data struct {
+			Data     map[string]any `json:"data"`
+			Metadata struct {
+				Version int `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// Missing secrets have an empty body; a deleted latest version still
+		// reports its metadata, which check-and-set needs
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return &KVSecret{Data: make(map[string]string), Version: result.Data.Metadata.Version}, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	secret := &KVSecret{
+		Data:    make(map[string]string, len(result.Data.Data)),
+		Version: result.Data.Metadata.Version,
+	}
+	for k, v := range result.Data.Data {
+		switch val := v.(type) {
+		case string:
+			secret.Data[k] = val
+		case nil:
+			secret.Data[k] = ""
+		default:
+			// Non-string values written by other tools are kept as JSON
+			b, _ := json.Marshal(val)
+			secret.Data[k] = string(b)
+		}
+	}
+
+	return secret, nil
+}
+
+// WriteKV writes a new version of a KV v2 secret. The write only succeeds if
+// the current version still equals cas (0 means the secret must not exist).
+func (c *Client) WriteKV(ctx context.Context, mount, path string, data map[string]string, cas int) error {
+	body, err := json.Marshal(map[string]any{
+		"options": map[string]int{"cas": cas},
+		"data":    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", kvDataPath(mount, path), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		err := c.parseError(resp)
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(err.Error(), "check-and-set") {
+			return errCASMismatch
+		}
+		return err
+	}
+
+	return nil
+}
+
+// LookupSelf validates the client token
+func (c *Client) LookupSelf(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "GET", "/v1/auth/token/lookup-self", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// login exchanges the AppRole credentials for a client token
+func (c *Client) login(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+	if c.roleID == "" || c.secretID == "" {
+		return "", fmt.Errorf("vault: no token, and AppRole role_id/secret_id are not set")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"role_id":   c.roleID,
+		"secret_id": c.secretID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", "/v1/auth/approle/login", body)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AppRole login failed: %w", c.parseError(resp))
+	}
+
+	var result struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault: AppRole login returned no token")
+	}
+
+	c.token = result.Auth.ClientToken
+	return c.token, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	return req, nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	token, err := c.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	return c.http.Do(req)
+}
+
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Errors []string `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if len(errResp.Errors) > 0 {
+			return fmt.Errorf("vault API error: %s", strings.Join(errResp.Errors, "; "))
+		}
+	}
+
+	return fmt.Errorf("vault API error: status %d, body: %s", resp.StatusCode, string(body))
+}
+
+// kvDataPath builds the KV v2 data endpoint for a secret
+func kvDataPath(mount, path string) string {
+	return fmt.Sprintf("/v1/%s/data/%s", strings.Trim(mount, "/"), strings.Trim(path, "/"))
+}
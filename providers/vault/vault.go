@@ -0,0 +1,171 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
+	"github.com/dotenvy-dev/dotenvy/pkg/provider"
+)
+
+func init() {
+	provider.Register(provider.ProviderInfo{
+		Name:        "vault",
+		DisplayName: "HashiCorp Vault",
+		Factory:     New,
+		EnvVar:      "VAULT_TOKEN",
+		SdkAuth:     true, // Resolves VAULT_TOKEN or AppRole itself
+		Beta:        true,
+	})
+}
+
+// defaultMount is the KV v2 mount created by `vault server -dev`
+const defaultMount = "secret"
+
+// Provider implements the HashiCorp Vault KV v2 provider. All keys for an
+// environment are stored as fields of one secret at mount/path.
+type Provider struct {
+	client *Client
+	config map[string]any
+}
+
+// New creates a new Vault provider
+func New(config map[string]any) (provider.SyncTarget, error) {
+	address, _ := config["address"].(string)
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, fmt.Errorf("vault: address is required (or set VAULT_ADDR)")
+	}
+
+	namespace, _ := config["namespace"].(string)
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+
+	token, _ := config["_resolved_token"].(string)
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		t, _ := config["token"].(string)
+		token = os.ExpandEnv(t)
+	}
+
+	roleID := provider.ConfigOrEnv(config, "role_id", "VAULT_ROLE_ID")
+	secretID := provider.ConfigOrEnv(config, "secret_id", "VAULT_SECRET_ID")
+	if token == "" && (roleID == "" || secretID == "") {
+		return nil, fmt.Errorf("vault: VAULT_TOKEN or AppRole role_id and secret_id are required")
+	}
+
+	if len(provider.ConfiguredEnvironments(config)) == 0 {
+		if path, _ := config["path"].(string); path == "" {
+			return nil, fmt.Errorf("vault: path is required")
+		}
+	}
+
+	return &Provider{
+		client: NewClient(address, namespace, token, roleID, secretID),
+		config: config,
+	}, nil
+}
+
+func (p *Provider) Name() string        { return "vault" }
+func (p *Provider) DisplayName() string { return "HashiCorp Vault" }
+
+func (p *Provider) Environments() []string {
+	if envs := provider.ConfiguredEnvironments(p.config); len(envs) > 0 {
+		return envs
+	}
+	return []string{"development", "production"}
+}
+
+func (p *Provider) DefaultMapping() map[string]string {
+	return map[string]string{
+		"development": "test",
+		"production":  "live",
+	}
+}
+
+func (p *Provider) Validate(ctx context.Context) error {
+	return p.client.LookupSelf(ctx)
+}
+
+// location returns the mount and secret path for a remote environment
+func (p *Provider) location(environment string) (string, string, error) {
+	mount := provider.EnvSetting(p.config, environment, "mount")
+	if mount == "" {
+		mount = defaultMount
+	}
+	path := provider.EnvSetting(p.config, environment, "path")
+	if path == "" {
+		return "", "", fmt.Errorf("vault: no path configured for environment %q", environment)
+	}
+	return mount, path, nil
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	mount, path, err := p.location(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := p.client.ReadKV(ctx, mount, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []model.SecretValue
+	for name, value := range secret.Data {
+		secrets = append(secrets, model.SecretValue{
+			Name:        name,
+			Value:       value,
+			Environment: environment,
+		})
+	}
+
+	return secrets, nil
+}
+
+func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
+	return p.update(ctx, environment, func(data map[string]string) bool {
+		if current, ok := data[name]; ok && current == value {
+			return false
+		}
+		data[name] = value
+		return true
+	})
+}
+
+func (p *Provider) Delete(ctx context.Context, name, environment string) error {
+	return p.update(ctx, environment, func(data map[string]string) bool {
+		if _, ok := data[name]; !ok {
+			return false
+		}
+		delete(data, name)
+		return true
+	})
+}
+
+// update applies a read-modify-write to an environment's secret using the
+// KV version for check-and-set, retrying if another writer got there first.
+// modify returns false if no write is needed.
+func (p *Provider) update(ctx context.Context, environment string, modify func(map[string]string) bool) error {
+	mount, path, err := p.location(environment)
+	if err != nil {
+		return err
+	}
+
+	return provider.RetryOnConflict(errCASMismatch, fmt.Sprintf("vault: %s/%s", mount, path), func() error {
+		secret, err := p.client.ReadKV(ctx, mount, path)
+		if err != nil {
+			return err
+		}
+		if !modify(secret.Data) {
+			return nil
+		}
+		return p.client.WriteKV(ctx, mount, path, secret.Data, secret.Version)
+	})
+}
@@ -0,0 +1,277 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault is an in-memory stand-in for the KV v2 and AppRole HTTP APIs
+type fakeVault struct {
+	mu         sync.Mutex
+	token      string
+	namespace  string
+	secrets    map[string]map[string]string // "mount/path" -> data
+	versions   map[string]int
+	writes     int
+	raceWrites int // Bump the version behind the client's back this many times
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	f := &fakeVault{
+		token:    "root",
+		secrets:  make(map[string]map[string]string),
+		versions: make(map[string]int),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeVault) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "s3cret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"` + f.token + `"}}`))
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	if r.Header.Get("X-Vault-Namespace") != f.namespace {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":["no handler for route"]}`))
+		return
+	}
+
+	if r.URL.Path == "/v1/auth/token/lookup-self" {
+		_, _ = w.Write([]byte(`{"data":{}}`))
+		return
+	}
+
+	mount, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/"), "/data/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := mount + "/" + path
+
+	switch r.Method {
+	case "GET":
+		data, exists := f.secrets[key]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data":     data,
+				"metadata": map[string]int{"version": f.versions[key]},
+			},
+		})
+	case "POST":
+		var body struct {
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+			Data map[string]string `json:"data"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if f.raceWrites > 0 {
+			f.raceWrites--
+			f.versions[key]++
+			if f.secrets[key] == nil {
+				f.secrets[key] = map[string]string{}
+			}
+		}
+		if body.Options.CAS == nil || *body.Options.CAS != f.versions[key] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+			return
+		}
+
+		f.secrets[key] = body.Data
+		f.versions[key]++
+		f.writes++
+		_, _ = w.Write([]byte(`{"data":{"version":1}}`))
+	}
+}
+
+func newTestProvider(t *testing.T, srv *httptest.Server, config map[string]any) *Provider {
+	t.Helper()
+	config["address"] = srv.URL
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return prov.(*Provider)
+}
+
+func TestNew_MissingAddress(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	_, err := New(map[string]any{"token": "root", "path": "myapp"})
+	if err == nil {
+		t.Error("expected error for missing address")
+	}
+}
+
+func TestNew_MissingAuth(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE_ID", "")
+	t.Setenv("VAULT_SECRET_ID", "")
+	_, err := New(map[string]any{"address": "http://127.0.0.1:8200", "path": "myapp"})
+	if err == nil {
+		t.Error("expected error when neither a token nor AppRole is configured")
+	}
+}
+
+func TestNew_MissingPath(t *testing.T) {
+	_, err := New(map[string]any{"address": "http://127.0.0.1:8200", "token": "root"})
+	if err == nil {
+		t.Error("expected error for missing path")
+	}
+}
+
+func TestProvider_SetAndList(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	f, srv := newFakeVault(t)
+	p := newTestProvider(t, srv, map[string]any{"token": "root", "path": "myapp"})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "API_KEY", "abc", "development"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "DB_URL", "postgres://x", "development"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	secrets, err := p.List(ctx, "development")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	if got["API_KEY"] != "abc" || got["DB_URL"] != "postgres://x" {
+		t.Errorf("List() = %v", got)
+	}
+	if f.versions["secret/myapp"] != 2 {
+		t.Errorf("version = %d, want 2", f.versions["secret/myapp"])
+	}
+
+	// Unchanged values don't create a new version
+	if err := p.Set(ctx, "API_KEY", "abc", "development"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if f.writes != 2 {
+		t.Errorf("writes = %d, want 2", f.writes)
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "development"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.secrets["secret/myapp"]["API_KEY"]; ok {
+		t.Error("API_KEY should be deleted")
+	}
+}
+
+func TestProvider_PerEnvironmentLocation(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	f, srv := newFakeVault(t)
+	p := newTestProvider(t, srv, map[string]any{
+		"token": "root",
+		"path":  "myapp",
+		"environments": map[string]map[string]string{
+			"development": {"path": "myapp/dev"},
+			"production":  {"mount": "kv-prod"},
+		},
+	})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "KEY", "dev", "development"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "KEY", "prod", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if f.secrets["secret/myapp/dev"]["KEY"] != "dev" {
+		t.Errorf("development secret = %v", f.secrets["secret/myapp/dev"])
+	}
+	if f.secrets["kv-prod/myapp"]["KEY"] != "prod" {
+		t.Errorf("production secret = %v", f.secrets["kv-prod/myapp"])
+	}
+
+	envs := p.Environments()
+	if len(envs) != 2 || envs[0] != "development" || envs[1] != "production" {
+		t.Errorf("Environments() = %v", envs)
+	}
+}
+
+func TestProvider_CASRetry(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	f, srv := newFakeVault(t)
+	p := newTestProvider(t, srv, map[string]any{"token": "root", "path": "myapp"})
+	ctx := context.Background()
+
+	// One concurrent write is retried
+	f.raceWrites = 1
+	if err := p.Set(ctx, "KEY", "v1", "development"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Persistent contention gives up
+	f.raceWrites = 100
+	if err := p.Set(ctx, "KEY", "v2", "development"); err == nil {
+		t.Error("expected error after repeated check-and-set failures")
+	}
+}
+
+func TestProvider_AppRoleAndNamespace(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	f, srv := newFakeVault(t)
+	f.token = "approle-token"
+	f.namespace = "team-a"
+	p := newTestProvider(t, srv, map[string]any{
+		"path":      "myapp",
+		"namespace": "team-a",
+		"role_id":   "role",
+		"secret_id": "s3cret",
+	})
+
+	if err := p.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := p.Set(context.Background(), "KEY", "value", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+}
+
+func TestProvider_InvalidToken(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	_, srv := newFakeVault(t)
+	p := newTestProvider(t, srv, map[string]any{"token": "wrong", "path": "myapp"})
+
+	err := p.Validate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Validate() error = %v, want permission denied", err)
+	}
+}
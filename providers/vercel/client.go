@@ -10,10 +10,11 @@ import (
 	"net/url"
 )
 
-const baseURL = "https://api.vercel.com"
+const defaultBaseURL = "https://api.vercel.com"
 
 // Client handles Vercel API requests
 type Client struct {
+	baseURL   string
 	token     string
 	projectID string
 	teamID    string
@@ -23,6 +24,7 @@ type Client struct {
 // NewClient creates a new Vercel API client
 func NewClient(token, projectID, teamID string) *Client {
 	return &Client{
+		baseURL:   defaultBaseURL,
 		token:     token,
 		projectID: projectID,
 		teamID:    teamID,
@@ -30,14 +32,28 @@ func NewClient(token, projectID, teamID string) *Client {
 	}
 }
 
+// Environment variable types
+const (
+	TypePlain     = "plain"
+	TypeEncrypted = "encrypted"
+	TypeSensitive = "sensitive" // Value can't be read back after it's written
+)
+
 // EnvVar represents a Vercel environment variable
 type EnvVar struct {
-	ID        string   `json:"id,omitempty"`
-	Key       string   `json:"key"`
-	Value     string   `json:"value"`
-	Target    []string `json:"target"`
-	Type      string   `json:"type"`
-	GitBranch string   `json:"gitBranch,omitempty"`
+	ID                   string   `json:"id,omitempty"`
+	Key                  string   `json:"key"`
+	Value                string   `json:"value,omitempty"`
+	Target               []string `json:"target"`
+	CustomEnvironmentIDs []string `json:"customEnvironmentIds,omitempty"`
+	Type                 string   `json:"type"`
+	GitBranch            string   `json:"gitBranch,omitempty"`
+}
+
+// CustomEnvironment represents a Vercel custom environment
+type CustomEnvironment struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
 }
 
 // ListResponse represents the response from listing env vars
@@ -67,48 +83,111 @@ func (c *Client) ListEnvVars(ctx context.Context) ([]EnvVar, error) {
 	return result.Envs, nil
 }
 
-// CreateEnvVar creates a new environment variable
-func (c *Client) CreateEnvVar(ctx context.Context, env EnvVar) error {
-	endpoint := fmt.Sprintf("/v10/projects/%s/env", url.PathEscape(c.projectID))
+// ListCustomEnvironments retrieves the project's custom environments
+func (c *Client) ListCustomEnvironments(ctx context.Context) ([]CustomEnvironment, error) {
+	endpoint := fmt.Sprintf("/v9/projects/%s/custom-environments", url.PathEscape(c.projectID))
 
-	body, err := json.Marshal(env)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Environments []CustomEnvironment `json:"environments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Environments, nil
+}
+
+// CreateEnvVars creates environment variables in one request. With upsert,
+// a variable that already exists for the same environment is replaced.
+// Variables created before an error are returned along with it.
+func (c *Client) CreateEnvVars(ctx context.Context, envs []EnvVar) ([]EnvVar, error) {
+	endpoint := fmt.Sprintf("/v10/projects/%s/env?upsert=true", url.PathEscape(c.projectID))
+
+	body, err := json.Marshal(envs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	resp, err := c.doRequest(ctx, "POST", endpoint, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return c.parseError(resp)
+		return nil, c.parseError(resp)
 	}
 
-	return nil
+	// created is a single object when one variable was created
+	var result struct {
+		Created json.RawMessage `json:"created"`
+		Failed  []struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+				Key     string `json:"key"`
+			} `json:"error"`
+		} `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var created []EnvVar
+	switch trimmed := bytes.TrimSpace(result.Created); {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+	case trimmed[0] == '[':
+		err = json.Unmarshal(trimmed, &created)
+	default:
+		var e EnvVar
+		err = json.Unmarshal(trimmed, &e)
+		created = []EnvVar{e}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Failed) > 0 {
+		f := result.Failed[0].Error
+		return created, fmt.Errorf("vercel API error: %s: %s (%s)", f.Key, f.Message, f.Code)
+	}
+	return created, nil
 }
 
-// UpdateEnvVar updates an existing environment variable
-func (c *Client) UpdateEnvVar(ctx context.Context, envID string, env EnvVar) error {
+// UpdateEnvVar updates an existing environment variable and returns it
+func (c *Client) UpdateEnvVar(ctx context.Context, envID string, env EnvVar) (EnvVar, error) {
 	endpoint := fmt.Sprintf("/v9/projects/%s/env/%s", url.PathEscape(c.projectID), url.PathEscape(envID))
 
 	body, err := json.Marshal(env)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return EnvVar{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	resp, err := c.doRequest(ctx, "PATCH", endpoint, body)
 	if err != nil {
-		return err
+		return EnvVar{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return c.parseError(resp)
+		return EnvVar{}, c.parseError(resp)
 	}
 
-	return nil
+	var updated EnvVar
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return EnvVar{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return updated, nil
 }
 
 // DeleteEnvVar deletes an environment variable
@@ -129,7 +208,7 @@ func (c *Client) DeleteEnvVar(ctx context.Context, envID string) error {
 }
 
 func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
-	u := baseURL + endpoint
+	u := c.baseURL + endpoint
 	if c.teamID != "" {
 		if bytes.Contains([]byte(u), []byte("?")) {
 			u += "&teamId=" + url.QueryEscape(c.teamID)
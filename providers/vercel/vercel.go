@@ -3,6 +3,9 @@ package vercel
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/dotenvy-dev/dotenvy/internal/model"
 	"github.com/dotenvy-dev/dotenvy/pkg/provider"
@@ -17,11 +20,16 @@ func init() {
 	})
 }
 
+// standardTargets are the environments every Vercel project has
+var standardTargets = []string{"development", "preview", "production"}
+
 // Provider implements the Vercel secrets provider
 type Provider struct {
-	client  *Client
-	project string
-	envVars map[string]EnvVar // Cache of env vars by key
+	client     *Client
+	project    string
+	config     map[string]any
+	envVars    []EnvVar          // The project's env vars, loaded once per run
+	customEnvs map[string]string // Custom environment IDs by slug, loaded on first use
 }
 
 // New creates a new Vercel provider
@@ -44,15 +52,23 @@ func New(config map[string]any) (provider.SyncTarget, error) {
 	return &Provider{
 		client:  NewClient(token, project, teamID),
 		project: project,
-		envVars: make(map[string]EnvVar),
+		config:  config,
 	}, nil
 }
 
 func (p *Provider) Name() string        { return "vercel" }
 func (p *Provider) DisplayName() string { return "Vercel" }
 
+// Environments returns the standard environments followed by any configured
+// ones, such as custom environments or branch previews
 func (p *Provider) Environments() []string {
-	return []string{"development", "preview", "production"}
+	envs := slices.Clone(standardTargets)
+	for _, env := range provider.ConfiguredEnvironments(p.config) {
+		if !slices.Contains(envs, env) {
+			envs = append(envs, env)
+		}
+	}
+	return envs
 }
 
 func (p *Provider) DefaultMapping() map[string]string {
@@ -67,26 +83,130 @@ func (p *Provider) Validate(ctx context.Context) error {
 	return p.client.ValidateToken(ctx)
 }
 
-func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
-	// Fetch all env vars if cache is empty
-	if len(p.envVars) == 0 {
-		envs, err := p.client.ListEnvVars(ctx)
+// scope identifies the env vars of one remote environment: a standard
+// target, optionally narrowed to a git branch, or a custom environment
+type scope struct {
+	target              string
+	customEnvironmentID string
+	gitBranch           string
+}
+
+// scope resolves a remote environment. Environments can set git_branch to
+// scope preview variables to a branch, and target or custom_environment to
+// use a name other than the target or custom environment slug.
+func (p *Provider) scope(ctx context.Context, environment string) (scope, error) {
+	target := provider.EnvSetting(p.config, environment, "target")
+	branch := provider.EnvSetting(p.config, environment, "git_branch")
+	if target == "" && branch != "" {
+		target = "preview"
+	}
+	if target == "" && slices.Contains(standardTargets, environment) {
+		target = environment
+	}
+	if target != "" {
+		if branch != "" && target != "preview" {
+			return scope{}, fmt.Errorf("vercel: git_branch only applies to preview, not %s", target)
+		}
+		return scope{target: target, gitBranch: branch}, nil
+	}
+
+	slug := provider.EnvSetting(p.config, environment, "custom_environment")
+	if slug == "" {
+		slug = environment
+	}
+	if p.customEnvs == nil {
+		envs, err := p.client.ListCustomEnvironments(ctx)
 		if err != nil {
-			return nil, err
+			return scope{}, err
 		}
+		p.customEnvs = make(map[string]string, len(envs))
 		for _, e := range envs {
-			p.envVars[e.Key] = e
+			p.customEnvs[e.Slug] = e.ID
+		}
+	}
+	id, ok := p.customEnvs[slug]
+	if !ok {
+		return scope{}, fmt.Errorf("vercel: project %s has no environment %q", p.project, slug)
+	}
+	return scope{customEnvironmentID: id}, nil
+}
+
+// matches reports whether an env var applies to the scope
+func (s scope) matches(e EnvVar) bool {
+	if s.customEnvironmentID != "" {
+		return slices.Contains(e.CustomEnvironmentIDs, s.customEnvironmentID)
+	}
+	return containsTarget(e.Target, s.target) && e.GitBranch == s.gitBranch
+}
+
+// find returns the env var for key in the scope
+func (p *Provider) find(key string, s scope) (EnvVar, bool) {
+	for _, e := range p.envVars {
+		if e.Key == key && s.matches(e) {
+			return e, true
 		}
 	}
+	return EnvVar{}, false
+}
+
+// load fetches the project's env vars once per run. Writes keep the cache
+// up to date from their responses instead of listing again.
+func (p *Provider) load(ctx context.Context) error {
+	if p.envVars != nil {
+		return nil
+	}
+	envs, err := p.client.ListEnvVars(ctx)
+	if err != nil {
+		return err
+	}
+	if envs == nil {
+		envs = []EnvVar{}
+	}
+	p.envVars = envs
+	return nil
+}
+
+// store records a written variable in the cache, replacing any entry with
+// the same ID. Responses don't always include the value, so the one that
+// was written is kept, except for sensitive variables which can't be read.
+func (p *Provider) store(written, sent EnvVar) {
+	if written.Key == "" {
+		id := written.ID
+		written = sent
+		written.ID = id
+	}
+	written.Value = sent.Value
+	if written.Type == TypeSensitive {
+		written.Value = ""
+	}
+	p.forget(written.ID)
+	p.envVars = append(p.envVars, written)
+}
+
+// forget removes a variable from the cache
+func (p *Provider) forget(id string) {
+	p.envVars = slices.DeleteFunc(p.envVars, func(e EnvVar) bool { return e.ID == id })
+}
+
+func (p *Provider) List(ctx context.Context, environment string) ([]model.SecretValue, error) {
+	s, err := p.scope(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
 
 	var secrets []model.SecretValue
 	for _, env := range p.envVars {
 		// Check if this env var targets the requested environment
-		if containsTarget(env.Target, environment) {
+		if s.matches(env) {
 			secrets = append(secrets, model.SecretValue{
 				Name:        env.Key,
 				Value:       env.Value,
 				Environment: environment,
+				Unreadable:  env.Type == TypeSensitive,
 			})
 		}
 	}
@@ -94,86 +214,199 @@ func (p *Provider) List(ctx context.Context, environment string) ([]model.Secret
 	return secrets, nil
 }
 
+// varType returns the type to write a variable with. Schema metadata
+// decides: sensitive: true uses Vercel's sensitive type (except in
+// development, which doesn't support it), sensitive: false uses plain, and
+// otherwise the existing type is kept.
+func (p *Provider) varType(name string, s scope, existing *EnvVar) string {
+	schema := provider.SecretSchema(p.config, name)
+	switch {
+	case schema.Sensitive == nil && existing != nil:
+		return existing.Type
+	case schema.Sensitive == nil:
+		return TypeEncrypted
+	case !*schema.Sensitive:
+		return TypePlain
+	case s.target == "development":
+		return TypeEncrypted
+	default:
+		return TypeSensitive
+	}
+}
+
+// newVar returns a variable holding value in this environment only
+func (p *Provider) newVar(name, value string, s scope, existing *EnvVar) EnvVar {
+	e := EnvVar{
+		Key:       name,
+		Value:     value,
+		Target:    []string{},
+		Type:      p.varType(name, s, existing),
+		GitBranch: s.gitBranch,
+	}
+	if s.customEnvironmentID != "" {
+		e.CustomEnvironmentIDs = []string{s.customEnvironmentID}
+	} else {
+		e.Target = []string{s.target}
+	}
+	return e
+}
+
 func (p *Provider) Set(ctx context.Context, name, value, environment string) error {
-	// Refresh cache
-	envs, err := p.client.ListEnvVars(ctx)
+	return p.SetMany(ctx, map[string]string{name: value}, environment)
+}
+
+// SetMany writes all values in one bulk upsert. A variable shared with other
+// environments is split: this environment is removed from it and gets a
+// variable of its own, so the others keep their value and type. Vercel
+// rejects a new variable that overlaps an existing one, so this happens
+// before the upsert, and is undone for any value the upsert fails to write.
+func (p *Provider) SetMany(ctx context.Context, values map[string]string, environment string) error {
+	s, err := p.scope(ctx, environment)
 	if err != nil {
 		return err
 	}
-	p.envVars = make(map[string]EnvVar)
-	for _, e := range envs {
-		p.envVars[e.Key] = e
+	if err := p.load(ctx); err != nil {
+		return err
 	}
 
-	existing, exists := p.envVars[name]
+	var writes, detached []EnvVar
+	var deleted []string
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		var previous *EnvVar
+		existing, exists := p.find(name, s)
+		if exists {
+			previous = &existing
+		}
+		write := p.newVar(name, values[name], s, previous)
 
-	if exists {
-		// Check if this existing var targets our environment
-		if containsTarget(existing.Target, environment) {
-			// Update the existing var
-			updated := EnvVar{
-				Key:    name,
-				Value:  value,
-				Target: existing.Target,
-				Type:   "encrypted",
+		switch {
+		case !exists:
+		case len(existing.Target)+len(existing.CustomEnvironmentIDs) > 1:
+			if err := p.detach(ctx, existing, s); err != nil {
+				return p.restore(ctx, err, detached, deleted, nil)
 			}
-			return p.client.UpdateEnvVar(ctx, existing.ID, updated)
+			detached = append(detached, existing)
+		case existing.Type == TypeSensitive && write.Type != TypeSensitive:
+			// Vercel can't make a sensitive variable readable again, so it
+			// is recreated
+			if err := p.client.DeleteEnvVar(ctx, existing.ID); err != nil {
+				return p.restore(ctx, err, detached, deleted, nil)
+			}
+			p.forget(existing.ID)
+			deleted = append(deleted, name)
+		}
+		writes = append(writes, write)
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	// Variables this environment already had are replaced by the upsert
+	created, err := p.client.CreateEnvVars(ctx, writes)
+	written := make(map[string]bool, len(created))
+	for _, c := range created {
+		i := slices.IndexFunc(writes, func(w EnvVar) bool { return w.Key == c.Key })
+		if i < 0 {
+			continue
+		}
+		if replaced, ok := p.find(c.Key, s); ok {
+			p.forget(replaced.ID)
+		}
+		p.store(c, writes[i])
+		written[c.Key] = true
+	}
+	if err != nil {
+		return p.restore(ctx, err, detached, deleted, written)
+	}
+	return nil
+}
+
+// restore gives this environment back the variables SetMany detached it
+// from whose new value wasn't written, after cause stopped the write. A
+// sensitive variable that was deleted can't be put back, since its value
+// can't be read, so the error names it instead.
+func (p *Provider) restore(ctx context.Context, cause error, detached []EnvVar, deleted []string, written map[string]bool) error {
+	for _, e := range detached {
+		if written[e.Key] {
+			continue
 		}
-		// Var exists but for different environment - add our environment
-		updated := EnvVar{
-			Key:    name,
-			Value:  value,
-			Target: append(existing.Target, environment),
-			Type:   "encrypted",
+		restored := e
+		restored.ID = ""
+		restored.Value = ""
+		if restored.Target == nil {
+			restored.Target = []string{}
 		}
-		return p.client.UpdateEnvVar(ctx, existing.ID, updated)
+		w, err := p.client.UpdateEnvVar(ctx, e.ID, restored)
+		if err != nil {
+			return fmt.Errorf("%w; putting %s back also failed: %v", cause, e.Key, err)
+		}
+		if w.ID == "" {
+			w.ID = e.ID
+		}
+		p.store(w, e)
 	}
 
-	// Create new env var
-	newVar := EnvVar{
-		Key:    name,
-		Value:  value,
-		Target: []string{environment},
-		Type:   "encrypted",
+	var lost []string
+	for _, name := range deleted {
+		if !written[name] {
+			lost = append(lost, name)
+		}
+	}
+	if len(lost) > 0 {
+		return fmt.Errorf("%w; %s had to be deleted to stop being sensitive and has no value until it's set again", cause, strings.Join(lost, ", "))
 	}
-	return p.client.CreateEnvVar(ctx, newVar)
+	return cause
 }
 
 func (p *Provider) Delete(ctx context.Context, name, environment string) error {
-	// Refresh cache
-	envs, err := p.client.ListEnvVars(ctx)
+	s, err := p.scope(ctx, environment)
 	if err != nil {
 		return err
 	}
-	p.envVars = make(map[string]EnvVar)
-	for _, e := range envs {
-		p.envVars[e.Key] = e
+	if err := p.load(ctx); err != nil {
+		return err
 	}
 
-	existing, exists := p.envVars[name]
+	existing, exists := p.find(name, s)
 	if !exists {
 		return nil // Already doesn't exist
 	}
+	return p.detach(ctx, existing, s)
+}
 
-	// If var only targets this environment, delete it entirely
-	if len(existing.Target) == 1 && existing.Target[0] == environment {
-		return p.client.DeleteEnvVar(ctx, existing.ID)
+// detach removes this environment from a variable, deleting it if nothing is
+// left. The value is left out so the other environments keep theirs.
+func (p *Provider) detach(ctx context.Context, existing EnvVar, s scope) error {
+	updated := existing
+	updated.ID = ""
+	updated.Value = ""
+	updated.Target = slices.DeleteFunc(slices.Clone(existing.Target), func(t string) bool {
+		return s.customEnvironmentID == "" && t == s.target
+	})
+	updated.CustomEnvironmentIDs = slices.DeleteFunc(slices.Clone(existing.CustomEnvironmentIDs), func(id string) bool {
+		return id == s.customEnvironmentID
+	})
+	if len(updated.Target)+len(updated.CustomEnvironmentIDs) == 0 {
+		if err := p.client.DeleteEnvVar(ctx, existing.ID); err != nil {
+			return err
+		}
+		p.forget(existing.ID)
+		return nil
+	}
+	if updated.Target == nil {
+		updated.Target = []string{}
 	}
 
-	// Remove environment from targets
-	var newTargets []string
-	for _, t := range existing.Target {
-		if t != environment {
-			newTargets = append(newTargets, t)
-		}
+	written, err := p.client.UpdateEnvVar(ctx, existing.ID, updated)
+	if err != nil {
+		return err
 	}
-	updated := EnvVar{
-		Key:    existing.Key,
-		Value:  existing.Value,
-		Target: newTargets,
-		Type:   existing.Type,
+	if written.ID == "" {
+		written.ID = existing.ID
 	}
-	return p.client.UpdateEnvVar(ctx, existing.ID, updated)
+	updated.Value = existing.Value
+	p.store(written, updated)
+	return nil
 }
 
 func containsTarget(targets []string, target string) bool {
@@ -1,7 +1,19 @@
 package vercel
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/dotenvy-dev/dotenvy/internal/model"
 )
 
 func TestNew_ValidConfig(t *testing.T) {
@@ -144,3 +156,422 @@ func TestContainsTarget(t *testing.T) {
 		}
 	}
 }
+
+// fakeVercel is an in-memory stand-in for the Vercel env API
+type fakeVercel struct {
+	mu      sync.Mutex
+	srv     *httptest.Server
+	vars    []EnvVar
+	nextID  int
+	patches []map[string]any
+	deletes int
+	lists   int
+	posts   int
+	reject  string // Key that bulk creates fail for
+}
+
+func newFakeVercel(t *testing.T) *fakeVercel {
+	f := &fakeVercel{}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeVercel) add(e EnvVar) {
+	f.nextID++
+	e.ID = fmt.Sprintf("env_%d", f.nextID)
+	f.vars = append(f.vars, e)
+}
+
+func (f *fakeVercel) index(id string) int {
+	return slices.IndexFunc(f.vars, func(e EnvVar) bool { return e.ID == id })
+}
+
+// conflict returns the variable a new one would clash with: same key and
+// branch, sharing an environment
+func (f *fakeVercel) conflict(e EnvVar) int {
+	return slices.IndexFunc(f.vars, func(v EnvVar) bool {
+		if v.Key != e.Key || v.GitBranch != e.GitBranch {
+			return false
+		}
+		return slices.ContainsFunc(e.Target, func(t string) bool { return containsTarget(v.Target, t) }) ||
+			slices.ContainsFunc(e.CustomEnvironmentIDs, func(id string) bool { return slices.Contains(v.CustomEnvironmentIDs, id) })
+	})
+}
+
+// find returns the stored variable for key in a standard target
+func (f *fakeVercel) find(key, target string) EnvVar {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.vars {
+		if e.Key == key && containsTarget(e.Target, target) && e.GitBranch == "" {
+			return e
+		}
+	}
+	return EnvVar{}
+}
+
+func (f *fakeVercel) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer tok123" || r.URL.Query().Get("teamId") != "team_abc" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"forbidden","message":"Not authorized"}}`))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v9/projects/my-app/env/")
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v9/projects/my-app/env":
+		f.lists++
+		// Sensitive values are never returned
+		envs := slices.Clone(f.vars)
+		for i := range envs {
+			if envs[i].Type == TypeSensitive {
+				envs[i].Value = ""
+			}
+		}
+		_ = json.NewEncoder(w).Encode(ListResponse{Envs: envs})
+
+	case r.Method == "GET" && r.URL.Path == "/v9/projects/my-app/custom-environments":
+		_, _ = w.Write([]byte(`{"environments":[{"id":"env_qa","slug":"qa"}]}`))
+
+	case r.Method == "POST" && r.URL.Path == "/v10/projects/my-app/env":
+		f.posts++
+		var envs []EnvVar
+		_ = json.NewDecoder(r.Body).Decode(&envs)
+		var created []EnvVar
+		var failed []map[string]any
+		for _, e := range envs {
+			if e.Key == f.reject {
+				failed = append(failed, map[string]any{"error": map[string]string{"code": "internal", "key": e.Key, "message": "try again"}})
+				continue
+			}
+			if e.Type == TypeSensitive && containsTarget(e.Target, "development") {
+				failed = append(failed, map[string]any{"error": map[string]string{"code": "bad_request", "key": e.Key, "message": "sensitive is not supported in development"}})
+				continue
+			}
+			if i := f.conflict(e); i >= 0 {
+				if r.URL.Query().Get("upsert") != "true" {
+					failed = append(failed, map[string]any{"error": map[string]string{"code": "ENV_ALREADY_EXISTS", "key": e.Key, "message": "already exists"}})
+					continue
+				}
+				e.ID = f.vars[i].ID
+				f.vars[i] = e
+			} else {
+				f.add(e)
+				e = f.vars[len(f.vars)-1]
+			}
+			e.Value = "" // Responses leave the value out
+			created = append(created, e)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"created": created, "failed": failed})
+
+	case r.Method == "PATCH" && f.index(id) >= 0:
+		body, _ := io.ReadAll(r.Body)
+		var fields map[string]any
+		_ = json.Unmarshal(body, &fields)
+		f.patches = append(f.patches, fields)
+		i := f.index(id)
+		if f.vars[i].Type == TypeSensitive && fields["type"] != TypeSensitive {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"bad_request","message":"cannot change sensitive type"}}`))
+			return
+		}
+		_ = json.Unmarshal(body, &f.vars[i])
+		f.vars[i].ID = id
+		_ = json.NewEncoder(w).Encode(f.vars[i])
+
+	case r.Method == "DELETE" && f.index(id) >= 0:
+		f.vars = slices.Delete(f.vars, f.index(id), f.index(id)+1)
+		f.deletes++
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"not_found","message":"Not found"}}`))
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeVercel, config map[string]any) *Provider {
+	config["_resolved_token"] = "tok123"
+	config["project"] = "my-app"
+	config["team_id"] = "team_abc"
+	prov, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	p := prov.(*Provider)
+	p.client.baseURL = f.srv.URL
+	return p
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func listed(t *testing.T, p *Provider, env string) map[string]string {
+	t.Helper()
+	secrets, err := p.List(context.Background(), env)
+	if err != nil {
+		t.Fatalf("List(%s) failed: %v", env, err)
+	}
+	got := map[string]string{}
+	for _, s := range secrets {
+		got[s.Name] = s.Value
+	}
+	return got
+}
+
+func TestProvider_CustomEnvironmentsAndBranches(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "API_URL", Value: "https://preview", Target: []string{"preview"}, Type: TypeEncrypted})
+	f.add(EnvVar{Key: "API_URL", Value: "https://staging", Target: []string{"preview"}, GitBranch: "staging", Type: TypeEncrypted})
+	f.add(EnvVar{Key: "API_URL", Value: "https://qa", CustomEnvironmentIDs: []string{"env_qa"}, Type: TypeEncrypted})
+	p := newTestProvider(t, f, map[string]any{
+		"environments": map[string]map[string]string{
+			"staging": {"git_branch": "staging"},
+			"qa":      {},
+		},
+	})
+	ctx := context.Background()
+
+	if got := p.Environments(); !slices.Equal(got, []string{"development", "preview", "production", "qa", "staging"}) {
+		t.Errorf("Environments() = %v", got)
+	}
+	for env, want := range map[string]string{"preview": "https://preview", "staging": "https://staging", "qa": "https://qa"} {
+		if got := listed(t, p, env); len(got) != 1 || got["API_URL"] != want {
+			t.Errorf("List(%s) = %v, want API_URL=%s", env, got, want)
+		}
+	}
+
+	if err := p.Set(ctx, "DEBUG", "1", "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "DEBUG", "2", "qa"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := listed(t, p, "staging")["DEBUG"]; got != "1" {
+		t.Errorf("staging DEBUG = %q", got)
+	}
+	if got := listed(t, p, "qa")["DEBUG"]; got != "2" {
+		t.Errorf("qa DEBUG = %q", got)
+	}
+	if _, ok := listed(t, p, "preview")["DEBUG"]; ok {
+		t.Error("branch variable leaked into preview")
+	}
+
+	if err := p.Delete(ctx, "API_URL", "staging"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := listed(t, p, "preview")["API_URL"]; got != "https://preview" {
+		t.Errorf("preview API_URL = %q after deleting the branch variable", got)
+	}
+
+	p.config["environments"] = map[string]map[string]string{"missing": {}}
+	if _, err := p.List(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "no environment") {
+		t.Errorf("List(missing) error = %v", err)
+	}
+}
+
+func TestProvider_ResyncUnchanged(t *testing.T) {
+	f := newFakeVercel(t)
+	config := map[string]any{
+		"_schema": map[string]model.Secret{
+			"API_KEY":    {Sensitive: boolPtr(true)},
+			"PUBLIC_URL": {Sensitive: boolPtr(false)},
+		},
+	}
+	values := map[string]string{"API_KEY": "sk_live", "PUBLIC_URL": "https://shop.example"}
+	if err := newTestProvider(t, f, config).SetMany(context.Background(), values, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+
+	// On the next run the sensitive value comes back empty; it must be
+	// reported as unreadable rather than as a value that differs
+	secrets, err := newTestProvider(t, f, config).List(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("List() = %+v", secrets)
+	}
+	for _, s := range secrets {
+		if s.Unreadable != (s.Name == "API_KEY") || (!s.Unreadable && s.Value != values[s.Name]) {
+			t.Errorf("%s = %+v, want it unreadable or unchanged", s.Name, s)
+		}
+	}
+}
+
+func TestProvider_TypeFromSchema(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "LEGACY", Value: "x", Target: []string{"production"}, Type: TypePlain})
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{
+			"API_KEY":    {Sensitive: boolPtr(true)},
+			"PUBLIC_URL": {Sensitive: boolPtr(false)},
+		},
+	})
+	ctx := context.Background()
+
+	for _, tt := range []struct{ name, env, want string }{
+		{"API_KEY", "production", TypeSensitive},
+		{"API_KEY", "development", TypeEncrypted},
+		{"PUBLIC_URL", "production", TypePlain},
+		{"OTHER", "production", TypeEncrypted},
+		{"LEGACY", "production", TypePlain},
+	} {
+		if err := p.Set(ctx, tt.name, "v", tt.env); err != nil {
+			t.Fatalf("Set(%s, %s) failed: %v", tt.name, tt.env, err)
+		}
+		if e := f.find(tt.name, tt.env); e.Type != tt.want {
+			t.Errorf("%s in %s has type %q, want %q", tt.name, tt.env, e.Type, tt.want)
+		}
+	}
+}
+
+func TestProvider_TypeChangeKeepsTargets(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "API_KEY", Value: "old", Target: []string{"preview", "production"}, Type: TypeEncrypted})
+	f.add(EnvVar{Key: "TOKEN", Value: "", Target: []string{"production"}, Type: TypeSensitive})
+	f.add(EnvVar{Key: "SHARED", Value: "", Target: []string{"preview", "production"}, Type: TypeSensitive})
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{
+			"API_KEY": {Sensitive: boolPtr(true)},
+			"TOKEN":   {Sensitive: boolPtr(false)},
+			"SHARED":  {Sensitive: boolPtr(false)},
+		},
+	})
+	ctx := context.Background()
+
+	// Shared variables are split, leaving the other targets as they were
+	if err := p.Set(ctx, "API_KEY", "new", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if patch := f.patches[0]; patch["type"] != TypeEncrypted || len(patch["target"].([]any)) != 1 {
+		t.Errorf("patch = %v, want encrypted with preview only", patch)
+	}
+	if e := f.find("API_KEY", "preview"); e.Type != TypeEncrypted || e.Value != "old" {
+		t.Errorf("preview API_KEY = %+v", e)
+	}
+	if e := f.find("API_KEY", "production"); e.Type != TypeSensitive || e.Value != "new" || len(e.Target) != 1 {
+		t.Errorf("production API_KEY = %+v", e)
+	}
+
+	// Sensitive variables are recreated to make them readable again
+	if err := p.Set(ctx, "TOKEN", "t", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if e := f.find("TOKEN", "production"); f.deletes != 1 || e.Type != TypePlain || e.Value != "t" {
+		t.Errorf("TOKEN = %+v, deletes = %d", e, f.deletes)
+	}
+
+	if err := p.Set(ctx, "SHARED", "s", "production"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if e := f.find("SHARED", "preview"); e.Type != TypeSensitive || len(e.Target) != 1 {
+		t.Errorf("preview SHARED = %+v", e)
+	}
+	if e := f.find("SHARED", "production"); e.Type != TypePlain || e.Value != "s" {
+		t.Errorf("production SHARED = %+v", e)
+	}
+}
+
+func TestProvider_FailedSetKeepsOldValue(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "API_URL", Value: "https://shared", Target: []string{"preview", "production"}, Type: TypeEncrypted})
+	f.add(EnvVar{Key: "TOKEN", Value: "", Target: []string{"production"}, Type: TypeSensitive})
+	p := newTestProvider(t, f, map[string]any{
+		"_schema": map[string]model.Secret{"TOKEN": {Sensitive: boolPtr(false)}},
+	})
+	ctx := context.Background()
+
+	// The shared variable gets production back when its new value fails
+	f.reject = "API_URL"
+	err := p.SetMany(ctx, map[string]string{"API_URL": "https://prod", "DEBUG": "1"}, "production")
+	if err == nil || !strings.Contains(err.Error(), "try again") {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	if e := f.find("API_URL", "production"); e.Value != "https://shared" || len(e.Target) != 2 {
+		t.Errorf("production API_URL = %+v, want the shared variable back", e)
+	}
+	want := map[string]string{"API_URL": "https://shared", "DEBUG": "1", "TOKEN": ""}
+	for _, fresh := range []*Provider{p, newTestProvider(t, f, map[string]any{})} {
+		if got := listed(t, fresh, "production"); !maps.Equal(got, want) {
+			t.Errorf("List(production) = %v, want %v", got, want)
+		}
+	}
+
+	// A sensitive value can't be put back, so the error says it's gone
+	f.reject = "TOKEN"
+	err = p.Set(ctx, "TOKEN", "t", "production")
+	if err == nil || !strings.Contains(err.Error(), "TOKEN had to be deleted") {
+		t.Errorf("Set() error = %v", err)
+	}
+}
+
+func TestProvider_SetManyUsesCache(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "API_URL", Value: "https://shared", Target: []string{"development", "preview", "production"}, Type: TypeEncrypted})
+	f.add(EnvVar{Key: "DEBUG", Value: "0", Target: []string{"production"}, Type: TypePlain})
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	if err := p.SetMany(ctx, map[string]string{"API_URL": "https://prod", "DEBUG": "1", "NEW": "n"}, "production"); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	if err := p.Set(ctx, "API_URL", "https://preview", "preview"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "NEW", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"development": {"API_URL": "https://shared"},
+		"preview":     {"API_URL": "https://preview"},
+		"production":  {"API_URL": "https://prod", "DEBUG": "1"},
+	}
+	for env, vals := range want {
+		if got := listed(t, p, env); !maps.Equal(got, vals) {
+			t.Errorf("List(%s) = %v, want %v", env, got, vals)
+		}
+	}
+	if f.lists != 1 || f.posts != 2 {
+		t.Errorf("lists = %d, posts = %d, want 1 list and 2 bulk creates", f.lists, f.posts)
+	}
+
+	// A fresh run sees the same
+	fresh := newTestProvider(t, f, map[string]any{})
+	for env, vals := range want {
+		if got := listed(t, fresh, env); !maps.Equal(got, vals) {
+			t.Errorf("fresh List(%s) = %v, want %v", env, got, vals)
+		}
+	}
+}
+
+func TestProvider_DeleteKeepsOtherTargets(t *testing.T) {
+	f := newFakeVercel(t)
+	f.add(EnvVar{Key: "API_KEY", Value: "v", Target: []string{"preview", "production"}, Type: TypeEncrypted})
+	p := newTestProvider(t, f, map[string]any{})
+	ctx := context.Background()
+
+	if err := p.Delete(ctx, "API_KEY", "production"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := f.patches[0]["value"]; ok {
+		t.Errorf("patch = %v, should leave the value alone", f.patches[0])
+	}
+	if got := listed(t, p, "preview")["API_KEY"]; got != "v" {
+		t.Errorf("preview API_KEY = %q", got)
+	}
+	if _, ok := listed(t, p, "production")["API_KEY"]; ok {
+		t.Error("production API_KEY should be gone")
+	}
+
+	if err := p.Delete(ctx, "API_KEY", "preview"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if f.deletes != 1 || len(f.vars) != 0 {
+		t.Errorf("deletes = %d, vars = %v", f.deletes, f.vars)
+	}
+}